@@ -27,7 +27,7 @@ func main() {
 
 	var participants []model.Participant
 	for i := range nParticipants {
-		participant, err := model.NewParticipant(fmt.Sprintf("Vorname%d", i), fmt.Sprintf("Nachname%d", i), secret)
+		participant, err := model.NewParticipant(fmt.Sprintf("Vorname%d", i), fmt.Sprintf("Nachname%d", i), secret, model.WithParticipantId(i+1))
 		if err != nil {
 			panic(err)
 		}
@@ -40,7 +40,10 @@ func main() {
 	var courses []model.Course
 	var courseIds []int
 	for i := range nCourses {
-		course := model.Course{Name: fmt.Sprintf("Kurs%d", i), MinCapacity: minCap, MaxCapacity: maxCap}
+		course := model.Course{ID: i + 1, MinCapacity: minCap, MaxCapacity: maxCap}
+		if err := course.SetName(fmt.Sprintf("Kurs%d", i), secret); err != nil {
+			panic(err)
+		}
 		if err := db.Create(&course).Error; err != nil {
 			panic(err)
 		}
@@ -0,0 +1,222 @@
+// Command priobaer-admin inspects and purges the session databases of a Priobär server. It can be used while the
+// server is running.
+//
+// Usage:
+//
+//	priobaer-admin [-root dir] list
+//	priobaer-admin [-root dir] purge (-expired | <session id>...)
+//	priobaer-admin [-root dir] vacuum [<session id>...]
+//	priobaer-admin [-root dir] usage
+//
+// The root dir defaults to PRIOBAER_DB_ROOT_DIR. Vacuum without session ids vacuums all sessions.
+// The exit code is 0 on success, 2 on invalid usage and 1 on any other error.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"softbaer.dev/ass/internal/dbdir"
+)
+
+const (
+	exitOk    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Getenv, os.Stdout, os.Stderr))
+}
+
+func run(args []string, getenv func(string) string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("priobaer-admin", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	rootDir := flags.String("root", getenv("PRIOBAER_DB_ROOT_DIR"), "directory of the session databases")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: priobaer-admin [-root dir] list | purge (-expired | <session id>...) | vacuum [<session id>...] | usage")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	if *rootDir == "" || flags.NArg() == 0 {
+		flags.Usage()
+		return exitUsage
+	}
+
+	command, commandArgs := flags.Arg(0), flags.Args()[1:]
+
+	var err error
+	switch command {
+	case "list":
+		err = list(*rootDir, stdout)
+	case "purge":
+		return purge(*rootDir, commandArgs, stdout, stderr)
+	case "vacuum":
+		err = vacuum(*rootDir, commandArgs, stdout)
+	case "usage":
+		err = diskUsage(*rootDir, stdout)
+	default:
+		fmt.Fprintf(stderr, "unknown command %q\n", command)
+		flags.Usage()
+		return exitUsage
+	}
+
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitError
+	}
+
+	return exitOk
+}
+
+func list(rootDir string, w io.Writer) error {
+	infos, err := dbdir.ListSessions(rootDir)
+
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	table := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "SESSION\tEXPIRES\tSIZE\tROWS")
+
+	for _, info := range infos {
+		if info.Err != nil {
+			fmt.Fprintf(table, "%s\tunreadable: %v\t%s\t\n", info.DbId, info.Err, formatBytes(info.Size))
+			continue
+		}
+
+		expires := info.ExpiresAt.Local().Format(time.DateTime)
+		if info.Expired(now) {
+			expires += " (expired)"
+		}
+
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\n", info.DbId, expires, formatBytes(info.Size), formatRowCounts(info.RowCounts))
+	}
+
+	return table.Flush()
+}
+
+func purge(rootDir string, args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("purge", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	expired := flags.Bool("expired", false, "purge all expired sessions")
+
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	if *expired == (flags.NArg() > 0) {
+		fmt.Fprintln(stderr, "purge needs either -expired or session ids")
+		return exitUsage
+	}
+
+	dbIds := flags.Args()
+	if *expired {
+		infos, err := dbdir.ListSessions(rootDir)
+
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitError
+		}
+
+		now := time.Now()
+		for _, info := range infos {
+			if info.Expired(now) {
+				dbIds = append(dbIds, info.DbId)
+			}
+		}
+	}
+
+	exitCode := exitOk
+	for _, dbId := range dbIds {
+		if err := dbdir.PurgeSession(rootDir, dbId); err != nil {
+			fmt.Fprintf(stderr, "could not purge %s: %v\n", dbId, err)
+			exitCode = exitError
+			continue
+		}
+
+		fmt.Fprintf(stdout, "purged %s\n", dbId)
+	}
+
+	return exitCode
+}
+
+func vacuum(rootDir string, dbIds []string, w io.Writer) error {
+	if len(dbIds) == 0 {
+		infos, err := dbdir.ListSessions(rootDir)
+
+		if err != nil {
+			return err
+		}
+
+		for _, info := range infos {
+			if info.Err == nil {
+				dbIds = append(dbIds, info.DbId)
+			}
+		}
+	}
+
+	var errs []error
+	for _, dbId := range dbIds {
+		if err := dbdir.VacuumSession(rootDir, dbId); err != nil {
+			errs = append(errs, fmt.Errorf("could not vacuum %s: %w", dbId, err))
+			continue
+		}
+
+		fmt.Fprintf(w, "vacuumed %s\n", dbId)
+	}
+
+	return errors.Join(errs...)
+}
+
+func diskUsage(rootDir string, w io.Writer) error {
+	total, err := dbdir.DiskUsage(rootDir)
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w, formatBytes(total))
+
+	return nil
+}
+
+func formatRowCounts(rowCounts map[string]int64) string {
+	var parts []string
+	for _, table := range slices.Sorted(maps.Keys(rowCounts)) {
+		parts = append(parts, fmt.Sprintf("%s=%d", table, rowCounts[table]))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func formatBytes(size int64) string {
+	const unit = 1024
+
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	value := float64(size)
+	prefixes := "KMGT"
+	i := -1
+	for value >= unit && i < len(prefixes)-1 {
+		value /= unit
+		i++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", value, prefixes[i])
+}
@@ -0,0 +1,180 @@
+// Command priobaer computes optimal assignments for a scenario stored in an Excel file without starting the web server.
+//
+// Usage:
+//
+//	priobaer -in scenario.xlsx [-out result.xlsx] [-objective linear|quadratic] [-timeout 10m] [-seed 0]
+//
+// The exit code is 0 on success, 2 if the scenario is not solvable, 3 if solving timed out and 1 on any other error.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"softbaer.dev/ass/internal/domain"
+	"softbaer.dev/ass/internal/domain/solve"
+	"softbaer.dev/ass/internal/model/loadsave"
+)
+
+const (
+	exitOk          = 0
+	exitError       = 1
+	exitNotSolvable = 2
+	exitTimeout     = 3
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	os.Exit(run(ctx, os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("priobaer", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	in := flags.String("in", "", "Excel file to read the scenario from (required)")
+	out := flags.String("out", "", "Excel file to write the result to (default: <in>_solved.xlsx)")
+	objectiveName := flags.String("objective", string(solve.ObjectiveLinear), "objective function: 'linear' or 'quadratic'")
+	timeout := flags.Duration("timeout", 10*time.Minute, "maximum time spent solving")
+	seed := flags.Uint("seed", 0, "random seed passed to z3")
+
+	if err := flags.Parse(args); err != nil {
+		return exitError
+	}
+
+	if *in == "" {
+		fmt.Fprintln(stderr, "flag -in is required")
+		flags.Usage()
+		return exitError
+	}
+
+	if *out == "" {
+		*out = strings.TrimSuffix(*in, filepath.Ext(*in)) + "_solved.xlsx"
+	}
+
+	objective, err := solve.ParseObjective(*objectiveName)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitError
+	}
+
+	scenario, err := readScenario(*in)
+	if err != nil {
+		fmt.Fprintf(stderr, "could not read scenario from %s: %v\n", *in, err)
+		return exitError
+	}
+
+	assignedBefore := len(allParticipants(scenario)) - len(scenario.Unassigned())
+
+	start := time.Now()
+	result, err := solve.ComputeAndApplyOptimalAssignmentsToScenario(
+		ctx,
+		scenario,
+		solve.WithObjective(objective),
+		solve.WithTimeout(*timeout),
+		solve.WithSeed(*seed),
+	)
+
+	switch {
+	case errors.Is(err, solve.NotSolvable):
+		fmt.Fprintln(stderr, "scenario is not solvable. Try to change course capacities or priorities")
+		return exitNotSolvable
+	case errors.Is(err, solve.Timeout):
+		fmt.Fprintf(stderr, "solving took longer than %s\n", *timeout)
+		return exitTimeout
+	case err != nil:
+		fmt.Fprintf(stderr, "solving failed: %v\n", err)
+		return exitError
+	}
+
+	duration := time.Since(start)
+
+	for _, violation := range domain.VerifySolve(scenario, result.Claim()) {
+		fmt.Fprintf(stderr, "warning: %s\n", violation.Message)
+	}
+
+	excelBytes, err := loadsave.SaveScenarioToExcelFile(scenario)
+	if err != nil {
+		fmt.Fprintf(stderr, "could not export scenario: %v\n", err)
+		return exitError
+	}
+
+	if err := os.WriteFile(*out, excelBytes, 0666); err != nil {
+		fmt.Fprintf(stderr, "could not write %s: %v\n", *out, err)
+		return exitError
+	}
+
+	printSummary(stdout, scenario, result, assignedBefore, duration, *out)
+
+	return exitOk
+}
+
+func readScenario(path string) (*domain.Scenario, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return loadsave.LoadScenarioFromExcelFile(file, loadsave.SheetLimits{})
+}
+
+func allParticipants(scenario *domain.Scenario) (participants []domain.ParticipantData) {
+	for participant := range scenario.AllParticipants() {
+		participants = append(participants, participant)
+	}
+
+	return
+}
+
+func printSummary(w io.Writer, scenario *domain.Scenario, result solve.Result, assignedBefore int, duration time.Duration, out string) {
+	participants := allParticipants(scenario)
+	courseCount := 0
+	for range scenario.AllCourses() {
+		courseCount++
+	}
+
+	// levelCounts[0] counts assigned participants whose course is not among their priorities.
+	levelCounts := make([]int, scenario.MaxAmountOfPriorities()+1)
+	for _, participant := range participants {
+		assignedCourse, ok := scenario.AssignedCourse(participant.ID)
+		if !ok {
+			continue
+		}
+
+		level := 0
+		i := 1
+		for course := range scenario.PrioritizedCoursesOrdered(participant.ID) {
+			if course.ID == assignedCourse.ID {
+				level = i
+				break
+			}
+			i++
+		}
+		levelCounts[level]++
+	}
+
+	fmt.Fprintf(w, "Courses:              %d\n", courseCount)
+	fmt.Fprintf(w, "Participants:         %d\n", len(participants))
+	fmt.Fprintf(w, "Assigned before:      %d\n", assignedBefore)
+	fmt.Fprintf(w, "Assigned by solver:   %d\n", len(result.Assignments))
+	fmt.Fprintf(w, "Still unassigned:     %d\n", len(scenario.Unassigned()))
+	fmt.Fprintf(w, "Objective value:      %d\n", result.ObjectiveValue)
+	fmt.Fprintf(w, "Solved in:            %s\n", duration.Round(time.Millisecond))
+	for level := 1; level < len(levelCounts); level++ {
+		fmt.Fprintf(w, "%-22s%d\n", fmt.Sprintf("Got priority %d:", level), levelCounts[level])
+	}
+	if levelCounts[0] > 0 {
+		fmt.Fprintf(w, "Got no priority:      %d\n", levelCounts[0])
+	}
+	fmt.Fprintf(w, "Result written to %s\n", out)
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"os"
 
 	"github.com/jonboulle/clockwork"
@@ -9,8 +10,15 @@ import (
 )
 
 func main() {
+	getenv, err := server.ConfigSources(os.Args[1:], os.Getenv, os.Stderr)
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
 	ctx := context.Background()
-	err := server.Run(ctx, os.Getenv, clockwork.NewRealClock())
+	err = server.Run(ctx, getenv, clockwork.NewRealClock())
 
 	if err != nil {
 		panic(err)
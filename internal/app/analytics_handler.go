@@ -0,0 +1,52 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"softbaer.dev/ass/internal/app/respond"
+	"softbaer.dev/ass/internal/crypt"
+	"softbaer.dev/ass/internal/domain"
+	"softbaer.dev/ass/internal/ui"
+)
+
+// demandTableFixedColumns counts the columns of the demand table besides the one per priority level.
+const demandTableFixedColumns = 6
+
+func AnalyticsIndex(c *gin.Context) {
+	scenario, err := domain.LoadScenario(GetDB(c), crypt.GetSecret(c))
+	if err != nil {
+		respond.InternalServerError(c, "Error while loading scenario", err)
+		return
+	}
+
+	levels := make([]int, scenario.MaxAmountOfPriorities())
+	for i := range levels {
+		levels[i] = i + 1
+	}
+
+	c.HTML(http.StatusOK, "analytics/index", gin.H{
+		"levels":  levels,
+		"columns": demandTableFixedColumns + len(levels),
+		"demands": toViewCourseDemands(domain.AnalyzeDemand(scenario)),
+	})
+}
+
+func toViewCourseDemands(demands []domain.CourseDemand) []ui.CourseDemand {
+	viewDemands := make([]ui.CourseDemand, 0, len(demands))
+	for _, d := range demands {
+		viewDemands = append(viewDemands, ui.CourseDemand{
+			Name:           d.Course.Name,
+			MinCapacity:    d.Course.MinCapacity,
+			MaxCapacity:    d.Course.MaxCapacity,
+			CountsByLevel:  d.CountsByLevel,
+			WeightedDemand: fmt.Sprintf("%.2f", d.WeightedDemand),
+			Ratio:          fmt.Sprintf("%.2f", d.Ratio),
+			Outlook:        d.Outlook.Label(),
+			Highlighted:    d.Outlook != domain.DemandOutlookOpen,
+		})
+	}
+
+	return viewDemands
+}
@@ -3,18 +3,19 @@ package app
 import (
 	"database/sql"
 	"errors"
-	"log/slog"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"softbaer.dev/ass/internal/app/requestlog"
 	"softbaer.dev/ass/internal/app/respond"
+	"softbaer.dev/ass/internal/crypt"
 	"softbaer.dev/ass/internal/domain"
 	"softbaer.dev/ass/internal/ui"
 )
 
 func AssignmentsCreate(c *gin.Context) {
-	logger := slog.With("Func", "AssignmentsCreate")
+	logger := requestlog.Logger(c).With("Func", "AssignmentsCreate")
 	db := GetDB(c)
 
 	var uriParams assignUriParams
@@ -28,7 +29,9 @@ func AssignmentsCreate(c *gin.Context) {
 	var courseID = uriParams.CourseID
 
 	err := db.Transaction(func(tx *gorm.DB) error {
-		return domain.InitialAssign(tx, participantID, courseID)
+		return domain.Record(tx, domain.ActionAssign, domain.ParticipantScope(participantID), func(tx *gorm.DB) error {
+			return domain.InitialAssign(tx, participantID, courseID)
+		})
 	})
 
 	if err != nil {
@@ -42,7 +45,7 @@ func AssignmentsCreate(c *gin.Context) {
 		return
 	}
 
-	courseData, err := domain.FindSingleCourseData(db, courseID)
+	courseData, err := domain.FindSingleCourseData(db, courseID, crypt.GetSecret(c))
 	if err != nil {
 		respond.InternalServerError(c, "Finding course data failed", err)
 		return
@@ -59,11 +62,12 @@ func AssignmentsCreate(c *gin.Context) {
 		SetUnassignedCount(unassignedCount)
 	uiUpdate.AppendCourse(newUiCourse(courseData, newCourseAllocation))
 
+	triggerScenarioChanged(c)
 	c.HTML(http.StatusOK, "scenario/course-list", uiUpdate)
 }
 
 func AssignmentsUpdate(c *gin.Context) {
-	logger := slog.With("Func", "AssignmentsCreate")
+	logger := requestlog.Logger(c).With("Func", "AssignmentsCreate")
 	db := GetDB(c)
 
 	var uriParams assignUriParams
@@ -77,19 +81,21 @@ func AssignmentsUpdate(c *gin.Context) {
 	targetID := uriParams.CourseID
 	var source, target domain.CourseData
 
-	target, err := domain.FindSingleCourseData(db, targetID)
+	target, err := domain.FindSingleCourseData(db, targetID, crypt.GetSecret(c))
 	if err != nil {
 		respond.InternalServerError(c, "Finding target course data failed", err)
 		return
 	}
-	source, err = domain.FindAssignedCourse(db, participantID)
+	source, err = domain.FindAssignedCourse(db, participantID, crypt.GetSecret(c))
 	if err != nil {
 		respond.InternalServerError(c, "Finding assigned course data failed", err)
 		return
 	}
 
 	err = db.Transaction(func(tx *gorm.DB) error {
-		return domain.Reassign(tx, participantID, targetID)
+		return domain.Record(tx, domain.ActionReassign, domain.ParticipantScope(participantID), func(tx *gorm.DB) error {
+			return domain.Reassign(tx, participantID, targetID)
+		})
 	})
 
 	if err != nil {
@@ -108,6 +114,7 @@ func AssignmentsUpdate(c *gin.Context) {
 		uiUpdate.AppendCourse(newUiCourse(course, newCourseAllocation))
 	}
 
+	triggerScenarioChanged(c)
 	c.HTML(http.StatusOK, "scenario/course-list", uiUpdate)
 }
 
@@ -116,7 +123,7 @@ func AssignmentsDelete(c *gin.Context) {
 		ParticipantID domain.ParticipantID `uri:"id" binding:"required"`
 	}
 
-	logger := slog.With("Func", "AssignmentsDelete")
+	logger := requestlog.Logger(c).With("Func", "AssignmentsDelete")
 	db := GetDB(c)
 
 	var uriParams unassignUriParams
@@ -126,14 +133,16 @@ func AssignmentsDelete(c *gin.Context) {
 	}
 
 	participantID := uriParams.ParticipantID
-	source, err := domain.FindAssignedCourse(db, participantID)
+	source, err := domain.FindAssignedCourse(db, participantID, crypt.GetSecret(c))
 	if err != nil {
 		respond.InternalServerError(c, "Finding currently assigned course data failed", err)
 		return
 	}
 
 	err = db.Transaction(func(tx *gorm.DB) error {
-		return domain.Unassign(tx, participantID)
+		return domain.Record(tx, domain.ActionUnassign, domain.ParticipantScope(participantID), func(tx *gorm.DB) error {
+			return domain.Unassign(tx, participantID)
+		})
 	})
 
 	if err != nil {
@@ -157,6 +166,7 @@ func AssignmentsDelete(c *gin.Context) {
 		SetUnassignedCount(unassignedCount)
 	uiUpdate.AppendCourse(newUiCourse(source, sourceAllocation))
 
+	triggerScenarioChanged(c)
 	c.HTML(http.StatusOK, "scenario/course-list", uiUpdate)
 }
 
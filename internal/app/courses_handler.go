@@ -1,16 +1,14 @@
 package app
 
 import (
-	"database/sql"
 	"errors"
-	"log/slog"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"softbaer.dev/ass/internal/app/requestlog"
+	"softbaer.dev/ass/internal/crypt"
 	"softbaer.dev/ass/internal/domain"
-	"softbaer.dev/ass/internal/model"
-	"softbaer.dev/ass/internal/ui"
 )
 
 func CoursesNew() gin.HandlerFunc {
@@ -33,11 +31,11 @@ func CoursesCreate() gin.HandlerFunc {
 		err := c.Bind(&req)
 
 		if err != nil {
-			slog.Error("Could not bind request to when creating course", "err", err)
+			requestlog.Logger(c).Error("Could not bind request to when creating course", "err", err)
 			return
 		}
 
-		course := model.Course{Name: req.Name, MaxCapacity: *req.MaxCapacity, MinCapacity: *req.MinCapacity}
+		course := domain.CourseData{Name: req.Name, MaxCapacity: *req.MaxCapacity, MinCapacity: *req.MinCapacity}
 		validationErrors := course.Valid()
 
 		if len(validationErrors) > 0 {
@@ -46,25 +44,50 @@ func CoursesCreate() gin.HandlerFunc {
 			return
 		}
 
-		result := db.Create(&course)
+		var created domain.CourseData
+		err = db.Transaction(func(tx *gorm.DB) error {
+			scope := domain.CourseScope()
+			err := domain.Record(tx, domain.ActionCreateCourse, scope, func(tx *gorm.DB) error {
+				var err error
+				created, err = domain.CreateCourse(tx, course, crypt.GetSecret(c))
+				scope.AddCourse(created.ID)
+				return err
+			})
+			if err != nil {
+				return err
+			}
+
+			return GetQuota(c).Check(tx)
+		})
+
+		if QuotaError(c, err) {
+			return
+		}
+
+		if errors.Is(err, domain.ErrCourseNameTaken) {
+			c.HTML(422, "courses/new", gin.H{"Errors": map[string]string{"name": "Ein Kurs mit diesem Namen existiert bereits"}, "Value": course})
 
-		if result.Error != nil {
-			if errors.Is(result.Error, gorm.ErrCheckConstraintViolated) {
-				slog.Error("Constraint violated while creating Course", "err", err, "course.Name", course.Name)
+			return
+		}
+
+		if err != nil {
+			if errors.Is(err, gorm.ErrCheckConstraintViolated) {
+				requestlog.Logger(c).Error("Constraint violated while creating Course", "err", err)
 				c.AbortWithStatus(http.StatusConflict)
 
 				return
 			}
 
-			slog.Error("Unexpected error while creating Course", "err", err)
+			requestlog.Logger(c).Error("Unexpected error while creating Course", "err", err)
 			c.AbortWithStatus(http.StatusInternalServerError)
 
 			return
 		}
 
-		viewCourse := toViewCourse(course, sql.NullInt64{Valid: false}, false)
+		viewCourse := newUiCourse(created, 0)
 
 		if c.GetHeader("HX-Request") == "true" {
+			triggerScenarioChanged(c)
 			c.HTML(http.StatusOK, "courses/_show-with-new-button", viewCourse)
 		} else {
 			c.Redirect(http.StatusSeeOther, "/scenario")
@@ -83,23 +106,26 @@ func CoursesDelete() gin.HandlerFunc {
 		err := c.BindUri(&req)
 
 		if err != nil {
-			slog.Error("Could not parse id from uri in CoursesDelete", "err", err)
+			requestlog.Logger(c).Error("Could not parse id from uri in CoursesDelete", "err", err)
 			c.AbortWithStatus(http.StatusNotFound)
 
 			return
 		}
 
 		err = db.Transaction(func(tx *gorm.DB) error {
-			return domain.DeleteCourse(tx, req.ID)
+			return domain.Record(tx, domain.ActionDeleteCourse, domain.CourseScope(domain.CourseID(req.ID)), func(tx *gorm.DB) error {
+				return domain.DeleteCourse(tx, req.ID)
+			})
 		})
 
 		if err != nil {
-			slog.Error("Delete of course failed on db level", "err", err)
+			requestlog.Logger(c).Error("Delete of course failed on db level", "err", err)
 			c.AbortWithStatus(http.StatusInternalServerError)
 
 			return
 		}
 
+		triggerScenarioChanged(c)
 		c.Writer.WriteHeader(http.StatusNoContent)
 	}
 }
@@ -107,15 +133,3 @@ func CoursesDelete() gin.HandlerFunc {
 func CoursesButtonNew(c *gin.Context) {
 	c.HTML(http.StatusOK, "courses/_new-button", nil)
 }
-
-func toViewCourse(model model.Course, selectedId sql.NullInt64, asOobSwap bool) ui.Course {
-	return ui.Course{
-		ID:          model.ID,
-		Name:        model.Name,
-		MinCapacity: model.MinCapacity,
-		MaxCapacity: model.MaxCapacity,
-		Selected:    selectedId.Valid && model.ID == int(selectedId.Int64),
-		Allocation:  model.Allocation(),
-		AsOobSwap:   asOobSwap,
-	}
-}
@@ -0,0 +1,30 @@
+package app
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"softbaer.dev/ass/internal/app/respond"
+	"softbaer.dev/ass/internal/crypt"
+	"softbaer.dev/ass/internal/domain"
+	"softbaer.dev/ass/internal/ui"
+)
+
+func DiagnosticsIndex(c *gin.Context) {
+	scenario, err := domain.LoadScenario(GetDB(c), crypt.GetSecret(c))
+	if err != nil {
+		respond.InternalServerError(c, "Error while loading scenario", err)
+		return
+	}
+
+	c.HTML(http.StatusOK, "scenario/diagnostics", toViewDiagnostics(domain.Diagnose(scenario)))
+}
+
+func toViewDiagnostics(diagnostics []domain.Diagnostic) []ui.Diagnostic {
+	viewDiagnostics := make([]ui.Diagnostic, 0, len(diagnostics))
+	for _, d := range diagnostics {
+		viewDiagnostics = append(viewDiagnostics, ui.Diagnostic{Check: d.Check, Passed: d.Passed, Blocking: d.Blocking, Issues: d.Issues})
+	}
+
+	return viewDiagnostics
+}
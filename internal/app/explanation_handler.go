@@ -0,0 +1,91 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"softbaer.dev/ass/internal/app/requestlog"
+	"softbaer.dev/ass/internal/app/respond"
+	"softbaer.dev/ass/internal/crypt"
+	"softbaer.dev/ass/internal/domain"
+	"softbaer.dev/ass/internal/ui"
+)
+
+func ExplanationShow(c *gin.Context) {
+	type request struct {
+		ID int `uri:"id" binding:"required"`
+	}
+
+	var req request
+	if err := c.BindUri(&req); err != nil {
+		requestlog.Logger(c).Error("Could not parse id from uri in ExplanationShow", "err", err)
+		c.AbortWithStatus(http.StatusNotFound)
+
+		return
+	}
+
+	scenario, err := domain.LoadScenario(GetDB(c), crypt.GetSecret(c))
+	if err != nil {
+		respond.InternalServerError(c, "Error while loading scenario", err)
+		return
+	}
+
+	explanation, err := domain.Explain(scenario, domain.ParticipantID(req.ID))
+	if errors.Is(err, domain.ErrNotFound) {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		respond.InternalServerError(c, "Error while explaining assignment", err)
+		return
+	}
+
+	c.HTML(http.StatusOK, "participants/_explanation", toViewExplanation(explanation))
+}
+
+func toViewExplanation(explanation domain.Explanation) ui.Explanation {
+	result := ui.Explanation{
+		ParticipantName: participantDisplayName(explanation.Participant),
+		AssignedLevel:   int(explanation.AssignedLevel),
+	}
+
+	if explanation.IsAssigned {
+		result.AssignedCourse = explanation.Assigned.Name
+	}
+
+	for _, higher := range explanation.HigherPriorities {
+		course := ui.ExplainedCourse{
+			Name:        higher.Course.Name,
+			Level:       int(higher.Level),
+			Allocation:  higher.Allocation,
+			MaxCapacity: higher.Course.MaxCapacity,
+			Full:        higher.Full,
+		}
+
+		for _, occupant := range higher.Occupants {
+			if occupant.Prioritized {
+				course.Occupants = append(course.Occupants, fmt.Sprintf("%s (Priorität %d)", participantDisplayName(occupant.Participant), occupant.Level))
+			} else {
+				course.Occupants = append(course.Occupants, fmt.Sprintf("%s (nicht priorisiert)", participantDisplayName(occupant.Participant)))
+			}
+		}
+
+		result.HigherPriorities = append(result.HigherPriorities, course)
+	}
+
+	for _, improvement := range explanation.Improvements {
+		if improvement.IsSwap {
+			result.Improvements = append(result.Improvements, fmt.Sprintf("Tausch mit %s in Kurs '%s' (Priorität %d)", participantDisplayName(improvement.Partner), improvement.Course.Name, improvement.Level))
+		} else {
+			result.Improvements = append(result.Improvements, fmt.Sprintf("Wechsel in Kurs '%s' (Priorität %d)", improvement.Course.Name, improvement.Level))
+		}
+	}
+
+	return result
+}
+
+func participantDisplayName(participant domain.ParticipantData) string {
+	return fmt.Sprintf("%s, %s", participant.Surname, participant.Prename)
+}
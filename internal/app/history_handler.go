@@ -0,0 +1,68 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"softbaer.dev/ass/internal/app/respond"
+	"softbaer.dev/ass/internal/domain"
+	"softbaer.dev/ass/internal/ui"
+)
+
+func HistoryIndex(c *gin.Context) {
+	history, err := domain.History(GetDB(c))
+	if err != nil {
+		respond.InternalServerError(c, "Error while loading history", err)
+		return
+	}
+
+	c.HTML(http.StatusOK, "scenario/history", toViewHistory(history))
+}
+
+func HistoryUndo(c *gin.Context) {
+	replayHistory(c, domain.Undo)
+}
+
+func HistoryRedo(c *gin.Context) {
+	replayHistory(c, domain.Redo)
+}
+
+// replayHistory undoes or redoes an action and shows the scenario afterward. If there is nothing to replay, the page
+// stays as it is.
+func replayHistory(c *gin.Context, replay func(tx *gorm.DB) (domain.HistoryEntry, error)) {
+	err := GetDB(c).Transaction(func(tx *gorm.DB) error {
+		_, err := replay(tx)
+		return err
+	})
+
+	if errors.Is(err, domain.ErrNothingToUndo) || errors.Is(err, domain.ErrNothingToRedo) {
+		c.Writer.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if err != nil {
+		respond.InternalServerError(c, "Replaying history failed", err)
+		return
+	}
+
+	c.Redirect(http.StatusSeeOther, "/scenario")
+}
+
+func toViewHistory(entries []domain.HistoryEntry) ui.History {
+	history := ui.History{Entries: make([]ui.HistoryEntry, 0, len(entries))}
+	for _, entry := range entries {
+		history.Entries = append(history.Entries, ui.HistoryEntry{
+			Label:   entry.Action.Label(),
+			Time:    entry.CreatedAt.Local().Format("15:04:05"),
+			Summary: entry.Summary,
+			Undone:  entry.Undone,
+		})
+
+		history.CanUndo = history.CanUndo || !entry.Undone
+		history.CanRedo = history.CanRedo || entry.Undone
+	}
+
+	return history
+}
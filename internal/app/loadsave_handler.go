@@ -2,11 +2,12 @@ package app
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
-	"log/slog"
 	"net/http"
 	"strings"
 
+	"softbaer.dev/ass/internal/app/requestlog"
 	"softbaer.dev/ass/internal/crypt"
 	"softbaer.dev/ass/internal/domain"
 
@@ -19,52 +20,84 @@ func LoadDialog(c *gin.Context) {
 	c.HTML(200, "dialogs/load", nil)
 }
 
-func Load(c *gin.Context) {
-	db := GetDB(c)
-	secret := crypt.GetSecret(c)
+// Load replaces the scenario with the one of the uploaded Excel file. Files beyond the size limit or sheets beyond
+// sheetLimits are refused.
+func Load(sheetLimits loadsave.SheetLimits) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		db := GetDB(c)
+		secret := crypt.GetSecret(c)
 
-	formFile, err := c.FormFile("file")
+		formFile, err := c.FormFile("file")
 
-	if err != nil {
-		slog.Error("Could not get uploaded form file", "err", err)
-		c.AbortWithError(500, err)
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			requestlog.Logger(c).Info("Refused upload beyond limit", "err", err)
+			limitExceeded(c, http.StatusRequestEntityTooLarge, uploadTooLargeMessage(maxBytesErr.Limit))
 
-		return
-	}
+			return
+		}
 
-	file, err := formFile.Open()
+		if err != nil {
+			requestlog.Logger(c).Error("Could not get uploaded form file", "err", err)
+			c.AbortWithError(500, err)
 
-	if err != nil {
-		slog.Error("Could not open formFile", "err", err)
-		c.AbortWithError(500, err)
-		return
-	}
+			return
+		}
 
-	scenario, err := loadsave.LoadScenarioFromExcelFile(file)
+		file, err := formFile.Open()
 
-	if err != nil {
-		slog.Error("Could not unmarshal models from excel-file", "err", err)
-		c.Header("HX-Retarget", "body")
-		c.Header("HX-Reswap", "beforeend")
-		err := fmt.Errorf("Excel-Datei konnte nicht geladen werden.\n%w", err)
+		if err != nil {
+			requestlog.Logger(c).Error("Could not open formFile", "err", err)
+			c.AbortWithError(500, err)
+			return
+		}
 
-		stackedErrs := strings.Split(err.Error(), "\n")
-		c.HTML(422, "dialogs/validation-error", stackedErrs)
-		return
-	}
+		scenario, err := loadsave.LoadScenarioFromExcelFile(file, sheetLimits)
 
-	err = db.Transaction(func(tx *gorm.DB) error {
-		return domain.OverwriteScenario(tx, scenario, secret)
-	})
+		var sheetLimitErr loadsave.SheetLimitError
+		if errors.As(err, &sheetLimitErr) {
+			requestlog.Logger(c).Info("Refused Excel file beyond sheet limits", "err", err)
+			limitExceeded(c, http.StatusRequestEntityTooLarge, sheetLimitErr.Error())
 
-	if err != nil {
-		slog.Error("Error while inserting unmarshalled structs into db", "err", err)
-		c.AbortWithError(500, err)
+			return
+		}
 
-		return
-	}
+		if err != nil {
+			requestlog.Logger(c).Error("Could not unmarshal models from excel-file", "err", err)
+			c.Header("HX-Retarget", "body")
+			c.Header("HX-Reswap", "beforeend")
+			err := fmt.Errorf("Excel-Datei konnte nicht geladen werden.\n%w", err)
+
+			stackedErrs := strings.Split(err.Error(), "\n")
+			c.HTML(422, "dialogs/validation-error", stackedErrs)
+			return
+		}
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := domain.SnapshotBeforeLoad(tx); err != nil {
+				return err
+			}
 
-	c.Redirect(http.StatusSeeOther, "/scenario")
+			return domain.Record(tx, domain.ActionLoad, domain.WholeScenario(), func(tx *gorm.DB) error {
+				return domain.OverwriteScenario(tx, scenario, secret, GetQuota(c))
+			})
+		})
+
+		if QuotaError(c, err) {
+			return
+		}
+
+		if err != nil {
+			requestlog.Logger(c).Error("Error while inserting unmarshalled structs into db", "err", err)
+			c.AbortWithError(500, err)
+
+			return
+		}
+
+		reportViolations(c, "load", domain.Verify(scenario))
+
+		c.Redirect(http.StatusSeeOther, "/scenario")
+	}
 }
 
 func Save(c *gin.Context) {
@@ -74,7 +107,7 @@ func Save(c *gin.Context) {
 	scenario, err := domain.LoadScenario(db, secret)
 
 	if err != nil {
-		slog.Error("Error while loading scenario", "err", err)
+		requestlog.Logger(c).Error("Error while loading scenario", "err", err)
 		c.AbortWithError(500, err)
 
 		return
@@ -83,7 +116,7 @@ func Save(c *gin.Context) {
 	excelBytes, err := loadsave.SaveScenarioToExcelFile(scenario)
 
 	if err != nil {
-		slog.Error("Error while exporting models to ExcelBytes", "err", err)
+		requestlog.Logger(c).Error("Error while exporting models to ExcelBytes", "err", err)
 		c.AbortWithError(500, err)
 
 		return
@@ -0,0 +1,92 @@
+package app
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"softbaer.dev/ass/internal/app/requestlog"
+	"softbaer.dev/ass/internal/metrics"
+)
+
+// MetricsAccess restricts who can read the metrics. If both a token and networks are set, requests must satisfy
+// both. Without either, the metrics are not served at all.
+type MetricsAccess struct {
+	// Token must be sent as bearer token in the Authorization header.
+	Token string
+	// Networks contain the client addresses the metrics can be read from.
+	Networks []netip.Prefix
+}
+
+func (a MetricsAccess) Enabled() bool {
+	return a.Token != "" || len(a.Networks) > 0
+}
+
+func (a MetricsAccess) allows(c *gin.Context) bool {
+	if a.Token != "" {
+		sent := []byte(c.GetHeader("Authorization"))
+		if subtle.ConstantTimeCompare(sent, []byte("Bearer "+a.Token)) != 1 {
+			return false
+		}
+	}
+
+	if len(a.Networks) > 0 {
+		client, err := netip.ParseAddr(c.ClientIP())
+		if err != nil {
+			return false
+		}
+
+		return slices.ContainsFunc(a.Networks, func(network netip.Prefix) bool { return network.Contains(client.Unmap()) })
+	}
+
+	return true
+}
+
+// MetricsIndex writes the metrics of the registries in the Prometheus text format.
+func MetricsIndex(access MetricsAccess, registries ...*metrics.Registry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !access.allows(c) {
+			requestlog.Logger(c).Info("Refused to serve metrics", "clientIp", c.ClientIP())
+			c.AbortWithStatus(http.StatusForbidden)
+
+			return
+		}
+
+		c.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		c.Status(http.StatusOK)
+		for _, registry := range registries {
+			if err := registry.Write(c.Writer); err != nil {
+				requestlog.Logger(c).Error("Could not write metrics", "err", err)
+
+				return
+			}
+		}
+	}
+}
+
+// RecordRequestMetrics counts the requests and their durations per route. Routes are recorded as registered, e.g.
+// /participants/:id, so that the metrics contain neither ids nor other data of the users.
+func RecordRequestMetrics(registry *metrics.Registry) gin.HandlerFunc {
+	requests := registry.NewCounterVec("priobaer_http_requests_total",
+		"HTTP requests by method, route and status code.", "method", "route", "status")
+	durations := registry.NewHistogramVec("priobaer_http_request_duration_seconds",
+		"Durations of HTTP requests by method and route.", metrics.DurationBuckets, "method", "route")
+
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		requests.Inc(c.Request.Method, route, strconv.Itoa(c.Writer.Status()))
+		durations.Observe(time.Since(start).Seconds(), c.Request.Method, route)
+	}
+}
@@ -1,28 +1,294 @@
 package app
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
 	"log/slog"
+	"math"
 	"net/http"
+	"net/netip"
 	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"softbaer.dev/ass/internal/app/requestlog"
+	"softbaer.dev/ass/internal/app/respond"
+	"softbaer.dev/ass/internal/crypt"
 	"softbaer.dev/ass/internal/dbdir"
+	"softbaer.dev/ass/internal/domain"
+	"softbaer.dev/ass/internal/ratelimit"
 )
 
 const sessionIdKey = "session_id"
 const dbKey = "db"
+const quotaKey = "quota"
+
+// These headers tell the client when its data is going to be wiped, so that it can show a countdown.
+// The remaining time is sent in seconds instead of as a point in time, so that the client's clock does not matter.
+const sessionRemainingHeader = "X-Session-Remaining"
+const sessionExtendableHeader = "X-Session-Extendable"
+
+// The CSRF token is kept in the session. Since the session cookie is not readable by scripts, the token is mirrored
+// into a cookie that index.js reads to send it back along with every state-changing request.
+const csrfTokenKey = "csrf_token"
+const csrfCookieName = "csrf_token"
+const csrfHeader = "X-CSRF-Token"
+const csrfFormField = "csrf_token"
+
+// contentSecurityPolicy only allows scripts and styles served by us (and htmx), so that injected markup can not run
+// scripts. Therefore the templates must not contain inline scripts, event handler attributes or style attributes.
+const contentSecurityPolicy = "default-src 'self'; " +
+	"script-src 'self' https://unpkg.com; " +
+	"style-src 'self'; " +
+	"img-src 'self' data:; " +
+	"connect-src 'self'; " +
+	"object-src 'none'; " +
+	"base-uri 'none'; " +
+	"form-action 'self'; " +
+	"frame-ancestors 'none'"
+
+// requestIdHeader carries the id of a request in its response, so that users can refer to it when reporting errors.
+const requestIdHeader = "X-Request-Id"
+
+// maxRequestIdLength limits the ids taken over from trusted proxies, since they end up in every log line.
+const maxRequestIdLength = 128
+
+// LogRequests assigns every request an id, adds it to the request's logger (see requestlog.Logger) and sends it back
+// in the X-Request-Id header. Ids set by trusted proxies are kept, so that their logs can be correlated with ours.
+// Once the request is handled, it is logged along with its status and duration. Client addresses are not logged.
+func LogRequests(logger *slog.Logger, trustedProxies []netip.Prefix) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		requestId := c.GetHeader(requestIdHeader)
+		if !validRequestId(requestId) || !fromTrustedProxy(c, trustedProxies) {
+			requestId = newRequestId()
+		}
+		c.Header(requestIdHeader, requestId)
+		requestlog.Set(c, logger.With("requestId", requestId))
+
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+
+		requestlog.Logger(c).Log(c.Request.Context(), level, "Handled request",
+			"method", c.Request.Method,
+			"route", c.FullPath(),
+			"path", c.Request.URL.Path,
+			"status", status,
+			"duration", time.Since(start),
+		)
+	}
+}
+
+func newRequestId() string {
+	id := make([]byte, 12)
+	if _, err := rand.Read(id); err != nil {
+		panic(err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(id)
+}
+
+func validRequestId(id string) bool {
+	if id == "" || len(id) > maxRequestIdLength {
+		return false
+	}
+
+	return !strings.ContainsFunc(id, func(r rune) bool {
+		return !(r == '-' || r == '_' || r == '.' || ('0' <= r && r <= '9') || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z'))
+	})
+}
+
+// LogSessionPseudonym adds the pseudonym of the session to the request's logger, so that the log lines of a session
+// can be correlated without revealing its id, which would give access to its data.
+func LogSessionPseudonym(secret crypt.Secret) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if sessionId, ok := getSessionId(c); ok {
+			requestlog.With(c, "session", crypt.Pseudonym(sessionId, secret))
+		}
+
+		c.Next()
+	}
+}
+
+// SecurityHeaders restricts what browsers allow our pages to do, e.g. being embedded by other sites.
+func SecurityHeaders() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.Writer.Header()
+		header.Set("Content-Security-Policy", contentSecurityPolicy)
+		header.Set("X-Frame-Options", "DENY")
+		header.Set("X-Content-Type-Options", "nosniff")
+		header.Set("Referrer-Policy", "same-origin")
+		header.Set("Cross-Origin-Opener-Policy", "same-origin")
+		header.Set("Permissions-Policy", "camera=(), microphone=(), geolocation=()")
+
+		c.Next()
+	}
+}
+
+// VerifyCSRF refuses state-changing requests that do not carry the CSRF token of the session, either in the
+// X-CSRF-Token header (htmx) or in the csrf_token form field (plain forms). Sessions get a token on their first request.
+func VerifyCSRF() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		session := defaultSession(c)
+
+		token, ok := session.Get(csrfTokenKey).(string)
+		if !ok {
+			token = base64.RawURLEncoding.EncodeToString(crypt.GenerateSecret())
+			session.Set(csrfTokenKey, token)
+			if err := session.Save(); err != nil {
+				respond.InternalServerError(c, "Could not save CSRF token in session", err)
+				c.Abort()
+
+				return
+			}
+		}
+
+		if cookie, err := c.Cookie(csrfCookieName); err != nil || cookie != token {
+			http.SetCookie(c.Writer, &http.Cookie{
+				Name:     csrfCookieName,
+				Value:    token,
+				Path:     "/",
+				Secure:   secureCookies(c),
+				SameSite: http.SameSiteStrictMode,
+			})
+		}
+
+		if isSafeMethod(c.Request.Method) {
+			c.Next()
+
+			return
+		}
+
+		sent := c.GetHeader(csrfHeader)
+		if sent == "" {
+			sent = c.PostForm(csrfFormField)
+		}
+
+		if subtle.ConstantTimeCompare([]byte(sent), []byte(token)) != 1 {
+			respond.Forbidden(c, "Refused request without valid CSRF token", "tokenSent", sent != "")
+			c.Abort()
+
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// LimitRequestBody refuses request bodies beyond maxBytes, so that uploads can not exhaust memory. Bodies that do not
+// announce their size are cut off while they are read. Zero means no limit.
+func LimitRequestBody(maxBytes int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if maxBytes <= 0 {
+			c.Next()
+
+			return
+		}
+
+		if c.Request.ContentLength > maxBytes {
+			requestlog.Logger(c).Info("Refused request body beyond limit", "contentLength", c.Request.ContentLength, "maxBytes", maxBytes)
+			limitExceeded(c, http.StatusRequestEntityTooLarge, uploadTooLargeMessage(maxBytes))
+			c.Abort()
+
+			return
+		}
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes)
+		c.Next()
+	}
+}
+
+// RateLimit throttles state-changing requests per session and per IP address. Reading requests are not limited,
+// since they do not create data.
+func RateLimit(perSession, perIP *ratelimit.Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if isSafeMethod(c.Request.Method) {
+			c.Next()
+
+			return
+		}
+
+		ok, retryAfter := perIP.Allow(c.ClientIP())
+		if sessionId, hasSession := getSessionId(c); ok && hasSession {
+			ok, retryAfter = perSession.Allow(sessionId)
+		}
+
+		if !ok {
+			requestlog.Logger(c).Info("Refused request beyond rate limit", "retryAfter", retryAfter)
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			limitExceeded(c, http.StatusTooManyRequests, "Zu viele Anfragen in kurzer Zeit. Bitte warten Sie einen Moment und versuchen Sie es erneut.")
+			c.Abort()
+
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// passphraseRoutes derive a key from a submitted passphrase, which is slow and memory-hard on purpose.
+var passphraseRoutes = []string{
+	"/sessions/resume",
+	"/sessions/recovery",
+	"/sessions/passphrase",
+	"/sessions/unlock",
+	"/sessions/rekey",
+}
+
+// LimitPassphraseAttempts throttles the posts to passphrase routes per IP address, so that neither guessing
+// passphrases nor exhausting memory with key derivations is cheap for unauthenticated clients.
+func LimitPassphraseAttempts(perIP *ratelimit.Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodPost || !slices.Contains(passphraseRoutes, c.FullPath()) {
+			c.Next()
+
+			return
+		}
+
+		if ok, retryAfter := perIP.Allow(c.ClientIP()); !ok {
+			requestlog.Logger(c).Info("Refused passphrase attempt beyond rate limit", "retryAfter", retryAfter)
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			limitExceeded(c, http.StatusTooManyRequests, "Zu viele Versuche in kurzer Zeit. Bitte warten Sie einen Moment und versuchen Sie es erneut.")
+			c.Abort()
+
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
 
 func InjectDB(dbDirectory *dbdir.DbDirectory) gin.HandlerFunc {
 
 	return func(c *gin.Context) {
 		whitelist := []string{
 			"/health",
+			"/metrics",
 			"/static/*filepath",
 			"favicon.png",
 			"favicon.ico",
 			"/sessions/new",
+			"/sessions",
+			"/sessions/resume",
+			"/favicon.png",
+			"/style.css",
+			"/index.js",
 		}
 
 		if slices.Contains(whitelist, c.FullPath()) {
@@ -34,14 +300,29 @@ func InjectDB(dbDirectory *dbdir.DbDirectory) gin.HandlerFunc {
 		sessionId, ok := getSessionId(c)
 
 		if ok {
-			conn, err := dbDirectory.Open(sessionId)
+			conn, release, err := dbDirectory.Acquire(sessionId)
+
+			if errors.Is(err, dbdir.ErrTooManySessions) {
+				// The db of the session expired and there is no room for a new one.
+				sessionsFull(c)
+
+				return
+			}
 
 			if err != nil {
-				slog.Error("SessionId existed, but there was an error when opening db-conn", "err", err)
+				requestlog.Logger(c).Error("SessionId existed, but there was an error when opening db-conn", "err", err)
 				c.AbortWithStatus(http.StatusInternalServerError)
+
+				return
 			}
+			// The connection must stay open until the handlers are done with it.
+			defer release()
 
 			c.Set(dbKey, conn)
+			// The expiration banner loads itself without any action of the user, so it must not keep the session alive.
+			if c.FullPath() != "/sessions/expiration" {
+				extendSession(c, dbDirectory, sessionId)
+			}
 
 			c.Next()
 
@@ -49,8 +330,185 @@ func InjectDB(dbDirectory *dbdir.DbDirectory) gin.HandlerFunc {
 		}
 
 		c.Redirect(http.StatusSeeOther, "/sessions/new")
+		c.Abort()
+	}
+
+}
+
+// CookieSecurity decides whether cookies are marked Secure, i.e. whether browsers only send them via https.
+type CookieSecurity string
+
+const (
+	CookieSecureAlways CookieSecurity = "always"
+	// CookieSecureNever suits local setups via plain http. Browsers treat localhost as secure anyway.
+	CookieSecureNever CookieSecurity = "never"
+	// CookieSecureAuto marks cookies Secure if the request was made via https, either to us directly or to one of
+	// the trusted proxies, which tell via X-Forwarded-Proto.
+	CookieSecureAuto CookieSecurity = "auto"
+)
+
+const secureCookiesKey = "secure_cookies"
+
+// InjectCookieSecurity decides per request whether cookies are marked Secure and applies it to the session cookie.
+// Handlers that set other cookies ask secureCookies.
+func InjectCookieSecurity(mode CookieSecurity, trustedProxies []netip.Prefix, sessionMaxAge time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		secure := true
+		switch mode {
+		case CookieSecureNever:
+			secure = false
+		case CookieSecureAuto:
+			secure = c.Request.TLS != nil || (c.GetHeader("X-Forwarded-Proto") == "https" && fromTrustedProxy(c, trustedProxies))
+		}
+
+		c.Set(secureCookiesKey, secure)
+		defaultSession(c).Options(SessionCookieOptions(sessionMaxAge, secure))
+		sessions.DefaultMany(c, crypt.UnlockedCookieName).Options(UnlockedCookieOptions(secure))
+
+		c.Next()
+	}
+}
+
+func fromTrustedProxy(c *gin.Context, trustedProxies []netip.Prefix) bool {
+	remote, err := netip.ParseAddr(c.RemoteIP())
+	if err != nil {
+		return false
 	}
 
+	return slices.ContainsFunc(trustedProxies, func(proxy netip.Prefix) bool { return proxy.Contains(remote.Unmap()) })
+}
+
+// secureCookies tells whether cookies of the response should be marked Secure. Without InjectCookieSecurity they are.
+func secureCookies(c *gin.Context) bool {
+	if val, ok := c.Get(secureCookiesKey); ok {
+		secure, _ := val.(bool)
+		return secure
+	}
+	return true
+}
+
+// SessionCookieOptions returns the options of the session cookie, which should live as long as the session's db.
+func SessionCookieOptions(maxAge time.Duration, secure bool) sessions.Options {
+	maxAgeSeconds := int(maxAge.Seconds())
+
+	if maxAgeSeconds <= 0 {
+		maxAgeSeconds = 1
+	}
+
+	return sessions.Options{
+		Path:     "/",
+		Secure:   secure,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   maxAgeSeconds,
+	}
+}
+
+// UnlockedCookieOptions returns the options of the cookie that holds the secret of an unlocked session. Without MaxAge,
+// browsers drop it when they are closed.
+func UnlockedCookieOptions(secure bool) sessions.Options {
+	return sessions.Options{
+		Path:     "/",
+		Secure:   secure,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// extendSession treats the request as activity: it slides the expiration of the session's db and refreshes the
+// MaxAge of the session cookie accordingly.
+func extendSession(c *gin.Context, dbDirectory *dbdir.DbDirectory, sessionId string) {
+	expiration, err := dbDirectory.Extend(sessionId)
+	if err != nil {
+		requestlog.Logger(c).Error("Could not extend session", "err", err)
+		return
+	}
+
+	session := defaultSession(c)
+	session.Options(SessionCookieOptions(expiration.Remaining, secureCookies(c)))
+	if err := session.Save(); err != nil {
+		requestlog.Logger(c).Error("Could not refresh session cookie", "err", err)
+	}
+
+	setExpirationHeaders(c, expiration)
+}
+
+// setExpirationHeaders tells the client when the session expires, so that it can warn its user in time.
+func setExpirationHeaders(c *gin.Context, expiration dbdir.Expiration) {
+	c.Header(sessionRemainingHeader, strconv.Itoa(int(expiration.Remaining.Seconds())))
+	c.Header(sessionExtendableHeader, strconv.FormatBool(expiration.Extendable()))
+}
+
+// RequireUnlocked sends users of a locked session to the unlock page. A session is locked if its cookie holds no
+// secret, which only happens after the user locked a passphrase-protected session.
+func RequireUnlocked() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// These routes do not read encrypted data, and removing a locked session must not require its passphrase.
+		whitelist := []string{
+			"/sessions/unlock",
+			"/sessions/delete",
+			"/sessions/expiration",
+			"/sessions/extend",
+		}
+
+		if GetDB(c) == nil || slices.Contains(whitelist, c.FullPath()) {
+			c.Next()
+
+			return
+		}
+
+		if _, ok := crypt.LookupSecret(c); ok {
+			c.Next()
+
+			return
+		}
+
+		if c.GetHeader("HX-Request") == "true" {
+			c.Header("HX-Redirect", "/sessions/unlock")
+			c.AbortWithStatus(http.StatusNoContent)
+
+			return
+		}
+
+		c.Redirect(http.StatusSeeOther, "/sessions/unlock")
+		c.Abort()
+	}
+}
+
+// BindCiphertexts binds the names of older dbs to their rows, as soon as the secret to decrypt them is available. If
+// that fails, the request continues, so that the handlers report which row could not be decrypted.
+func BindCiphertexts() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		db := GetDB(c)
+		secret, ok := crypt.LookupSecret(c)
+
+		if db != nil && ok {
+			err := db.Transaction(func(tx *gorm.DB) error {
+				return domain.BindCiphertexts(tx, secret)
+			})
+			if err != nil {
+				requestlog.Logger(c).Error("Could not bind ciphertexts to their rows", "err", err)
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// InjectQuota makes the quota available to the handlers via GetQuota.
+func InjectQuota(quota domain.Quota) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(quotaKey, quota)
+		c.Next()
+	}
+}
+
+// GetQuota returns the quota of the session. Without InjectQuota there is no limit.
+func GetQuota(c *gin.Context) (quota domain.Quota) {
+	if val, ok := c.Get(quotaKey); ok {
+		quota, _ = val.(domain.Quota)
+	}
+	return
 }
 
 func GetDB(c *gin.Context) (db *gorm.DB) {
@@ -60,8 +518,18 @@ func GetDB(c *gin.Context) (db *gorm.DB) {
 	return
 }
 
+// defaultSession returns the session whose cookie lives as long as the session's db.
+func defaultSession(c *gin.Context) sessions.Session {
+	return sessions.DefaultMany(c, crypt.SessionCookieName)
+}
+
+// saveSessions writes both session cookies, since the secret moves between them.
+func saveSessions(c *gin.Context) error {
+	return errors.Join(defaultSession(c).Save(), sessions.DefaultMany(c, crypt.UnlockedCookieName).Save())
+}
+
 func getSessionId(c *gin.Context) (string, bool) {
-	session := sessions.Default(c)
+	session := defaultSession(c)
 
 	maybeSessionId := session.Get(sessionIdKey)
 
@@ -2,9 +2,9 @@ package app
 
 import (
 	"fmt"
-	"log/slog"
 	"net/http"
 
+	"softbaer.dev/ass/internal/app/requestlog"
 	"softbaer.dev/ass/internal/crypt"
 	"softbaer.dev/ass/internal/domain"
 
@@ -15,8 +15,8 @@ import (
 
 func ParticipantsNew(c *gin.Context) {
 	db := GetDB(c)
-	var courses model.Courses
-	if err := db.Select("id", "name").Find(&courses).Error; err != nil {
+	courses, err := domain.FindAllCourseData(db, crypt.GetSecret(c))
+	if err != nil {
 		DbError(c, err, "ParticipantsNew")
 
 		return
@@ -62,21 +62,31 @@ func ParticipantsCreate(c *gin.Context) {
 
 	var createdParticipant domain.Participant
 	err := db.Transaction(func(tx *gorm.DB) error {
-		var err error
-		createdParticipant, err = candidate.Save(tx, secret)
+		scope := domain.ParticipantScope()
+		err := domain.Record(tx, domain.ActionCreateParticipant, scope, func(tx *gorm.DB) error {
+			var err error
+			createdParticipant, err = candidate.Save(tx, secret)
+			scope.AddParticipant(createdParticipant.ID)
+			return err
+		})
 		if err != nil {
 			return err
 		}
 
-		return nil
+		return GetQuota(c).Check(tx)
 	})
 
+	if QuotaError(c, err) {
+		return
+	}
+
 	if err != nil {
 		DbError(c, err, "ParticipantsCreate")
 		return
 	}
 
 	if c.GetHeader("HX-Request") == "true" {
+		triggerScenarioChanged(c)
 		c.HTML(http.StatusOK, "participants/_show-with-new-button", domainToViewParticipant(createdParticipant))
 	} else {
 		c.Redirect(http.StatusSeeOther, "/scenario")
@@ -93,23 +103,27 @@ func ParticipantsDelete(c *gin.Context) {
 	err := c.BindUri(&req)
 
 	if err != nil {
-		slog.Error("Could not parse id from uri in ParticipantsDelete", "err", err)
+		requestlog.Logger(c).Error("Could not parse id from uri in ParticipantsDelete", "err", err)
 		c.AbortWithStatus(http.StatusNotFound)
 
 		return
 	}
 
 	err = db.Transaction(func(tx *gorm.DB) error {
-		return domain.DeleteParticipant(db, domain.ParticipantID(req.ID))
+		participantID := domain.ParticipantID(req.ID)
+		return domain.Record(tx, domain.ActionDeleteParticipant, domain.ParticipantScope(participantID), func(tx *gorm.DB) error {
+			return domain.DeleteParticipant(tx, participantID)
+		})
 	})
 
 	if err != nil {
-		slog.Error("Database error occurred when trying to delete participant", "err", err)
+		requestlog.Logger(c).Error("Database error occurred when trying to delete participant", "err", err)
 		DbError(c, err, "ParticipantsDelete")
 
 		return
 	}
 
+	triggerScenarioChanged(c)
 	c.Data(http.StatusOK, "text/html", []byte(""))
 }
 
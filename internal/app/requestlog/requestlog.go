@@ -0,0 +1,46 @@
+// Package requestlog keeps a logger per request on the gin context, so that the log lines of a request carry its
+// request id and the pseudonym of its session. Handlers log via Logger(c) instead of the default logger.
+package requestlog
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+)
+
+const loggerKey = "logger"
+
+// Logger returns the logger of the request. Without Set, it is the default logger.
+func Logger(c *gin.Context) *slog.Logger {
+	if val, ok := c.Get(loggerKey); ok {
+		if logger, ok := val.(*slog.Logger); ok {
+			return logger
+		}
+	}
+
+	return slog.Default()
+}
+
+// Set makes logger the logger of the request.
+func Set(c *gin.Context, logger *slog.Logger) {
+	c.Set(loggerKey, logger)
+}
+
+// With adds attributes to the log lines that follow during the request.
+func With(c *gin.Context, args ...any) {
+	Set(c, Logger(c).With(args...))
+}
+
+// NewHandler returns a handler that writes log lines as "text" (key=value pairs) or as "json" (one object per line).
+func NewHandler(format string, w io.Writer) (slog.Handler, error) {
+	switch format {
+	case "text":
+		return slog.NewTextHandler(w, nil), nil
+	case "json":
+		return slog.NewJSONHandler(w, nil), nil
+	}
+
+	return nil, fmt.Errorf("unknown log format %q, must be text or json", format)
+}
@@ -1,20 +1,38 @@
 package respond
 
 import (
-	"log/slog"
-
 	"github.com/gin-gonic/gin"
+	"softbaer.dev/ass/internal/app/requestlog"
 )
 
 func InternalServerError(c *gin.Context, logMessage string, err error, args ...any) {
-	logger := slog.With("Method", c.Request.Method, "Path", c.FullPath(), "ResponseType", "InternalServerError")
+	logger := requestlog.Logger(c).With("Method", c.Request.Method, "Path", c.FullPath(), "ResponseType", "InternalServerError")
 	args = append(args, "err", err)
 	logger.Error(logMessage, args...)
 	c.HTML(500, "general/500", gin.H{})
 }
 
 func BadRequest(c *gin.Context, logMessage string, args ...any) {
-	logger := slog.With("Method", c.Request.Method, "Path", c.FullPath(), "ResponseType", "BadRequest")
+	logger := requestlog.Logger(c).With("Method", c.Request.Method, "Path", c.FullPath(), "ResponseType", "BadRequest")
 	logger.Info(logMessage, args...)
 	c.HTML(400, "general/400", gin.H{})
 }
+
+// forbiddenMessage explains a 403, which users mostly get with a page that is older than their session.
+const forbiddenMessage = "Die Anfrage konnte nicht bestätigt werden. Bitte laden Sie die Seite neu und versuchen Sie es erneut."
+
+// Forbidden renders a page, or a dialog for requests by htmx, since their responses would replace only a fragment.
+func Forbidden(c *gin.Context, logMessage string, args ...any) {
+	logger := requestlog.Logger(c).With("Method", c.Request.Method, "Path", c.FullPath(), "ResponseType", "Forbidden")
+	logger.Info(logMessage, args...)
+
+	if c.GetHeader("HX-Request") != "true" {
+		c.HTML(403, "general/403", forbiddenMessage)
+
+		return
+	}
+
+	c.Header("HX-Reswap", "afterbegin")
+	c.Header("HX-Retarget", "body")
+	c.HTML(403, "dialogs/generic-error", forbiddenMessage)
+}
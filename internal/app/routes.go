@@ -4,9 +4,11 @@ import (
 	"github.com/gin-gonic/gin"
 	"softbaer.dev/ass/internal/app/staticfiles"
 	"softbaer.dev/ass/internal/dbdir"
+	"softbaer.dev/ass/internal/metrics"
+	"softbaer.dev/ass/internal/model/loadsave"
 )
 
-func RegisterRoutes(router *gin.Engine, dbDirectory *dbdir.DbDirectory) {
+func RegisterRoutes(router *gin.Engine, dbDirectory *dbdir.DbDirectory, sheetLimits loadsave.SheetLimits) {
 	router.GET("/health", HealthHandler())
 
 	router.Static("/static", "./static")
@@ -24,8 +26,11 @@ func RegisterRoutes(router *gin.Engine, dbDirectory *dbdir.DbDirectory) {
 	router.POST("/participants", ParticipantsCreate)
 	router.DELETE("/participants/:id", ParticipantsDelete)
 	router.GET("/participants/button-new", ParticipantsButtonNew)
+	router.GET("/participants/:id/explanation", ExplanationShow)
 
 	router.GET("/scenario", ScenarioIndex)
+	router.GET("/diagnostics", DiagnosticsIndex)
+	router.GET("/analytics", AnalyticsIndex)
 
 	router.POST("/participants/:id/assignments/:course-id", AssignmentsCreate)
 	router.PUT("/participants/:id/assignments/:course-id", AssignmentsUpdate)
@@ -33,10 +38,44 @@ func RegisterRoutes(router *gin.Engine, dbDirectory *dbdir.DbDirectory) {
 
 	router.PUT("/assignments", SolveAssignments)
 
+	router.GET("/history", HistoryIndex)
+	router.POST("/history/undo", HistoryUndo)
+	router.POST("/history/redo", HistoryRedo)
+
+	router.GET("/snapshots", SnapshotsIndex)
+	router.POST("/snapshots", SnapshotsCreate)
+	router.GET("/snapshots/compare", SnapshotsCompare)
+	router.POST("/snapshots/:id/restore", SnapshotsRestore)
+	router.DELETE("/snapshots/:id", SnapshotsDelete)
+
 	router.GET("/save", Save)
 	router.GET("/load", LoadDialog)
-	router.POST("/load", Load)
+	router.POST("/load", Load(sheetLimits))
 
-	router.GET("/sessions/new", SessionNew)
+	router.GET("/sessions/new", SessionNew(dbDirectory))
 	router.POST("sessions", SessionCreate(dbDirectory))
+	router.GET("/sessions/expiration", SessionExpiration(dbDirectory))
+	router.POST("/sessions/extend", SessionExpiration(dbDirectory))
+	router.POST("/sessions/delete", SessionDelete(dbDirectory))
+	router.GET("/sessions/recovery", SessionRecoveryForm)
+	router.POST("/sessions/recovery", SessionRecovery)
+	router.GET("/sessions/resume", SessionResumeForm)
+	router.POST("/sessions/resume", SessionResume(dbDirectory))
+	router.GET("/sessions/passphrase", SessionPassphraseForm)
+	router.POST("/sessions/passphrase", SessionPassphrase)
+	router.POST("/sessions/passphrase/remove", SessionPassphraseRemove)
+	router.POST("/sessions/lock", SessionLock)
+	router.GET("/sessions/rekey", SessionRekeyForm)
+	router.POST("/sessions/rekey", SessionRekey)
+	router.GET("/sessions/unlock", SessionUnlockForm)
+	router.POST("/sessions/unlock", SessionUnlock)
+}
+
+// RegisterMetricsRoute serves the metrics of the registries at /metrics, if access allows anyone to read them.
+func RegisterMetricsRoute(router *gin.Engine, access MetricsAccess, registries ...*metrics.Registry) {
+	if !access.Enabled() {
+		return
+	}
+
+	router.GET("/metrics", MetricsIndex(access, registries...))
 }
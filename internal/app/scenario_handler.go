@@ -1,10 +1,10 @@
 package app
 
 import (
-	"log/slog"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"softbaer.dev/ass/internal/app/requestlog"
 	"softbaer.dev/ass/internal/app/respond"
 	"softbaer.dev/ass/internal/crypt"
 	"softbaer.dev/ass/internal/domain"
@@ -23,7 +23,7 @@ func ScenarioIndex(c *gin.Context) {
 	err := c.Bind(&req)
 
 	if err != nil {
-		slog.Error("Bad request on AssignmentsIndex", "err", err)
+		requestlog.Logger(c).Error("Bad request on AssignmentsIndex", "err", err)
 		return
 	}
 
@@ -61,11 +61,20 @@ func ScenarioIndex(c *gin.Context) {
 		uiCourses.AppendCourse(uiCourse)
 	}
 
+	warnings := popViolationWarnings(c)
+	diagnostics := toViewDiagnostics(domain.Diagnose(scenario))
+
+	history, err := domain.History(db)
+	if err != nil {
+		respond.InternalServerError(c, "Error while loading history", err)
+		return
+	}
+
 	if c.GetHeader("HX-Request") == "true" {
-		c.HTML(http.StatusOK, "scenario/index", gin.H{"fullPage": false, "participants": uiParticipants, "courseList": uiCourses})
+		c.HTML(http.StatusOK, "scenario/index", gin.H{"fullPage": false, "participants": uiParticipants, "courseList": uiCourses, "warnings": warnings, "diagnostics": diagnostics, "history": toViewHistory(history)})
 
 		return
 	}
 
-	c.HTML(http.StatusOK, "scenario/index", gin.H{"fullPage": true, "participants": uiParticipants, "courseList": uiCourses})
+	c.HTML(http.StatusOK, "scenario/index", gin.H{"fullPage": true, "participants": uiParticipants, "courseList": uiCourses, "warnings": warnings, "diagnostics": diagnostics, "history": toViewHistory(history)})
 }
@@ -1,17 +1,127 @@
 package server
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
 	"errors"
 	"fmt"
+	"net"
+	"net/netip"
 	"strconv"
+	"strings"
 	"time"
+
+	"softbaer.dev/ass/internal/app"
+	"softbaer.dev/ass/internal/domain"
+	"softbaer.dev/ass/internal/model/loadsave"
 )
 
+// defaultSessionMaxLifetime is used if PRIOBAER_SESSION_MAX_LIFETIME is not set.
+const defaultSessionMaxLifetime = 7 * 24 * time.Hour
+
+// defaultListenAddress is used if PRIOBAER_LISTEN_ADDRESS is not set. Containers need 0.0.0.0 instead.
+const defaultListenAddress = "localhost"
+
+// defaultMaxOpenDbs is used if PRIOBAER_MAX_OPEN_DBS is not set.
+const defaultMaxOpenDbs = 100
+
+// These limits are used if their variables are not set. They are generous for real scenarios, but keep single
+// clients from exhausting memory or flooding the server.
+const defaultMaxUploadBytes = 5_000_000
+const defaultMaxSheetRows = 10_000
+const defaultMaxSheetColumns = 200
+const defaultSessionRateLimit = 120
+const defaultIPRateLimit = 600
+const defaultPassphraseRateLimit = 10
+
 type Config struct {
+	// Storage is either "file" or "memory". Sessions in memory are lost on restart, which suits demos.
+	Storage       string
 	DbRootDir     string
 	SessionMaxAge time.Duration
+	// SessionMaxLifetime caps how long activity can keep a session alive.
+	SessionMaxLifetime time.Duration
+	// MaxOpenDbs limits how many session dbs are kept open at the same time. Zero means no limit.
+	MaxOpenDbs int
+	// MaxSessions limits how many sessions can exist at the same time. Zero means no limit.
+	MaxSessions int
+	// EvictOldestSession makes room for new sessions by removing the oldest one instead of refusing them.
+	EvictOldestSession bool
+	Quota              domain.Quota
+	// MaxUploadBytes limits the size of request bodies, most notably uploaded Excel files. Zero means no limit.
+	MaxUploadBytes int64
+	SheetLimits    loadsave.SheetLimits
+	// SessionRateLimit and IPRateLimit limit the state-changing requests per minute of a session or an IP address.
+	// Zero means no limit.
+	SessionRateLimit int
+	IPRateLimit      int
+	// PassphraseRateLimit limits the requests per minute of an IP address that derive a key from a passphrase, since
+	// every derivation costs considerable memory and time. Zero means no limit.
+	PassphraseRateLimit int
+	// ListenAddress is the host or IP address the server listens on at Port.
+	ListenAddress string
 	Port          int
-	Secret        string
+	// TLSCertFile and TLSKeyFile make the server serve https. Either both are set or none.
+	TLSCertFile string
+	TLSKeyFile  string
+	// TrustedProxies are the reverse proxies whose X-Forwarded-For and X-Forwarded-Proto headers are trusted.
+	TrustedProxies []netip.Prefix
+	CookieSecurity app.CookieSecurity
+	// LogFormat is either "text" (key=value pairs) or "json" (one object per line), which suits log collectors.
+	LogFormat string
+	// Metrics restricts access to /metrics, which is only served if a token or networks are configured.
+	Metrics app.MetricsAccess
+	// Secret signs the session cookies. Cookies signed with one of the PreviousSecrets are still accepted and signed
+	// with Secret when they are sent back, so that secrets can be rotated without logging everyone out.
+	Secret          string
+	PreviousSecrets []string
+}
+
+// CookieKeyPairs returns the keys of the cookie store, current secret first. Cookies are signed and encrypted, since
+// they carry the secret of the session. Cookies that were only signed are still accepted and encrypted the next time
+// they are saved.
+func (c Config) CookieKeyPairs() [][]byte {
+	var pairs [][]byte
+	for _, secret := range append([]string{c.Secret}, c.PreviousSecrets...) {
+		pairs = append(pairs, []byte(secret), cookieEncryptionKey(secret))
+	}
+	for _, secret := range append([]string{c.Secret}, c.PreviousSecrets...) {
+		pairs = append(pairs, []byte(secret), nil)
+	}
+
+	return pairs
+}
+
+// cookieEncryptionLabel separates the encryption key of cookies from their signing key.
+const cookieEncryptionLabel = "cookie encryption"
+
+// cookieEncryptionKey derives an AES-256 key for the cookies from the secret that signs them.
+func cookieEncryptionKey(secret string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(cookieEncryptionLabel))
+
+	return mac.Sum(nil)
+}
+
+// Addr returns the address the server listens on.
+func (c Config) Addr() string {
+	return net.JoinHostPort(c.ListenAddress, strconv.Itoa(c.Port))
+}
+
+// ServesTLS tells whether the server serves https itself.
+func (c Config) ServesTLS() bool {
+	return c.TLSCertFile != ""
+}
+
+// TrustedProxyStrings returns the trusted proxies in the form gin expects.
+func (c Config) TrustedProxyStrings() []string {
+	proxies := make([]string, 0, len(c.TrustedProxies))
+	for _, proxy := range c.TrustedProxies {
+		proxies = append(proxies, proxy.String())
+	}
+
+	return proxies
 }
 
 func ParseConfig(getenv func(string) string) (Config, error) {
@@ -25,9 +135,25 @@ func ParseConfig(getenv func(string) string) (Config, error) {
 
 	config.Secret = secret
 
+	for previous := range strings.SplitSeq(getenv("PRIOBAER_PREVIOUS_SECRETS"), ",") {
+		if previous = strings.TrimSpace(previous); previous != "" {
+			config.PreviousSecrets = append(config.PreviousSecrets, previous)
+		}
+	}
+
+	config.Storage = getenv("PRIOBAER_STORAGE")
+
+	switch config.Storage {
+	case "":
+		config.Storage = "file"
+	case "file", "memory":
+	default:
+		return config, fmt.Errorf("PRIOBAER_STORAGE must be file or memory, got %q", config.Storage)
+	}
+
 	dbRootDir := getenv("PRIOBAER_DB_ROOT_DIR")
 
-	if dbRootDir == "" {
+	if dbRootDir == "" && config.Storage == "file" {
 		return config, errors.New("PRIOBAER_DB_ROOT_DIR not set")
 	}
 
@@ -41,17 +167,213 @@ func ParseConfig(getenv func(string) string) (Config, error) {
 
 	config.SessionMaxAge = time.Second * time.Duration(sessionMaxAge)
 
+	config.SessionMaxLifetime = defaultSessionMaxLifetime
+	if getenv("PRIOBAER_SESSION_MAX_LIFETIME") != "" {
+		sessionMaxLifetime, err := GetInt(getenv, "PRIOBAER_SESSION_MAX_LIFETIME")
+
+		if err != nil {
+			return config, err
+		}
+
+		config.SessionMaxLifetime = time.Second * time.Duration(sessionMaxLifetime)
+	}
+
+	config.MaxOpenDbs = defaultMaxOpenDbs
+	if getenv("PRIOBAER_MAX_OPEN_DBS") != "" {
+		maxOpenDbs, err := GetInt(getenv, "PRIOBAER_MAX_OPEN_DBS")
+
+		if err != nil {
+			return config, err
+		}
+
+		if maxOpenDbs < 0 {
+			return config, errors.New("PRIOBAER_MAX_OPEN_DBS must not be negative")
+		}
+
+		config.MaxOpenDbs = maxOpenDbs
+	}
+
+	quotaVars := []struct {
+		key    string
+		target *int
+	}{
+		{"PRIOBAER_MAX_PARTICIPANTS", &config.Quota.MaxParticipants},
+		{"PRIOBAER_MAX_COURSES", &config.Quota.MaxCourses},
+		{"PRIOBAER_MAX_SESSIONS", &config.MaxSessions},
+	}
+
+	for _, quotaVar := range quotaVars {
+		if getenv(quotaVar.key) == "" {
+			continue
+		}
+
+		value, err := GetInt(getenv, quotaVar.key)
+
+		if err != nil {
+			return config, err
+		}
+
+		if value < 0 {
+			return config, fmt.Errorf("%s must not be negative", quotaVar.key)
+		}
+
+		*quotaVar.target = value
+	}
+
+	if getenv("PRIOBAER_MAX_DB_BYTES") != "" {
+		maxDbBytes, err := GetInt(getenv, "PRIOBAER_MAX_DB_BYTES")
+
+		if err != nil {
+			return config, err
+		}
+
+		if maxDbBytes < 0 {
+			return config, errors.New("PRIOBAER_MAX_DB_BYTES must not be negative")
+		}
+
+		config.Quota.MaxDbBytes = int64(maxDbBytes)
+	}
+
+	config.EvictOldestSession = getenv("PRIOBAER_EVICT_OLDEST_SESSION") == "true"
+
+	maxUploadBytes := defaultMaxUploadBytes
+	config.SheetLimits = loadsave.SheetLimits{MaxRows: defaultMaxSheetRows, MaxColumns: defaultMaxSheetColumns}
+	config.SessionRateLimit = defaultSessionRateLimit
+	config.IPRateLimit = defaultIPRateLimit
+	config.PassphraseRateLimit = defaultPassphraseRateLimit
+
+	limitVars := []struct {
+		key    string
+		target *int
+	}{
+		{"PRIOBAER_MAX_UPLOAD_BYTES", &maxUploadBytes},
+		{"PRIOBAER_MAX_SHEET_ROWS", &config.SheetLimits.MaxRows},
+		{"PRIOBAER_MAX_SHEET_COLUMNS", &config.SheetLimits.MaxColumns},
+		{"PRIOBAER_SESSION_RATE_LIMIT", &config.SessionRateLimit},
+		{"PRIOBAER_IP_RATE_LIMIT", &config.IPRateLimit},
+		{"PRIOBAER_PASSPHRASE_RATE_LIMIT", &config.PassphraseRateLimit},
+	}
+
+	for _, limitVar := range limitVars {
+		if getenv(limitVar.key) == "" {
+			continue
+		}
+
+		value, err := GetInt(getenv, limitVar.key)
+
+		if err != nil {
+			return config, err
+		}
+
+		if value < 0 {
+			return config, fmt.Errorf("%s must not be negative", limitVar.key)
+		}
+
+		*limitVar.target = value
+	}
+
+	config.MaxUploadBytes = int64(maxUploadBytes)
+
 	port, err := GetInt(getenv, "PRIOBAER_PORT")
 
 	if err != nil {
 		return config, err
 	}
 
+	if port < 1 || port > 65535 {
+		return config, fmt.Errorf("PRIOBAER_PORT must be between 1 and 65535, got %d", port)
+	}
+
 	config.Port = port
 
+	config.ListenAddress = defaultListenAddress
+	if listenAddress := getenv("PRIOBAER_LISTEN_ADDRESS"); listenAddress != "" {
+		if _, _, err := net.SplitHostPort(listenAddress); err == nil {
+			return config, fmt.Errorf("PRIOBAER_LISTEN_ADDRESS must not contain a port, use PRIOBAER_PORT instead, got %q", listenAddress)
+		}
+
+		config.ListenAddress = listenAddress
+	}
+
+	config.TLSCertFile = getenv("PRIOBAER_TLS_CERT_FILE")
+	config.TLSKeyFile = getenv("PRIOBAER_TLS_KEY_FILE")
+
+	if (config.TLSCertFile == "") != (config.TLSKeyFile == "") {
+		return config, errors.New("PRIOBAER_TLS_CERT_FILE and PRIOBAER_TLS_KEY_FILE must be set together")
+	}
+
+	if config.ServesTLS() {
+		// Loading the pair once catches unreadable or mismatching files at startup instead of at the first request.
+		if _, err := tls.LoadX509KeyPair(config.TLSCertFile, config.TLSKeyFile); err != nil {
+			return config, fmt.Errorf("could not load PRIOBAER_TLS_CERT_FILE and PRIOBAER_TLS_KEY_FILE: %w", err)
+		}
+	}
+
+	for proxy := range strings.SplitSeq(getenv("PRIOBAER_TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy == "" {
+			continue
+		}
+
+		prefix, err := parsePrefix(proxy)
+		if err != nil {
+			return config, fmt.Errorf("PRIOBAER_TRUSTED_PROXIES must contain IP addresses or CIDRs, got %q", proxy)
+		}
+
+		config.TrustedProxies = append(config.TrustedProxies, prefix)
+	}
+
+	config.Metrics.Token = getenv("PRIOBAER_METRICS_TOKEN")
+
+	for network := range strings.SplitSeq(getenv("PRIOBAER_METRICS_NETWORKS"), ",") {
+		if network = strings.TrimSpace(network); network == "" {
+			continue
+		}
+
+		prefix, err := parsePrefix(network)
+		if err != nil {
+			return config, fmt.Errorf("PRIOBAER_METRICS_NETWORKS must contain IP addresses or CIDRs, got %q", network)
+		}
+
+		config.Metrics.Networks = append(config.Metrics.Networks, prefix)
+	}
+
+	config.CookieSecurity = app.CookieSecurity(getenv("PRIOBAER_COOKIE_SECURE"))
+
+	switch config.CookieSecurity {
+	case "":
+		config.CookieSecurity = app.CookieSecureAlways
+	case app.CookieSecureAlways, app.CookieSecureNever, app.CookieSecureAuto:
+	default:
+		return config, fmt.Errorf("PRIOBAER_COOKIE_SECURE must be always, never or auto, got %q", config.CookieSecurity)
+	}
+
+	config.LogFormat = getenv("PRIOBAER_LOG_FORMAT")
+
+	switch config.LogFormat {
+	case "":
+		config.LogFormat = "text"
+	case "text", "json":
+	default:
+		return config, fmt.Errorf("PRIOBAER_LOG_FORMAT must be text or json, got %q", config.LogFormat)
+	}
+
 	return config, nil
 }
 
+// parsePrefix parses a CIDR or a single IP address, which is treated as a CIDR that contains only this address.
+func parsePrefix(s string) (netip.Prefix, error) {
+	if addr, err := netip.ParseAddr(s); err == nil {
+		return netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()), nil
+	}
+
+	prefix, err := netip.ParsePrefix(s)
+	if err != nil {
+		return prefix, err
+	}
+
+	return prefix.Masked(), nil
+}
+
 func GetInt(getenv func(string) string, key string) (int, error) {
 	sessionMaxAgeString := getenv(key)
 
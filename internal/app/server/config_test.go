@@ -0,0 +1,165 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"softbaer.dev/ass/internal/app"
+)
+
+func mapEnv(pairs ...string) func(string) string {
+	env := make(map[string]string)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		env[pairs[i]] = pairs[i+1]
+	}
+
+	return func(key string) string { return env[key] }
+}
+
+func minimalEnv(pairs ...string) func(string) string {
+	return mapEnv(append([]string{
+		"PRIOBAER_SECRET", "secret",
+		"PRIOBAER_DB_ROOT_DIR", "db",
+		"PRIOBAER_SESSION_MAX_AGE", "60",
+		"PRIOBAER_PORT", "8080",
+	}, pairs...)...)
+}
+
+func TestParseConfigDefaults(t *testing.T) {
+	is := is.New(t)
+
+	config, err := ParseConfig(minimalEnv())
+	is.NoErr(err)
+
+	is.Equal(config.Addr(), "localhost:8080")
+	is.True(!config.ServesTLS())
+	is.Equal(len(config.TrustedProxies), 0) // forwarding headers are not trusted unless configured
+	is.Equal(config.CookieSecurity, app.CookieSecureAlways)
+	is.Equal(config.LogFormat, "text")
+}
+
+func TestParseConfigNetworkSettings(t *testing.T) {
+	is := is.New(t)
+
+	config, err := ParseConfig(minimalEnv(
+		"PRIOBAER_LISTEN_ADDRESS", "::",
+		"PRIOBAER_TRUSTED_PROXIES", "10.0.0.0/8, 192.168.1.1,::1",
+		"PRIOBAER_COOKIE_SECURE", "auto",
+	))
+	is.NoErr(err)
+
+	is.Equal(config.Addr(), "[::]:8080")
+	is.Equal(config.TrustedProxies, []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("192.168.1.1/32"),
+		netip.MustParsePrefix("::1/128"),
+	})
+	is.Equal(config.CookieSecurity, app.CookieSecureAuto)
+}
+
+func TestParseConfigRefusesInvalidSettings(t *testing.T) {
+	testcases := []struct {
+		name  string
+		pairs []string
+	}{
+		{"Listen address with port", []string{"PRIOBAER_LISTEN_ADDRESS", "0.0.0.0:80"}},
+		{"Port out of range", []string{"PRIOBAER_PORT", "70000"}},
+		{"Certificate without key", []string{"PRIOBAER_TLS_CERT_FILE", "cert.pem"}},
+		{"Missing certificate files", []string{"PRIOBAER_TLS_CERT_FILE", "missing.pem", "PRIOBAER_TLS_KEY_FILE", "missing.key"}},
+		{"Hostname as trusted proxy", []string{"PRIOBAER_TRUSTED_PROXIES", "proxy.local"}},
+		{"Unknown cookie mode", []string{"PRIOBAER_COOKIE_SECURE", "sometimes"}},
+		{"Negative rate limit", []string{"PRIOBAER_IP_RATE_LIMIT", "-1"}},
+		{"Unknown log format", []string{"PRIOBAER_LOG_FORMAT", "xml"}},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			is := is.New(t)
+
+			_, err := ParseConfig(minimalEnv(tc.pairs...))
+			is.True(err != nil)
+		})
+	}
+}
+
+func TestParseConfigLoadsTLSKeyPair(t *testing.T) {
+	is := is.New(t)
+	certFile, keyFile := writeSelfSignedCertificate(t)
+
+	config, err := ParseConfig(minimalEnv("PRIOBAER_TLS_CERT_FILE", certFile, "PRIOBAER_TLS_KEY_FILE", keyFile))
+	is.NoErr(err)
+	is.True(config.ServesTLS())
+
+	_, err = ParseConfig(minimalEnv("PRIOBAER_TLS_CERT_FILE", certFile, "PRIOBAER_TLS_KEY_FILE", certFile))
+	is.True(err != nil) // a certificate is no private key
+}
+
+func TestConfigSourcesPrecedence(t *testing.T) {
+	is := is.New(t)
+
+	configFile := filepath.Join(t.TempDir(), "priobaer.env")
+	err := os.WriteFile(configFile, []byte(`# comment
+export PRIOBAER_PORT=1000
+PRIOBAER_LISTEN_ADDRESS="0.0.0.0"
+PRIOBAER_DB_ROOT_DIR='from file'
+PRIOBAER_STORAGE=file
+`), 0o600)
+	is.NoErr(err)
+
+	getenv, err := ConfigSources(
+		[]string{"-config", configFile, "-port", "3000"},
+		mapEnv("PRIOBAER_PORT", "2000", "PRIOBAER_DB_ROOT_DIR", "from env"),
+		io.Discard,
+	)
+	is.NoErr(err)
+
+	is.Equal(getenv("PRIOBAER_PORT"), "3000")            // flags take precedence over everything
+	is.Equal(getenv("PRIOBAER_DB_ROOT_DIR"), "from env") // environment variables take precedence over the file
+	is.Equal(getenv("PRIOBAER_LISTEN_ADDRESS"), "0.0.0.0")
+	is.Equal(getenv("PRIOBAER_STORAGE"), "file")
+	is.Equal(getenv("PRIOBAER_MAX_COURSES"), "")
+}
+
+func TestConfigSourcesRefusesUnknownFlags(t *testing.T) {
+	is := is.New(t)
+
+	_, err := ConfigSources([]string{"-secret", "visible to everyone"}, mapEnv(), io.Discard)
+	is.True(err != nil) // secrets must not be passed as flags
+}
+
+func writeSelfSignedCertificate(t *testing.T) (certFile, keyFile string) {
+	is := is.New(t)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	is.NoErr(err)
+
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	is.NoErr(err)
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	is.NoErr(err)
+
+	dir := t.TempDir()
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	is.NoErr(os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	is.NoErr(os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0o600))
+
+	return certFile, keyFile
+}
@@ -6,20 +6,23 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"os"
 
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-contrib/sessions/cookie"
 	"github.com/gin-gonic/gin"
 	"github.com/jonboulle/clockwork"
 	"softbaer.dev/ass/internal/app"
+	"softbaer.dev/ass/internal/app/requestlog"
+	"softbaer.dev/ass/internal/crypt"
 	"softbaer.dev/ass/internal/dbdir"
+	"softbaer.dev/ass/internal/metrics"
 	"softbaer.dev/ass/internal/model"
+	"softbaer.dev/ass/internal/ratelimit"
 	"softbaer.dev/ass/internal/ui"
 )
 
 func Run(ctx context.Context, getenv func(string) string, clock clockwork.Clock) error {
-	router := gin.Default()
-
 	templates, err := ui.LoadTemplate()
 
 	if err != nil {
@@ -32,41 +35,92 @@ func Run(ctx context.Context, getenv func(string) string, clock clockwork.Clock)
 		panic(fmt.Sprintf("Could not parse config from env, Err: %v. Panic...", err))
 	}
 
-	sessionMaxAgeSeconds := int(config.SessionMaxAge.Seconds())
-
-	if sessionMaxAgeSeconds == 0 {
-		sessionMaxAgeSeconds = 1
+	logHandler, err := requestlog.NewHandler(config.LogFormat, os.Stderr)
+	if err != nil {
+		return err
 	}
-
-	cookieStore := cookie.NewStore([]byte(config.Secret))
-	cookieStore.Options(
-		sessions.Options{
-			Secure:   true,
-			HttpOnly: true,
-			SameSite: http.SameSiteLaxMode,
-			MaxAge:   sessionMaxAgeSeconds,
-		},
+	// Packages without access to the request, e.g. dbdir, log via the default logger, which should use the same format.
+	logger := slog.New(logHandler)
+	slog.SetDefault(logger)
+
+	// Requests are logged by app.LogRequests instead of gin's logger, so that every line carries the request id.
+	router := gin.New()
+	router.Use(gin.Recovery())
+
+	cookieStore := cookie.NewStore(config.CookieKeyPairs()...)
+	cookieStore.Options(app.SessionCookieOptions(config.SessionMaxAge, config.CookieSecurity != app.CookieSecureNever))
+
+	dbDirectory, err := dbdir.New(
+		config.DbRootDir,
+		config.SessionMaxAge,
+		clock,
+		nil,
+		dbdir.WithStorage(newStorage(config)),
+		dbdir.WithMigrations(model.Migrations),
+		dbdir.WithMaxLifetime(config.SessionMaxLifetime),
+		dbdir.WithMaxOpenConnections(config.MaxOpenDbs),
+		dbdir.WithMaxSessions(config.MaxSessions, config.EvictOldestSession),
+		dbdir.WithPseudonymSecret(crypt.Secret(config.Secret)),
 	)
 
-	dbDirectory, err := dbdir.New(config.DbRootDir, config.SessionMaxAge, clock, []any{&model.Course{}, model.EmptyParticipantPointer(), &model.Priority{}})
-
 	if err != nil {
 		panic(err)
 	}
 
-	router.Use(sessions.Sessions("session", cookieStore))
+	registry := metrics.NewRegistry()
+	registry.NewGaugeFunc("priobaer_sessions", "Sessions whose databases currently exist.", func() (float64, error) {
+		return float64(dbDirectory.Stats().Sessions), nil
+	})
+	registry.NewGaugeFunc("priobaer_open_dbs", "Session databases that are currently open.", func() (float64, error) {
+		return float64(dbDirectory.Stats().OpenConnections), nil
+	})
+	if config.Storage == "file" {
+		registry.NewGaugeFunc("priobaer_db_dir_bytes", "Disk usage of the session databases, including quarantined ones.", func() (float64, error) {
+			usage, err := dbdir.DiskUsage(config.DbRootDir)
+			return float64(usage), err
+		})
+	}
+
+	router.Use(app.LogRequests(logger, config.TrustedProxies))
+	router.Use(app.RecordRequestMetrics(registry))
+	router.Use(app.SecurityHeaders())
+	router.Use(app.LimitRequestBody(config.MaxUploadBytes))
+	// Without trusted proxies, gin would take the client's IP address from headers anyone can set.
+	if err := router.SetTrustedProxies(config.TrustedProxyStrings()); err != nil {
+		return fmt.Errorf("could not set trusted proxies: %w", err)
+	}
+
+	router.Use(sessions.SessionsMany([]string{crypt.SessionCookieName, crypt.UnlockedCookieName}, cookieStore))
+	router.Use(app.LogSessionPseudonym(crypt.Secret(config.Secret)))
+	router.Use(app.InjectCookieSecurity(config.CookieSecurity, config.TrustedProxies, config.SessionMaxAge))
+	router.Use(app.VerifyCSRF())
+	router.Use(app.RateLimit(ratelimit.New(config.SessionRateLimit, clock), ratelimit.New(config.IPRateLimit, clock)))
+	router.Use(app.LimitPassphraseAttempts(ratelimit.New(config.PassphraseRateLimit, clock)))
 	router.Use(app.InjectDB(dbDirectory))
+	router.Use(app.RequireUnlocked())
+	router.Use(app.BindCiphertexts())
+	router.Use(app.InjectQuota(config.Quota))
 
 	router.SetHTMLTemplate(templates)
 
-	app.RegisterRoutes(router, dbDirectory)
+	app.RegisterRoutes(router, dbDirectory, config.SheetLimits)
+	app.RegisterMetricsRoute(router, config.Metrics, metrics.Default, registry)
 
 	server := &http.Server{
-		Addr:    fmt.Sprintf("localhost:%d", config.Port),
+		Addr:    config.Addr(),
 		Handler: router.Handler(),
 	}
 	go func() {
-		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		slog.Info("Listening", "addr", server.Addr, "tls", config.ServesTLS())
+
+		var err error
+		if config.ServesTLS() {
+			err = server.ListenAndServeTLS(config.TLSCertFile, config.TLSKeyFile)
+		} else {
+			err = server.ListenAndServe()
+		}
+
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
 			slog.Error("ListenAndServe returned an error", "err", err)
 		}
 	}()
@@ -81,3 +135,11 @@ func Run(ctx context.Context, getenv func(string) string, clock clockwork.Clock)
 
 	return nil
 }
+
+func newStorage(config Config) dbdir.Storage {
+	if config.Storage == "memory" {
+		return dbdir.NewMemoryStorage()
+	}
+
+	return dbdir.NewFileStorage(config.DbRootDir)
+}
@@ -0,0 +1,132 @@
+package server
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// configKeys are the variables the config is parsed from, along with their description. Each of them can also be
+// passed as flag, e.g. PRIOBAER_LISTEN_ADDRESS as -listen-address. Secrets are left out, since flags are visible to
+// everyone who can list processes.
+var configKeys = []struct {
+	key   string
+	usage string
+}{
+	{"PRIOBAER_LISTEN_ADDRESS", "host or IP address to listen on (default: localhost)"},
+	{"PRIOBAER_PORT", "port to listen on"},
+	{"PRIOBAER_TLS_CERT_FILE", "certificate to serve https with, requires PRIOBAER_TLS_KEY_FILE"},
+	{"PRIOBAER_TLS_KEY_FILE", "private key of the certificate"},
+	{"PRIOBAER_TRUSTED_PROXIES", "comma-separated IP addresses or CIDRs of reverse proxies whose forwarding headers are trusted"},
+	{"PRIOBAER_COOKIE_SECURE", "always, never or auto: whether cookies are only sent via https (default: always)"},
+	{"PRIOBAER_LOG_FORMAT", "text or json (default: text)"},
+	{"PRIOBAER_METRICS_NETWORKS", "comma-separated IP addresses or CIDRs that can read /metrics, see also PRIOBAER_METRICS_TOKEN"},
+	{"PRIOBAER_STORAGE", "file or memory (default: file)"},
+	{"PRIOBAER_DB_ROOT_DIR", "directory of the session databases"},
+	{"PRIOBAER_SESSION_MAX_AGE", "seconds a session lives without activity"},
+	{"PRIOBAER_SESSION_MAX_LIFETIME", "seconds activity can keep a session alive"},
+	{"PRIOBAER_MAX_OPEN_DBS", "maximum number of open session databases"},
+	{"PRIOBAER_MAX_PARTICIPANTS", "maximum number of participants per session"},
+	{"PRIOBAER_MAX_COURSES", "maximum number of courses per session"},
+	{"PRIOBAER_MAX_DB_BYTES", "maximum size of a session database"},
+	{"PRIOBAER_MAX_SESSIONS", "maximum number of sessions"},
+	{"PRIOBAER_EVICT_OLDEST_SESSION", "true to remove the oldest session beyond the maximum number of sessions"},
+	{"PRIOBAER_MAX_UPLOAD_BYTES", "maximum size of request bodies"},
+	{"PRIOBAER_MAX_SHEET_ROWS", "maximum number of rows per uploaded sheet"},
+	{"PRIOBAER_MAX_SHEET_COLUMNS", "maximum number of columns per uploaded sheet"},
+	{"PRIOBAER_SESSION_RATE_LIMIT", "state-changing requests per minute and session"},
+	{"PRIOBAER_IP_RATE_LIMIT", "state-changing requests per minute and IP address"},
+	{"PRIOBAER_PASSPHRASE_RATE_LIMIT", "passphrase attempts per minute and IP address"},
+}
+
+// ConfigSources combines the sources of the config into one getenv for ParseConfig. Flags take precedence over
+// environment variables, which take precedence over the config file given by -config or PRIOBAER_CONFIG_FILE.
+func ConfigSources(args []string, getenv func(string) string, stderr io.Writer) (func(string) string, error) {
+	flags := flag.NewFlagSet("server", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	configFile := flags.String("config", getenv("PRIOBAER_CONFIG_FILE"), "file with KEY=VALUE lines, e.g. .dev-linux.env")
+
+	keysByFlag := make(map[string]string, len(configKeys))
+	for _, configKey := range configKeys {
+		flags.String(flagName(configKey.key), "", configKey.usage)
+		keysByFlag[flagName(configKey.key)] = configKey.key
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	if flags.NArg() != 0 {
+		return nil, fmt.Errorf("unexpected arguments: %v", flags.Args())
+	}
+
+	setFlags := make(map[string]string)
+	flags.Visit(func(f *flag.Flag) {
+		if key, ok := keysByFlag[f.Name]; ok {
+			setFlags[key] = f.Value.String()
+		}
+	})
+
+	fileValues := map[string]string{}
+	if *configFile != "" {
+		var err error
+		if fileValues, err = readConfigFile(*configFile); err != nil {
+			return nil, err
+		}
+	}
+
+	return func(key string) string {
+		if value, ok := setFlags[key]; ok {
+			return value
+		}
+		if value := getenv(key); value != "" {
+			return value
+		}
+
+		return fileValues[key]
+	}, nil
+}
+
+func flagName(key string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimPrefix(key, "PRIOBAER_"), "_", "-"))
+}
+
+// readConfigFile reads KEY=VALUE lines in the format of env files, so that .dev-linux.env can be passed as is. Empty
+// lines and lines starting with # are skipped, an export prefix and quotes around the value are dropped.
+func readConfigFile(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open config file: %w", err)
+	}
+	defer file.Close()
+
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: expected KEY=VALUE, got %q", path, lineNumber, line)
+		}
+
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		values[strings.TrimSpace(key)] = value
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read config file: %w", err)
+	}
+
+	return values, nil
+}
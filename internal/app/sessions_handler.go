@@ -1,46 +1,559 @@
 package app
 
 import (
-	"log/slog"
+	"errors"
+	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"softbaer.dev/ass/internal/app/requestlog"
+	"softbaer.dev/ass/internal/app/respond"
 	"softbaer.dev/ass/internal/crypt"
 	"softbaer.dev/ass/internal/dbdir"
+	"softbaer.dev/ass/internal/domain"
+	"softbaer.dev/ass/internal/ui"
 )
 
-func SessionNew(c *gin.Context) {
-	c.HTML(http.StatusOK, "sessions/new", nil)
+// retentionChoices are the retention periods users can pick from, if the max lifetime of the sessions allows them.
+var retentionChoices = []ui.RetentionChoice{
+	{Seconds: int((time.Hour).Seconds()), Label: "1 Stunde"},
+	{Seconds: int((24 * time.Hour).Seconds()), Label: "1 Tag", Selected: true},
+	{Seconds: int((7 * 24 * time.Hour).Seconds()), Label: "7 Tage"},
+}
+
+func SessionNew(dbDirectory *dbdir.DbDirectory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var choices []ui.RetentionChoice
+		for _, choice := range retentionChoices {
+			if time.Duration(choice.Seconds)*time.Second <= dbDirectory.MaxLifetime() {
+				choices = append(choices, choice)
+			}
+		}
+
+		c.HTML(http.StatusOK, "sessions/new", gin.H{"retentionChoices": choices})
+	}
 }
 
 func SessionCreate(dbDirectory *dbdir.DbDirectory) gin.HandlerFunc {
+	type request struct {
+		// RetentionSeconds is optional. Without it, the session expires after the configured max age.
+		RetentionSeconds *int `form:"retention"`
+	}
+
 	return func(c *gin.Context) {
-		session := sessions.Default(c)
+		var req request
+		if err := c.Bind(&req); err != nil {
+			requestlog.Logger(c).Error("Bad request on SessionCreate", "err", err)
+			return
+		}
+
+		if req.RetentionSeconds != nil && !dbDirectory.ValidRetention(time.Duration(*req.RetentionSeconds)*time.Second) {
+			respond.BadRequest(c, "Invalid retention on SessionCreate", "retentionSeconds", *req.RetentionSeconds)
+			return
+		}
+
+		session := defaultSession(c)
 		newDbId, err := uuid.NewRandom()
 
 		if err != nil {
-			slog.Error("Failed while generating uuid", "err", err)
+			requestlog.Logger(c).Error("Failed while generating uuid", "err", err)
 			c.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+
+		// The db is created before the session is saved, so that users do not get a session without db if there
+		// are too many sessions.
+		if req.RetentionSeconds == nil {
+			_, err = dbDirectory.Open(newDbId.String())
+		} else {
+			_, err = dbDirectory.Create(newDbId.String(), time.Duration(*req.RetentionSeconds)*time.Second)
+		}
+
+		if errors.Is(err, dbdir.ErrTooManySessions) {
+			sessionsFull(c)
+			return
+		}
+
+		if err != nil {
+			requestlog.Logger(c).Error("Failed to open new db", "err", err)
+			c.AbortWithStatus(http.StatusInternalServerError)
+			return
 		}
 
 		session.Set(sessionIdKey, newDbId.String())
 		crypt.SetNewSecret(c)
-		err = session.Save()
+		err = saveSessions(c)
 
 		if err != nil {
-			slog.Error("Failed while saving session", "err", err)
+			requestlog.Logger(c).Error("Failed while saving session", "err", err)
 			c.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+
+		// The recovery code is shown once right away, so that users can note it before they start working.
+		c.Redirect(http.StatusSeeOther, "/sessions/recovery")
+	}
+}
+
+// minPassphraseLength applies to passphrases chosen by users. Generated ones are longer.
+const minPassphraseLength = 10
+
+// SessionRecoveryForm shows a recovery code for the current session along with the generated passphrase it is wrapped
+// with. Every call returns a new code, but all of them stay valid as long as the session exists.
+func SessionRecoveryForm(c *gin.Context) {
+	showRecoveryCode(c, crypt.GeneratePassphrase(), true)
+}
+
+// SessionRecovery shows a recovery code wrapped with the passphrase chosen by the user. The passphrase is only read
+// from the request body, since URLs end up in access logs and the browser history.
+func SessionRecovery(c *gin.Context) {
+	type request struct {
+		Passphrase string `form:"passphrase"`
+	}
+
+	var req request
+	if err := c.ShouldBindWith(&req, binding.FormPost); err != nil {
+		respond.BadRequest(c, "Bad request on SessionRecovery", "err", err)
+		return
+	}
+
+	if req.Passphrase == "" {
+		showRecoveryCode(c, crypt.GeneratePassphrase(), true)
+		return
+	}
+
+	showRecoveryCode(c, req.Passphrase, false)
+}
+
+func showRecoveryCode(c *gin.Context, passphrase string, generated bool) {
+	if len([]rune(passphrase)) < minPassphraseLength {
+		c.HTML(http.StatusUnprocessableEntity, "sessions/recovery", gin.H{
+			"Errors": map[string]string{"passphrase": fmt.Sprintf("Das Passwort muss mindestens %d Zeichen lang sein", minPassphraseLength)},
+		})
+		return
+	}
+
+	dbId, err := sessionDbId(c)
+	if err != nil {
+		respond.InternalServerError(c, "Session id is no uuid", err)
+		return
+	}
+
+	code, err := crypt.EncodeRecoveryCode(dbId, crypt.GetSecret(c), passphrase)
+	if err != nil {
+		respond.InternalServerError(c, "Could not encode recovery code", err)
+		return
+	}
+
+	view := gin.H{"Errors": map[string]string{}, "code": code}
+	if generated {
+		view["passphrase"] = passphrase
+	}
+
+	c.HTML(http.StatusOK, "sessions/recovery", view)
+}
+
+func SessionResumeForm(c *gin.Context) {
+	c.HTML(http.StatusOK, "sessions/resume", gin.H{"Errors": map[string]string{}})
+}
+
+// SessionResume restores the session cookie from a recovery code, so that users can continue their work in another
+// browser.
+func SessionResume(dbDirectory *dbdir.DbDirectory) gin.HandlerFunc {
+	type request struct {
+		Code       string `form:"code"`
+		Passphrase string `form:"passphrase"`
+	}
+
+	return func(c *gin.Context) {
+		var req request
+		if err := c.ShouldBindWith(&req, binding.FormPost); err != nil {
+			respond.BadRequest(c, "Bad request on SessionResume", "err", err)
+			return
 		}
 
-		_, err = dbDirectory.Open(newDbId.String())
+		invalid := func(field, message string) {
+			c.HTML(http.StatusUnprocessableEntity, "sessions/resume", gin.H{"Errors": map[string]string{field: message}, "code": req.Code})
+		}
+
+		dbId, secret, err := crypt.DecodeRecoveryCode(req.Code, req.Passphrase)
+
+		switch {
+		case errors.Is(err, crypt.ErrInvalidRecoveryCode):
+			invalid("code", "Das ist kein gültiger Wiederherstellungscode")
+			return
+		case errors.Is(err, crypt.ErrWrongPassphrase):
+			requestlog.Logger(c).Info("Refused to resume session with wrong passphrase")
+			invalid("passphrase", "Das Passwort passt nicht zum Wiederherstellungscode")
+			return
+		case err != nil:
+			respond.InternalServerError(c, "Could not decode recovery code", err)
+			return
+		}
+
+		// Resuming must not create a db, since the data of an expired session is gone for good.
+		if _, err := dbDirectory.Expiration(dbId.String()); err != nil {
+			requestlog.Logger(c).Info("Refused to resume session without db", "err", err)
+			invalid("code", "Die Daten dieser Sitzung wurden bereits gelöscht")
+			return
+		}
 
+		conn, release, err := dbDirectory.Acquire(dbId.String())
 		if err != nil {
-			slog.Error("Failed to open new db", "err", err)
+			respond.InternalServerError(c, "Could not open db of resumed session", err)
+			return
+		}
+		err = domain.VerifySecret(conn, secret)
+		var protected bool
+		if err == nil {
+			protected, err = domain.HasPassphrase(conn)
+		}
+		release()
+
+		if errors.Is(err, crypt.ErrTampered) {
+			requestlog.Logger(c).Info("Refused to resume session with outdated recovery code")
+			invalid("code", "Dieser Wiederherstellungscode ist nicht mehr gültig, weil der Schlüssel der Sitzung erneuert wurde")
+			return
+		}
+		if err != nil {
+			respond.InternalServerError(c, "Could not verify secret of resumed session", err)
+			return
+		}
+
+		session := defaultSession(c)
+		session.Set(sessionIdKey, dbId.String())
+		if protected {
+			crypt.SetUnlockedSecret(c, secret)
+		} else {
+			crypt.SetSecret(c, secret)
+		}
+		if err := saveSessions(c); err != nil {
+			requestlog.Logger(c).Error("Failed while saving session", "err", err)
 			c.AbortWithStatus(http.StatusInternalServerError)
+			return
 		}
 
+		requestlog.Logger(c).Info("Resumed session from recovery code")
+
 		c.Redirect(http.StatusSeeOther, "/scenario")
 	}
 }
+
+// SessionPassphraseForm shows whether the secret of the session is protected by a passphrase and lets users set,
+// change or remove it, or lock the session.
+func SessionPassphraseForm(c *gin.Context) {
+	protected, err := domain.HasPassphrase(GetDB(c))
+	if err != nil {
+		DbError(c, err, "SessionPassphraseForm")
+		return
+	}
+
+	c.HTML(http.StatusOK, "sessions/passphrase", gin.H{"Errors": map[string]string{}, "protected": protected})
+}
+
+// SessionPassphrase protects the secret of the session with a passphrase, replacing an existing one. The secret
+// itself stays the same, so the data does not have to be encrypted again.
+func SessionPassphrase(c *gin.Context) {
+	type request struct {
+		Passphrase   string `form:"passphrase"`
+		Confirmation string `form:"confirmation"`
+	}
+
+	var req request
+	if err := c.ShouldBindWith(&req, binding.FormPost); err != nil {
+		respond.BadRequest(c, "Bad request on SessionPassphrase", "err", err)
+		return
+	}
+
+	db := GetDB(c)
+
+	invalid := func(message string) {
+		protected, err := domain.HasPassphrase(db)
+		if err != nil {
+			DbError(c, err, "SessionPassphrase")
+			return
+		}
+		c.HTML(http.StatusUnprocessableEntity, "sessions/passphrase", gin.H{"Errors": map[string]string{"passphrase": message}, "protected": protected})
+	}
+
+	if len([]rune(req.Passphrase)) < minPassphraseLength {
+		invalid(fmt.Sprintf("Das Passwort muss mindestens %d Zeichen lang sein", minPassphraseLength))
+		return
+	}
+	if req.Passphrase != req.Confirmation {
+		invalid("Die Passwörter stimmen nicht überein")
+		return
+	}
+
+	dbId, err := sessionDbId(c)
+	if err != nil {
+		respond.InternalServerError(c, "Session id is no uuid", err)
+		return
+	}
+
+	secret := crypt.GetSecret(c)
+	err = db.Transaction(func(tx *gorm.DB) error {
+		return domain.SetPassphrase(tx, dbId, secret, req.Passphrase)
+	})
+	if err != nil {
+		DbError(c, err, "SessionPassphrase")
+		return
+	}
+
+	// From now on, the secret is only kept until the browser is closed.
+	crypt.SetUnlockedSecret(c, secret)
+	if err := saveSessions(c); err != nil {
+		respond.InternalServerError(c, "Failed while saving session", err)
+		return
+	}
+
+	requestlog.Logger(c).Info("Protected session secret with passphrase")
+
+	c.Redirect(http.StatusSeeOther, "/sessions/passphrase")
+}
+
+// SessionPassphraseRemove drops the passphrase protection. The session can not be locked afterward.
+func SessionPassphraseRemove(c *gin.Context) {
+	if err := domain.RemovePassphrase(GetDB(c)); err != nil {
+		DbError(c, err, "SessionPassphraseRemove")
+		return
+	}
+
+	// Without passphrase, the session cookie is the only place left to keep the secret.
+	crypt.SetSecret(c, crypt.GetSecret(c))
+	if err := saveSessions(c); err != nil {
+		respond.InternalServerError(c, "Failed while saving session", err)
+		return
+	}
+
+	c.Redirect(http.StatusSeeOther, "/sessions/passphrase")
+}
+
+// SessionLock drops the secret from the session cookies. Afterward, the data can only be read again after unlocking
+// the session with its passphrase. Sessions without passphrase can not be locked, since their data would be lost.
+func SessionLock(c *gin.Context) {
+	protected, err := domain.HasPassphrase(GetDB(c))
+	if err != nil {
+		DbError(c, err, "SessionLock")
+		return
+	}
+
+	if !protected {
+		requestlog.Logger(c).Info("Refused to lock session without passphrase")
+		c.HTML(http.StatusConflict, "sessions/passphrase", gin.H{
+			"Errors":    map[string]string{"lock": "Legen Sie zuerst ein Passwort fest, sonst können Ihre Daten nach dem Sperren nicht mehr gelesen werden"},
+			"protected": false,
+		})
+		return
+	}
+
+	crypt.ClearSecret(c)
+	if err := saveSessions(c); err != nil {
+		requestlog.Logger(c).Error("Failed while saving session", "err", err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.Redirect(http.StatusSeeOther, "/sessions/unlock")
+}
+
+func SessionUnlockForm(c *gin.Context) {
+	c.HTML(http.StatusOK, "sessions/unlock", gin.H{"Errors": map[string]string{}})
+}
+
+// SessionUnlock unwraps the secret of a locked session with its passphrase and keeps it in the unlocked cookie until
+// the browser is closed.
+func SessionUnlock(c *gin.Context) {
+	type request struct {
+		Passphrase string `form:"passphrase"`
+	}
+
+	var req request
+	if err := c.ShouldBindWith(&req, binding.FormPost); err != nil {
+		respond.BadRequest(c, "Bad request on SessionUnlock", "err", err)
+		return
+	}
+
+	dbId, err := sessionDbId(c)
+	if err != nil {
+		respond.InternalServerError(c, "Session id is no uuid", err)
+		return
+	}
+
+	secret, err := domain.UnlockSecret(GetDB(c), dbId, req.Passphrase)
+
+	switch {
+	case errors.Is(err, crypt.ErrWrongPassphrase):
+		requestlog.Logger(c).Info("Refused to unlock session with wrong passphrase")
+		c.HTML(http.StatusUnprocessableEntity, "sessions/unlock", gin.H{"Errors": map[string]string{"passphrase": "Das Passwort ist falsch"}})
+		return
+	case errors.Is(err, domain.ErrNoPassphrase):
+		// Without a passphrase, the session was never locked or its secret is gone for good.
+		if _, ok := crypt.LookupSecret(c); ok {
+			c.Redirect(http.StatusSeeOther, "/scenario")
+			return
+		}
+		c.HTML(http.StatusUnprocessableEntity, "sessions/unlock", gin.H{"Errors": map[string]string{"passphrase": "Diese Sitzung ist nicht durch ein Passwort geschützt"}})
+		return
+	case err != nil:
+		respond.InternalServerError(c, "Could not unlock session", err)
+		return
+	}
+
+	crypt.SetUnlockedSecret(c, secret)
+	if err := saveSessions(c); err != nil {
+		requestlog.Logger(c).Error("Failed while saving session", "err", err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	requestlog.Logger(c).Info("Unlocked session with passphrase")
+
+	c.Redirect(http.StatusSeeOther, "/scenario")
+}
+
+func SessionRekeyForm(c *gin.Context) {
+	protected, err := domain.HasPassphrase(GetDB(c))
+	if err != nil {
+		DbError(c, err, "SessionRekeyForm")
+		return
+	}
+
+	c.HTML(http.StatusOK, "sessions/rekey", gin.H{"Errors": map[string]string{}, "protected": protected})
+}
+
+// SessionRekey replaces the secret of the session with a fresh one and re-encrypts all data with it. Recovery codes
+// and other browsers holding the old secret lose access. Passphrase-protected sessions require their passphrase, since
+// the secret is wrapped with it.
+func SessionRekey(c *gin.Context) {
+	type request struct {
+		Passphrase string `form:"passphrase"`
+	}
+
+	var req request
+	if err := c.ShouldBindWith(&req, binding.FormPost); err != nil {
+		respond.BadRequest(c, "Bad request on SessionRekey", "err", err)
+		return
+	}
+
+	dbId, err := sessionDbId(c)
+	if err != nil {
+		respond.InternalServerError(c, "Session id is no uuid", err)
+		return
+	}
+
+	protected, err := domain.HasPassphrase(GetDB(c))
+	if err != nil {
+		DbError(c, err, "SessionRekey")
+		return
+	}
+
+	storeSecret := func(secret crypt.Secret) error {
+		if protected {
+			crypt.SetUnlockedSecret(c, secret)
+		} else {
+			crypt.SetSecret(c, secret)
+		}
+
+		return saveSessions(c)
+	}
+
+	oldSecret := crypt.GetSecret(c)
+	stored := false
+	err = domain.RekeySession(GetDB(c), dbId, oldSecret, crypt.GenerateSecret(), req.Passphrase, func(secret crypt.Secret) error {
+		stored = true
+		return storeSecret(secret)
+	})
+
+	if err != nil && stored {
+		// The data is still encrypted with the old secret, so the cookie must hold it again. The last Set-Cookie wins.
+		if err := storeSecret(oldSecret); err != nil {
+			requestlog.Logger(c).Error("Could not restore old secret after failed rekey", "err", err)
+		}
+	}
+
+	if errors.Is(err, crypt.ErrWrongPassphrase) {
+		requestlog.Logger(c).Info("Refused to rekey session with wrong passphrase")
+		c.HTML(http.StatusUnprocessableEntity, "sessions/rekey", gin.H{"Errors": map[string]string{"passphrase": "Das Passwort ist falsch"}, "protected": true})
+		return
+	}
+
+	if err != nil {
+		respond.InternalServerError(c, "Could not rekey session", err)
+		return
+	}
+
+	requestlog.Logger(c).Info("Rekeyed session")
+
+	c.Redirect(http.StatusSeeOther, "/sessions/recovery")
+}
+
+// sessionDbId returns the id of the session's db, which is bound to everything encrypted with a passphrase.
+func sessionDbId(c *gin.Context) (uuid.UUID, error) {
+	sessionId, _ := getSessionId(c)
+
+	return uuid.Parse(sessionId)
+}
+
+// sessionsFull tells the user that no new session can be started right now.
+func sessionsFull(c *gin.Context) {
+	requestlog.Logger(c).Warn("Refused to create session, because the maximum number of sessions is reached")
+
+	c.Header("Retry-After", "3600")
+	c.HTML(http.StatusServiceUnavailable, "sessions/full", nil)
+	c.Abort()
+}
+
+// SessionExpiration renders the banner that counts down until the session's data is wiped.
+// Since every request extends the session, the extend button of the banner is served by this handler as well.
+func SessionExpiration(dbDirectory *dbdir.DbDirectory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sessionId, _ := getSessionId(c)
+		expiration, err := dbDirectory.Expiration(sessionId)
+
+		if err != nil {
+			requestlog.Logger(c).Error("Could not get session expiration", "err", err)
+			c.AbortWithStatus(http.StatusInternalServerError)
+
+			return
+		}
+
+		setExpirationHeaders(c, expiration)
+		c.HTML(http.StatusOK, "sessions/_expiration-banner", ui.SessionExpiration{Extendable: expiration.Extendable()})
+	}
+}
+
+// SessionDelete removes the session's db right away and clears the session cookies.
+func SessionDelete(dbDirectory *dbdir.DbDirectory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sessionId, _ := getSessionId(c)
+		removedAt, err := dbDirectory.Remove(sessionId)
+
+		if err != nil {
+			requestlog.Logger(c).Error("Could not remove db of session", "err", err)
+			c.AbortWithStatus(http.StatusInternalServerError)
+
+			return
+		}
+
+		options := SessionCookieOptions(0, secureCookies(c))
+		options.MaxAge = -1
+		for _, name := range []string{crypt.SessionCookieName, crypt.UnlockedCookieName} {
+			session := sessions.DefaultMany(c, name)
+			session.Clear()
+			session.Options(options)
+		}
+		if err := saveSessions(c); err != nil {
+			requestlog.Logger(c).Error("Could not clear session cookies", "err", err)
+		}
+
+		requestlog.Logger(c).Info("Removed db on user request")
+
+		c.HTML(http.StatusOK, "sessions/deleted", gin.H{"removedAt": removedAt.Format("02.01.2006 um 15:04:05 Uhr")})
+	}
+}
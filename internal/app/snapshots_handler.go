@@ -0,0 +1,218 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"softbaer.dev/ass/internal/app/requestlog"
+	"softbaer.dev/ass/internal/app/respond"
+	"softbaer.dev/ass/internal/crypt"
+	"softbaer.dev/ass/internal/domain"
+	"softbaer.dev/ass/internal/ui"
+)
+
+// maxSnapshotNameLength keeps names short enough for the snapshot list.
+const maxSnapshotNameLength = 100
+
+type snapshotUriParams struct {
+	ID domain.SnapshotID `uri:"id" binding:"required"`
+}
+
+func SnapshotsIndex(c *gin.Context) {
+	renderSnapshotsIndex(c, http.StatusOK, make(map[string]string), "")
+}
+
+func SnapshotsCreate(c *gin.Context) {
+	type request struct {
+		Name string `form:"name"`
+	}
+
+	var req request
+	if err := c.Bind(&req); err != nil {
+		requestlog.Logger(c).Error("Could not bind request when creating snapshot", "err", err)
+		return
+	}
+
+	validationErrors := make(map[string]string)
+	switch name := []rune(req.Name); {
+	case len(name) == 0:
+		validationErrors["name"] = "Name darf nicht leer sein"
+	case len(name) > maxSnapshotNameLength:
+		validationErrors["name"] = fmt.Sprintf("Name darf höchstens %d Zeichen lang sein", maxSnapshotNameLength)
+	}
+
+	if len(validationErrors) > 0 {
+		renderSnapshotsIndex(c, http.StatusUnprocessableEntity, validationErrors, req.Name)
+		return
+	}
+
+	err := GetDB(c).Transaction(func(tx *gorm.DB) error {
+		if _, err := domain.SaveSnapshot(tx, req.Name); err != nil {
+			return err
+		}
+
+		return GetQuota(c).Check(tx)
+	})
+
+	if QuotaError(c, err) {
+		return
+	}
+
+	if err != nil {
+		DbError(c, err, "SnapshotsCreate")
+		return
+	}
+
+	c.Redirect(http.StatusSeeOther, "/snapshots")
+}
+
+func SnapshotsRestore(c *gin.Context) {
+	var uriParams snapshotUriParams
+	if err := c.ShouldBindUri(&uriParams); err != nil {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+
+	err := GetDB(c).Transaction(func(tx *gorm.DB) error {
+		if err := domain.RestoreSnapshot(tx, uriParams.ID); err != nil {
+			return err
+		}
+
+		return GetQuota(c).Check(tx)
+	})
+
+	if QuotaError(c, err) {
+		return
+	}
+
+	if errors.Is(err, domain.ErrSnapshotNotFound) {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		respond.InternalServerError(c, "Restoring snapshot failed", err, "snapshotId", uriParams.ID)
+		return
+	}
+
+	c.Redirect(http.StatusSeeOther, "/scenario")
+}
+
+func SnapshotsDelete(c *gin.Context) {
+	var uriParams snapshotUriParams
+	if err := c.ShouldBindUri(&uriParams); err != nil {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+
+	err := domain.DeleteSnapshot(GetDB(c), uriParams.ID)
+
+	if errors.Is(err, domain.ErrSnapshotNotFound) {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		DbError(c, err, "SnapshotsDelete")
+		return
+	}
+
+	c.Data(http.StatusOK, "text/html", []byte(""))
+}
+
+func SnapshotsCompare(c *gin.Context) {
+	type request struct {
+		Left  domain.SnapshotID `form:"left" binding:"required"`
+		Right domain.SnapshotID `form:"right" binding:"required"`
+	}
+
+	var req request
+	if err := c.ShouldBindQuery(&req); err != nil {
+		respond.BadRequest(c, "Could not bind snapshots to compare", "err", err)
+		return
+	}
+
+	comparison, err := domain.CompareSnapshots(GetDB(c), crypt.GetSecret(c), req.Left, req.Right)
+
+	if errors.Is(err, domain.ErrSnapshotNotFound) {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		respond.InternalServerError(c, "Comparing snapshots failed", err, "left", req.Left, "right", req.Right)
+		return
+	}
+
+	c.HTML(http.StatusOK, "snapshots/compare", toViewSnapshotComparison(comparison))
+}
+
+func renderSnapshotsIndex(c *gin.Context, status int, validationErrors map[string]string, name string) {
+	snapshots, err := domain.Snapshots(GetDB(c))
+	if err != nil {
+		respond.InternalServerError(c, "Error while loading snapshots", err)
+		return
+	}
+
+	viewSnapshots := make([]ui.Snapshot, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		viewSnapshots = append(viewSnapshots, toViewSnapshot(snapshot))
+	}
+
+	c.HTML(status, "snapshots/index", gin.H{"snapshots": viewSnapshots, "Errors": validationErrors, "Name": name})
+}
+
+func toViewSnapshot(snapshot domain.Snapshot) ui.Snapshot {
+	return ui.Snapshot{
+		ID:               int(snapshot.ID),
+		Name:             snapshot.Name,
+		CreatedAt:        snapshot.CreatedAt.Local().Format("02.01.2006 15:04"),
+		Automatic:        snapshot.Automatic,
+		CourseCount:      snapshot.CourseCount,
+		ParticipantCount: snapshot.ParticipantCount,
+	}
+}
+
+func toViewSnapshotComparison(comparison domain.SnapshotComparison) ui.SnapshotComparison {
+	view := ui.SnapshotComparison{
+		Left:            toViewSnapshot(comparison.Left),
+		Right:           toViewSnapshot(comparison.Right),
+		UnassignedLeft:  comparison.UnassignedLeft,
+		UnassignedRight: comparison.UnassignedRight,
+	}
+
+	allocation := func(present bool, allocation, maxCapacity int) string {
+		if !present {
+			return ""
+		}
+		return fmt.Sprintf("%d / %d", allocation, maxCapacity)
+	}
+	for _, course := range comparison.Courses {
+		left := allocation(course.InLeft, course.AllocationLeft, course.MaxCapacityLeft)
+		right := allocation(course.InRight, course.AllocationRight, course.MaxCapacityRight)
+		view.Courses = append(view.Courses, ui.CourseComparison{Name: course.Name, Left: left, Right: right, Changed: left != right})
+	}
+
+	assignment := func(present bool, courseName string) string {
+		switch {
+		case !present:
+			return ""
+		case courseName == "":
+			return "Nicht zugeteilt"
+		default:
+			return courseName
+		}
+	}
+	for _, participant := range comparison.Participants {
+		view.Participants = append(view.Participants, ui.ParticipantComparison{
+			Name:  fmt.Sprintf("%s, %s", participant.Surname, participant.Prename),
+			Left:  assignment(participant.InLeft, participant.CourseLeft),
+			Right: assignment(participant.InRight, participant.CourseRight),
+		})
+	}
+
+	return view
+}
@@ -2,22 +2,29 @@ package app
 
 import (
 	"errors"
-	"log/slog"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"softbaer.dev/ass/internal/app/requestlog"
 	"softbaer.dev/ass/internal/app/respond"
+	"softbaer.dev/ass/internal/crypt"
+	"softbaer.dev/ass/internal/domain"
 	"softbaer.dev/ass/internal/domain/solve"
 )
 
 func SolveAssignments(c *gin.Context) {
-	logger := slog.With("Func", "SolveAssignments")
+	logger := requestlog.Logger(c).With("Func", "SolveAssignments")
 	db := GetDB(c)
 
+	var result solve.Result
 	err := db.Transaction(
 		func(tx *gorm.DB) error {
-			return solve.ComputeAndApplyOptimalAssignments(c.Request.Context(), tx)
+			return domain.Record(tx, domain.ActionSolve, domain.WholeScenario(), func(tx *gorm.DB) error {
+				var err error
+				result, err = solve.ComputeAndApplyOptimalAssignments(c.Request.Context(), tx)
+				return err
+			})
 		},
 	)
 
@@ -43,5 +50,12 @@ func SolveAssignments(c *gin.Context) {
 		return
 	}
 
+	// The solution is already persisted at this point. Failing to verify it is no reason to fail the whole request.
+	if scenario, err := domain.LoadScenario(db, crypt.GetSecret(c)); err == nil {
+		reportViolations(c, "solve", domain.VerifySolve(scenario, result.Claim()))
+	} else {
+		logger.Error("Could not load scenario to verify solution", "err", err)
+	}
+
 	c.Redirect(http.StatusSeeOther, "/scenario")
 }
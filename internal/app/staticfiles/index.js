@@ -24,12 +24,12 @@ function dragLeave(e) {
 function drop(e) {
     e.preventDefault();
 
-    const dropzone = e.currentTarget;
+    const dropzone = e.target.closest(".dropzone");
     dropzone.classList.remove("drop-ready");
 
     const participantElementId = e.dataTransfer.getData("css-id");
     const participantId = extractNumericId(participantElementId);
-    const courseElementId = e.target.id;
+    const courseElementId = dropzone.id;
 
     const isInitialAssign = document.querySelector(".selected").id === "not-assigned"
     const isUnassign = courseElementId === "not-assigned"
@@ -52,6 +52,34 @@ function drop(e) {
     }
 }
 
+// The content security policy forbids inline event handlers, so the handlers are attached to the document instead.
+document.addEventListener("dragstart", (e) => {
+    if (e.target.matches?.("[draggable]")) {
+        dragStart(e);
+    }
+});
+
+for (const [type, handler] of [["dragover", allowDrop], ["drop", drop], ["dragleave", dragLeave]]) {
+    document.addEventListener(type, (e) => {
+        if (e.target.closest?.(".dropzone")) {
+            handler(e);
+        }
+    });
+}
+
+document.addEventListener("click", (e) => {
+    if (e.target.closest("#abort-solve-button")) {
+        htmx.trigger("#solve-assignment-link", "htmx:abort");
+    }
+});
+
+// Close events do not bubble, so they are caught while capturing.
+document.addEventListener("close", (e) => {
+    if (e.target.matches("dialog[data-remove-on-close]")) {
+        e.target.remove();
+    }
+}, true);
+
 /**
  * @param {string} elementId
  */
@@ -152,3 +180,92 @@ class PrioInput extends HTMLElement {
 }
 
 customElements.define("prio-input", PrioInput)
+
+// Ctrl+Z undoes the latest change, Ctrl+Y or Ctrl+Shift+Z redoes it. Text inputs keep their own undo.
+document.addEventListener("keydown", (e) => {
+    if (!(e.ctrlKey || e.metaKey) || e.target.closest("input, textarea, select, [contenteditable]")) {
+        return;
+    }
+
+    const key = e.key.toLowerCase();
+    const isUndo = key === "z" && !e.shiftKey;
+    const isRedo = key === "y" || (key === "z" && e.shiftKey);
+
+    if ((!isUndo && !isRedo) || !document.getElementById("scenario")) {
+        return;
+    }
+
+    e.preventDefault();
+    htmx.ajax("POST", isUndo ? "/history/undo" : "/history/redo", {
+        "target": "#scenario",
+        "swap": "outerHTML",
+    });
+});
+
+// The session banner counts down until the data of the session is wiped. It stays hidden until the deadline is near.
+const sessionWarningSeconds = 10 * 60;
+let sessionDeadline = null;
+let sessionExtendable = true;
+
+/**
+ * @param {number} remainingSeconds
+ * @param {boolean} extendable
+ */
+function setSessionDeadline(remainingSeconds, extendable) {
+    sessionDeadline = Date.now() + remainingSeconds * 1000;
+    sessionExtendable = extendable;
+    renderSessionBanner();
+}
+
+function renderSessionBanner() {
+    const banner = document.getElementById("session-banner");
+    if (!banner || sessionDeadline === null) {
+        return;
+    }
+
+    const remainingSeconds = Math.max(0, Math.floor((sessionDeadline - Date.now()) / 1000));
+    banner.hidden = remainingSeconds > sessionWarningSeconds;
+
+    const minutes = Math.floor(remainingSeconds / 60);
+    const seconds = String(remainingSeconds % 60).padStart(2, "0");
+    banner.querySelector(".session-countdown").textContent = `${minutes}:${seconds} Minuten`;
+    banner.querySelector(".session-extend").hidden = !sessionExtendable;
+    banner.querySelector(".session-hard-cap").hidden = sessionExtendable;
+}
+
+// Every request extends the session, except for loading the banner, and every response carries the deadline.
+document.addEventListener("htmx:afterRequest", (e) => {
+    const remaining = e.detail.xhr.getResponseHeader("X-Session-Remaining");
+    if (remaining !== null) {
+        setSessionDeadline(Number(remaining), e.detail.xhr.getResponseHeader("X-Session-Extendable") === "true");
+    }
+});
+
+setInterval(renderSessionBanner, 1000);
+
+// State-changing requests must carry the CSRF token of the session, which the server mirrors into a readable cookie.
+function csrfToken() {
+    const cookie = document.cookie.split("; ").find((c) => c.startsWith("csrf_token="));
+    return cookie ? cookie.substring("csrf_token=".length) : "";
+}
+
+document.addEventListener("htmx:configRequest", (e) => {
+    e.detail.headers["X-CSRF-Token"] = csrfToken();
+});
+
+// Plain forms send the token as a form field. Forms submitted by htmx are covered by the header as well.
+document.addEventListener("submit", (e) => {
+    const form = e.target;
+    if (form.method !== "post") {
+        return;
+    }
+
+    let input = form.querySelector('input[name="csrf_token"]');
+    if (!input) {
+        input = document.createElement("input");
+        input.type = "hidden";
+        input.name = "csrf_token";
+        form.appendChild(input);
+    }
+    input.value = csrfToken();
+}, true);
@@ -1,10 +1,13 @@
 package app
 
 import (
-	"log/slog"
+	"errors"
+	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"softbaer.dev/ass/internal/app/requestlog"
+	"softbaer.dev/ass/internal/domain"
 )
 
 // DbError logs the error and renders a generic error dialog.
@@ -21,7 +24,7 @@ func DbError(c *gin.Context, err error, function string, args ...string) {
 		}
 	}
 
-	slog.Error("DB-Operation Failed", logArgs...)
+	requestlog.Logger(c).Error("DB-Operation Failed", logArgs...)
 
 	// In case of an error, we do want to show an error dialog, but keep the UI untouched otherwise.
 	// Therefore, we set these headers to prepend the dialog to the body rather than replacing something.
@@ -29,3 +32,43 @@ func DbError(c *gin.Context, err error, function string, args ...string) {
 	c.Header("HX-Retarget", "body")
 	c.HTML(http.StatusInternalServerError, "dialogs/db-error", err)
 }
+
+// QuotaError renders a dialog that explains which quota was exceeded, if err is a domain.QuotaExceededError.
+// It reports whether it did, so that other errors can be handled by the caller.
+func QuotaError(c *gin.Context, err error) bool {
+	var quotaErr domain.QuotaExceededError
+	if !errors.As(err, &quotaErr) {
+		return false
+	}
+
+	requestlog.Logger(c).Info("Session exceeded its quota", "err", err)
+
+	c.Header("HX-Reswap", "afterbegin")
+	c.Header("HX-Retarget", "body")
+	c.HTML(http.StatusUnprocessableEntity, "dialogs/quota-exceeded", quotaErr.Error())
+
+	return true
+}
+
+// limitExceeded tells the user that a request was refused because of a limit, e.g. the upload size or the rate
+// limit. Requests by htmx get a dialog, other requests a page.
+func limitExceeded(c *gin.Context, status int, message string) {
+	if c.GetHeader("HX-Request") != "true" {
+		c.HTML(status, "general/limit-exceeded", message)
+
+		return
+	}
+
+	c.Header("HX-Reswap", "afterbegin")
+	c.Header("HX-Retarget", "body")
+	c.HTML(status, "dialogs/limit-exceeded", message)
+}
+
+func uploadTooLargeMessage(maxBytes int64) string {
+	return fmt.Sprintf("Eine Datei darf höchstens %.1f MB groß sein.", float64(maxBytes)/1e6)
+}
+
+// triggerScenarioChanged tells htmx that the scenario was modified, so that panels derived from it can refresh.
+func triggerScenarioChanged(c *gin.Context) {
+	c.Header("HX-Trigger", "scenario-changed")
+}
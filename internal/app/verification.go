@@ -0,0 +1,62 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"softbaer.dev/ass/internal/app/requestlog"
+	"softbaer.dev/ass/internal/domain"
+)
+
+const verificationFlashKey = "verification"
+
+// maxFlashedViolations limits how many violations are stored in the session, since the session lives in a cookie.
+const maxFlashedViolations = 5
+
+// reportViolations logs the violations found after an action (e.g. "solve" or "load") and stores them in the session.
+// The next render of the scenario page shows them as warnings.
+func reportViolations(c *gin.Context, action string, violations []domain.Violation) {
+	if len(violations) == 0 {
+		return
+	}
+
+	for _, violation := range violations {
+		requestlog.Logger(c).Warn("Scenario violates rule", append([]any{"action", action}, violation.LogArgs()...)...)
+	}
+
+	session := defaultSession(c)
+	for i, violation := range violations {
+		if i == maxFlashedViolations {
+			session.AddFlash(fmt.Sprintf("... und %d weitere Regelverstöße", len(violations)-maxFlashedViolations), verificationFlashKey)
+			break
+		}
+		session.AddFlash(violation.Message, verificationFlashKey)
+	}
+
+	if err := session.Save(); err != nil {
+		requestlog.Logger(c).Error("Could not save violations in session", "err", err)
+	}
+}
+
+// popViolationWarnings returns the warnings stored by reportViolations and removes them from the session.
+func popViolationWarnings(c *gin.Context) []string {
+	session := defaultSession(c)
+	flashes := session.Flashes(verificationFlashKey)
+
+	if len(flashes) == 0 {
+		return nil
+	}
+
+	if err := session.Save(); err != nil {
+		requestlog.Logger(c).Error("Could not remove violations from session", "err", err)
+	}
+
+	warnings := make([]string, 0, len(flashes))
+	for _, flash := range flashes {
+		if warning, ok := flash.(string); ok {
+			warnings = append(warnings, warning)
+		}
+	}
+
+	return warnings
+}
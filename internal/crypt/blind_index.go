@@ -0,0 +1,25 @@
+package crypt
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+)
+
+// blindIndexLabel separates the key of blind indexes from the secret itself, which also encrypts.
+const blindIndexLabel = "blind-index"
+
+// BlindIndex returns a keyed hash of the plaintext. Equal plaintexts with equal associated data yield equal indexes,
+// so that encrypted columns can be looked up and kept unique without decrypting them. Without the secret, the index
+// reveals nothing but equality.
+func BlindIndex(plaintext string, secret Secret, associatedData []byte) string {
+	keyMac := hmac.New(sha256.New, secret)
+	keyMac.Write([]byte(blindIndexLabel))
+
+	mac := hmac.New(sha256.New, keyMac.Sum(nil))
+	mac.Write(associatedData)
+	mac.Write([]byte{0})
+	mac.Write([]byte(plaintext))
+
+	return base64.RawStdEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -5,11 +5,22 @@ import (
 	"crypto/cipher"
 	"crypto/rand"
 	"encoding/base64"
+	"errors"
+	"fmt"
 	"io"
 	"log/slog"
 )
 
-func Encrypt(plaintext string, secret Secret) (string, error) {
+var (
+	ErrMalformedCiphertext = errors.New("ciphertext is malformed")
+	// ErrTampered means that the ciphertext was modified, encrypted with another secret or encrypted for other
+	// associated data, e.g. for another row.
+	ErrTampered = errors.New("ciphertext was tampered with or does not belong here")
+)
+
+// Encrypt encrypts the plaintext and binds it to the associated data, which is not part of the result. Decrypt only
+// succeeds with the same associated data, so that ciphertexts can not be moved unnoticed.
+func Encrypt(plaintext string, secret Secret, associatedData []byte) (string, error) {
 	logger := slog.With("Func", "Encrypt")
 	block, err := aes.NewCipher(secret)
 	if err != nil {
@@ -29,37 +40,42 @@ func Encrypt(plaintext string, secret Secret) (string, error) {
 		return "", err
 	}
 
-	cipherbytes := aesgcm.Seal(nil, nonce, []byte(plaintext), nil)
+	cipherbytes := aesgcm.Seal(nil, nonce, []byte(plaintext), associatedData)
 
 	return base64.StdEncoding.EncodeToString(append(nonce, cipherbytes...)), nil
 }
 
-func Decrypt(ciphertext string, secret Secret) (string, error) {
-	logger := slog.With("Func", "Decrypt")
+// Decrypt reverses Encrypt. An empty ciphertext decrypts to an empty string, because Encrypt never returns one. It
+// is found in dbs whose plaintext columns were dropped by a migration.
+// The errors are left to the caller to log, since only the caller knows where the ciphertext came from.
+func Decrypt(ciphertext string, secret Secret, associatedData []byte) (string, error) {
+	if ciphertext == "" {
+		return "", nil
+	}
 	cipherbytes, err := base64.StdEncoding.DecodeString(ciphertext)
 	if err != nil {
-		logger.Error("Error decoding from base64", "err", err)
-		return "", err
+		return "", fmt.Errorf("%w: %w", ErrMalformedCiphertext, err)
 	}
 
 	block, err := aes.NewCipher(secret)
 	if err != nil {
-		logger.Error("Error creating cipher", "err", err)
 		return "", err
 	}
 
 	aesgcm, err := cipher.NewGCM(block)
 	if err != nil {
-		logger.Error("Error creating gcm block", "err", err)
 		return "", err
 	}
 
+	if len(cipherbytes) < aesgcm.NonceSize() {
+		return "", fmt.Errorf("%w: shorter than nonce", ErrMalformedCiphertext)
+	}
+
 	nonce, cipherbytes := cipherbytes[:aesgcm.NonceSize()], cipherbytes[aesgcm.NonceSize():]
 
-	plaintext, err := aesgcm.Open(nil, nonce, cipherbytes, nil)
+	plaintext, err := aesgcm.Open(nil, nonce, cipherbytes, associatedData)
 	if err != nil {
-		logger.Error("Error in final decrypt", "err", err)
-		return "", err
+		return "", ErrTampered
 	}
 
 	return string(plaintext), nil
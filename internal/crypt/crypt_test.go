@@ -1,6 +1,7 @@
 package crypt
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/matryer/is"
@@ -18,22 +19,56 @@ func TestEncryptDecryptRoundtripIsConsistent(t *testing.T) {
 	}
 
 	for _, plaintext := range testcases {
-		ciphertext, err := Encrypt(plaintext, secret)
+		ciphertext, err := Encrypt(plaintext, secret, nil)
 		is.NoErr(err) // want encryption to be successful
-		plaintext2, err := Decrypt(ciphertext, secret)
+		plaintext2, err := Decrypt(ciphertext, secret, nil)
 		is.NoErr(err) // want decryption to be successful
 
 		is.Equal(plaintext, plaintext2) // want plaintext to stay the same through a en- decrypt roundtrip
 	}
 }
 
+func TestDecryptRejectsTruncatedCiphertext(t *testing.T) {
+	is := is.New(t)
+
+	_, err := Decrypt("AAAA", GenerateSecret(), nil)
+
+	is.True(err != nil) // want an error instead of a panic
+}
+
+func TestDecryptRejectsOtherAssociatedData(t *testing.T) {
+	is := is.New(t)
+
+	secret := GenerateSecret()
+	ciphertext, err := Encrypt("hallo", secret, []byte("participant/1/prename"))
+	is.NoErr(err)
+
+	_, err = Decrypt(ciphertext, secret, []byte("participant/2/prename"))
+	is.True(errors.Is(err, ErrTampered)) // want ciphertext moved to another row to be detected
+
+	plaintext, err := Decrypt(ciphertext, secret, []byte("participant/1/prename"))
+	is.NoErr(err)
+	is.Equal(plaintext, "hallo")
+}
+
 func BenchmarkCryptRoundtrip(t *testing.B) {
 	secret := GenerateSecret()
 	plaintext := "einmittellangerstring"
 
 	for t.Loop() {
-		ciphertext, _ := Encrypt(plaintext, secret)
-		Decrypt(ciphertext, secret)
+		ciphertext, _ := Encrypt(plaintext, secret, nil)
+		Decrypt(ciphertext, secret, nil)
 	}
 
 }
+
+func TestPseudonymIsStableButDependsOnSecret(t *testing.T) {
+	is := is.New(t)
+
+	secret := GenerateSecret()
+	sessionId := "0b5f8c36-5a4e-4a8e-9a3e-2f0c1d7b6e21"
+
+	is.Equal(Pseudonym(sessionId, secret), Pseudonym(sessionId, secret)) // want log lines of a session to be correlatable
+	is.True(Pseudonym(sessionId, secret) != Pseudonym("other", secret))
+	is.True(Pseudonym(sessionId, secret) != Pseudonym(sessionId, GenerateSecret())) // want pseudonyms to depend on the secret
+}
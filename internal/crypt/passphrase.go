@@ -0,0 +1,114 @@
+package crypt
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/sync/semaphore"
+)
+
+// The argon2id parameters follow the second recommendation of RFC 9106 for memory-constrained environments.
+const (
+	kdfTime    = 3
+	kdfMemory  = 64 * 1024
+	kdfThreads = 4
+	saltSize   = 16
+)
+
+// maxConcurrentKdfs bounds the memory spent on key derivations, since every one of them allocates kdfMemory KiB.
+const maxConcurrentKdfs = 2
+
+var kdfSlots = semaphore.NewWeighted(maxConcurrentKdfs)
+
+var ErrWrongPassphrase = errors.New("wrong passphrase or tampered data")
+
+// deriveKey derives an AES key from the passphrase. It is deliberately slow and memory-hard, so that passphrases can
+// not be guessed quickly.
+func deriveKey(passphrase string, salt []byte) Secret {
+	// Acquire only fails for a cancelled context.
+	_ = kdfSlots.Acquire(context.Background(), 1)
+	defer kdfSlots.Release(1)
+
+	return argon2.IDKey([]byte(passphrase), salt, kdfTime, kdfMemory, kdfThreads, 32)
+}
+
+// WrapSecret encrypts the secret with a key derived from the passphrase. The associated data is not encrypted, but
+// unwrapping fails if it does not match.
+func WrapSecret(secret Secret, passphrase string, associatedData []byte) ([]byte, error) {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	aesgcm, err := newGCM(deriveKey(passphrase, salt))
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aesgcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	wrapped := append(salt, nonce...)
+	return aesgcm.Seal(wrapped, nonce, secret, associatedData), nil
+}
+
+// UnwrapSecret reverses WrapSecret. It returns ErrWrongPassphrase if the passphrase or the associated data do not
+// match.
+func UnwrapSecret(wrapped []byte, passphrase string, associatedData []byte) (Secret, error) {
+	if len(wrapped) < saltSize {
+		return nil, ErrWrongPassphrase
+	}
+
+	salt, rest := wrapped[:saltSize], wrapped[saltSize:]
+	aesgcm, err := newGCM(deriveKey(passphrase, salt))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rest) < aesgcm.NonceSize() {
+		return nil, ErrWrongPassphrase
+	}
+
+	nonce, ciphertext := rest[:aesgcm.NonceSize()], rest[aesgcm.NonceSize():]
+	secret, err := aesgcm.Open(nil, nonce, ciphertext, associatedData)
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+
+	return secret, nil
+}
+
+// GeneratePassphrase returns a random passphrase of 20 characters in groups of five, which is easy to write down.
+func GeneratePassphrase() string {
+	random := make([]byte, 15)
+	if _, err := rand.Read(random); err != nil {
+		panic(err)
+	}
+
+	// 20 characters of base32 carry 100 random bits.
+	encoded := base32.StdEncoding.EncodeToString(random)[:20]
+
+	groups := make([]string, 0, len(encoded)/5)
+	for i := 0; i < len(encoded); i += 5 {
+		groups = append(groups, encoded[i:i+5])
+	}
+
+	return strings.Join(groups, "-")
+}
+
+func newGCM(key Secret) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,25 @@
+package crypt
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+)
+
+// pseudonymLabel separates the key of pseudonyms from the secret itself.
+const pseudonymLabel = "pseudonym"
+
+// pseudonymBytes is short enough to keep log lines readable and long enough to not collide between sessions.
+const pseudonymBytes = 12
+
+// Pseudonym returns a keyed hash of an identifier, e.g. a session id, so that log lines of the same session can be
+// correlated without revealing the session id. Without the secret, a pseudonym can not be traced back to its session.
+func Pseudonym(identifier string, secret Secret) string {
+	keyMac := hmac.New(sha256.New, secret)
+	keyMac.Write([]byte(pseudonymLabel))
+
+	mac := hmac.New(sha256.New, keyMac.Sum(nil))
+	mac.Write([]byte(identifier))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:pseudonymBytes])
+}
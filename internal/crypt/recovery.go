@@ -0,0 +1,50 @@
+package crypt
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// recoveryCodeVersion is the first byte of every recovery code, so that the format can change later.
+const recoveryCodeVersion = 1
+
+var ErrInvalidRecoveryCode = errors.New("invalid recovery code")
+
+// EncodeRecoveryCode returns a code that lets users resume their session in another browser. It contains the id of
+// the session's db and its secret, wrapped with the passphrase. The db id is bound to the wrapped secret, so that
+// it can not be exchanged.
+func EncodeRecoveryCode(dbId uuid.UUID, secret Secret, passphrase string) (string, error) {
+	wrapped, err := WrapSecret(secret, passphrase, dbId[:])
+	if err != nil {
+		return "", err
+	}
+
+	code := append([]byte{recoveryCodeVersion}, dbId[:]...)
+	code = append(code, wrapped...)
+
+	return base64.RawURLEncoding.EncodeToString(code), nil
+}
+
+// DecodeRecoveryCode reverses EncodeRecoveryCode. It returns ErrInvalidRecoveryCode if the code is malformed and
+// ErrWrongPassphrase if the passphrase does not match.
+func DecodeRecoveryCode(code string, passphrase string) (uuid.UUID, Secret, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(strings.Join(strings.Fields(code), ""))
+	if err != nil || len(decoded) < 1+len(uuid.UUID{}) || decoded[0] != recoveryCodeVersion {
+		return uuid.UUID{}, nil, ErrInvalidRecoveryCode
+	}
+
+	dbId, err := uuid.FromBytes(decoded[1 : 1+len(uuid.UUID{})])
+	if err != nil {
+		return uuid.UUID{}, nil, ErrInvalidRecoveryCode
+	}
+
+	secret, err := UnwrapSecret(decoded[1+len(uuid.UUID{}):], passphrase, dbId[:])
+	if err != nil {
+		return uuid.UUID{}, nil, err
+	}
+
+	return dbId, secret, nil
+}
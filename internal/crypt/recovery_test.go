@@ -0,0 +1,44 @@
+package crypt
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/matryer/is"
+)
+
+func TestRecoveryCodeRoundtrip(t *testing.T) {
+	is := is.New(t)
+
+	dbId := uuid.New()
+	secret := GenerateSecret()
+	passphrase := GeneratePassphrase()
+
+	code, err := EncodeRecoveryCode(dbId, secret, passphrase)
+	is.NoErr(err)
+
+	decodedDbId, decodedSecret, err := DecodeRecoveryCode(code, passphrase)
+	is.NoErr(err)
+	is.Equal(decodedDbId, dbId)     // want the db id back
+	is.Equal(decodedSecret, secret) // want the secret back
+
+	_, _, err = DecodeRecoveryCode(code, "falsch")
+	is.True(errors.Is(err, ErrWrongPassphrase)) // want a wrong passphrase to be detected
+}
+
+func TestRecoveryCodeBindsDbId(t *testing.T) {
+	is := is.New(t)
+
+	code, err := EncodeRecoveryCode(uuid.New(), GenerateSecret(), "passphrase")
+	is.NoErr(err)
+
+	tampered := []byte(code)
+	tampered[3] ^= 1 // lies within the db id
+
+	_, _, err = DecodeRecoveryCode(string(tampered), "passphrase")
+	is.True(err != nil) // want an exchanged db id to be rejected
+
+	_, _, err = DecodeRecoveryCode("kein code", "passphrase")
+	is.True(errors.Is(err, ErrInvalidRecoveryCode))
+}
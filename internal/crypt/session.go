@@ -8,24 +8,66 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// SessionCookieName names the cookie that lives as long as the session's db. UnlockedCookieName names the cookie that
+// holds the secret of a passphrase-protected session while it is unlocked. It expires with the browser session, so that
+// closing the browser locks the session again. Both are registered via sessions.SessionsMany.
+const (
+	SessionCookieName  = "session"
+	UnlockedCookieName = "unlocked"
+)
+
 const userSecretKey = "secret"
 
 func GetSecret(ctx *gin.Context) Secret {
-	session := sessions.Default(ctx)
-	secretInBase64 := session.Get(userSecretKey).(string)
-	secret, err := base64.StdEncoding.DecodeString(secretInBase64)
+	secret, ok := LookupSecret(ctx)
 
-	if err != nil {
-		slog.Error("Error decoding secret", "err", err)
-		panic(err)
+	if !ok {
+		slog.Error("Session holds no secret")
+		panic("session holds no secret")
 	}
 
 	return secret
 }
 
 func SetNewSecret(ctx *gin.Context) {
-	session := sessions.Default(ctx)
-	secret := GenerateSecret()
-	secretInBase64 := base64.StdEncoding.EncodeToString(secret)
-	session.Set(userSecretKey, secretInBase64)
+	SetSecret(ctx, GenerateSecret())
+}
+
+// SetSecret stores the secret in the session cookie, e.g. after it was recovered from a recovery code. Secrets of
+// passphrase-protected sessions belong into the unlocked cookie, see SetUnlockedSecret.
+func SetSecret(ctx *gin.Context, secret Secret) {
+	sessions.DefaultMany(ctx, UnlockedCookieName).Delete(userSecretKey)
+	sessions.DefaultMany(ctx, SessionCookieName).Set(userSecretKey, base64.StdEncoding.EncodeToString(secret))
+}
+
+// SetUnlockedSecret stores the secret of a passphrase-protected session in the unlocked cookie only.
+func SetUnlockedSecret(ctx *gin.Context, secret Secret) {
+	sessions.DefaultMany(ctx, SessionCookieName).Delete(userSecretKey)
+	sessions.DefaultMany(ctx, UnlockedCookieName).Set(userSecretKey, base64.StdEncoding.EncodeToString(secret))
+}
+
+// LookupSecret returns the secret of the session, if it holds one. A locked session does not.
+func LookupSecret(ctx *gin.Context) (Secret, bool) {
+	for _, name := range []string{UnlockedCookieName, SessionCookieName} {
+		secretInBase64, ok := sessions.DefaultMany(ctx, name).Get(userSecretKey).(string)
+		if !ok {
+			continue
+		}
+
+		secret, err := base64.StdEncoding.DecodeString(secretInBase64)
+		if err != nil {
+			slog.Error("Error decoding secret", "err", err)
+			panic(err)
+		}
+
+		return secret, true
+	}
+
+	return nil, false
+}
+
+// ClearSecret drops the secret from both cookies, so that the session has to be unlocked again.
+func ClearSecret(ctx *gin.Context) {
+	sessions.DefaultMany(ctx, SessionCookieName).Delete(userSecretKey)
+	sessions.DefaultMany(ctx, UnlockedCookieName).Delete(userSecretKey)
 }
@@ -0,0 +1,207 @@
+package dbdir
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// The functions in this file give operators access to the dbs of a root dir while a DbDirectory in another process
+// manages them. They do not share its mutex, so they rely on the same assumptions instead: every access goes through
+// sqlite's file locks with a busy timeout, dbs are only removed while no one writes to them, and a DbDirectory treats
+// dbs that disappeared from disk as removed.
+
+// SessionInfo describes a db in a root dir. It contains no user data except for row counts.
+type SessionInfo struct {
+	DbId      string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	// Size is the size of the db including its side files in bytes.
+	Size int64
+	// RowCounts maps the name of every table to its number of rows.
+	RowCounts map[string]int64
+	// Err is set if the db could not be read. Then only DbId and Size are valid.
+	Err error
+}
+
+// Expired reports whether the db should already have been removed at now.
+func (s SessionInfo) Expired(now time.Time) bool {
+	return s.Err == nil && !now.Before(s.ExpiresAt)
+}
+
+var ErrUnknownSession = errors.New("unknown session")
+
+// ListSessions inspects all dbs in rootDir. Dbs that can not be read are listed with Err set.
+func ListSessions(rootDir string) ([]SessionInfo, error) {
+	dbIds, err := dbIdsIn(rootDir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]SessionInfo, 0, len(dbIds))
+	for _, dbId := range dbIds {
+		info, err := InspectSession(rootDir, dbId)
+
+		if errors.Is(err, ErrUnknownSession) {
+			// Removed since reading the directory.
+			continue
+		}
+
+		info.Err = err
+		infos = append(infos, info)
+	}
+
+	return infos, nil
+}
+
+// InspectSession reads expiration, size and row counts of the db with the given id.
+func InspectSession(rootDir string, dbId string) (SessionInfo, error) {
+	dbPath := dbPathIn(rootDir, dbId)
+	info := SessionInfo{DbId: dbId, Size: filesSize(dbPath)}
+
+	db, err := openExisting(dbPath)
+
+	if err != nil {
+		return info, err
+	}
+
+	defer closeConn(db)
+
+	var session Session
+	if err := db.First(&session).Error; err != nil {
+		return info, err
+	}
+
+	info.CreatedAt = session.CreatedAt
+	info.ExpiresAt = session.ExpiresAt
+
+	var tables []string
+	err = db.Raw("SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%'").Scan(&tables).Error
+
+	if err != nil {
+		return info, err
+	}
+
+	info.RowCounts = make(map[string]int64, len(tables))
+	for _, table := range tables {
+		var count int64
+		if err := db.Table(table).Count(&count).Error; err != nil {
+			return info, err
+		}
+
+		info.RowCounts[table] = count
+	}
+
+	return info, nil
+}
+
+// PurgeSession removes the db with the given id. It waits for running write transactions on the db to finish, but not
+// for readers, since the dbs use WAL mode. A DbDirectory that has the db open notices the removal on the next access
+// and closes its connection. Requests that use the connection at that moment still work on the removed files.
+func PurgeSession(rootDir string, dbId string) error {
+	dbPath := dbPathIn(rootDir, dbId)
+
+	db, err := openExisting(dbPath)
+
+	if err != nil {
+		return err
+	}
+
+	defer closeConn(db)
+
+	// openExisting limits the pool to one connection, so the transaction and the removal happen on the same one.
+	if err := db.Exec("BEGIN EXCLUSIVE").Error; err != nil {
+		return err
+	}
+
+	defer db.Exec("ROLLBACK")
+
+	return removeDbFiles(dbPath)
+}
+
+// VacuumSession rebuilds the db with the given id to free unused space.
+func VacuumSession(rootDir string, dbId string) error {
+	db, err := openExisting(dbPathIn(rootDir, dbId))
+
+	if err != nil {
+		return err
+	}
+
+	defer closeConn(db)
+
+	if err := db.Exec("VACUUM").Error; err != nil {
+		return err
+	}
+
+	return db.Exec("PRAGMA wal_checkpoint(TRUNCATE)").Error
+}
+
+// DiskUsage sums up the size of all files in rootDir, including quarantined dbs, in bytes.
+func DiskUsage(rootDir string) (int64, error) {
+	var total int64
+
+	err := filepath.WalkDir(rootDir, func(_ string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := entry.Info()
+
+		if err != nil {
+			return err
+		}
+
+		total += info.Size()
+
+		return nil
+	})
+
+	return total, err
+}
+
+// openExisting opens the db at dbPath without creating or migrating it.
+func openExisting(dbPath string) (*gorm.DB, error) {
+	if !fileExists(dbPath) {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownSession, dbPath)
+	}
+
+	db, err := gorm.Open(sqlite.Open(dbPath), &gorm.Config{})
+
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := db.DB()
+
+	if err != nil {
+		return nil, err
+	}
+
+	conn.SetMaxOpenConns(1)
+	db.Exec("PRAGMA busy_timeout = 5000;")
+
+	return db, nil
+}
+
+func filesSize(dbPath string) int64 {
+	var size int64
+
+	for _, suffix := range append([]string{""}, sideFileSuffixes...) {
+		if info, err := os.Stat(dbPath + suffix); err == nil {
+			size += info.Size()
+		}
+	}
+
+	return size
+}
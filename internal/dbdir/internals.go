@@ -1,15 +1,12 @@
 package dbdir
 
 import (
-	"fmt"
 	"iter"
 	"log/slog"
-	"os"
-	"path"
-	"strings"
 	"time"
 
-	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"softbaer.dev/ass/internal/crypt"
 )
 
 func (d *DbDirectory) getEntry(dbId string) (*entry, bool) {
@@ -37,7 +34,7 @@ func (d *DbDirectory) iterEntries() iter.Seq2[string, *entry] {
 			if !ok {
 				return false
 			}
-			valueTyped, ok := key.(*entry)
+			valueTyped, ok := value.(*entry)
 			if !ok {
 				return false
 			}
@@ -48,36 +45,30 @@ func (d *DbDirectory) iterEntries() iter.Seq2[string, *entry] {
 }
 
 func (d *DbDirectory) remove(dbId string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.removeLocked(dbId)
+}
+
+func (d *DbDirectory) removeLocked(dbId string) error {
 	entry, ok := d.getAndDeleteEntry(dbId)
 
 	if !ok {
-		slog.Warn("Tried to remove db, but was not in map", "dbId", dbId)
+		slog.Warn("Tried to remove db, but was not in map", "session", d.pseudonym(dbId))
 
 		return nil
 	}
 
-	conn, err := entry.conn.DB()
-
-	if err == nil {
-		err = conn.Close()
-	}
-
-	if err != nil {
-		slog.Warn("Tried to close connection do db, but got an error", "err", err)
-	}
+	d.closeConnLocked(entry)
 
-	dbPath := d.path(dbId)
-	err = os.Remove(dbPath)
-
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return d.storage.Remove(dbId)
 }
 
-func (d *DbDirectory) scheduleRemove(dbId string) {
-	expirationDate, err := d.getExpirationDate(dbId)
+// scheduleRemove starts the timer that removes the db at its expiration date. The caller has to hold a reference to
+// conn, so that the connection is not closed while the expiration date is read.
+func (d *DbDirectory) scheduleRemove(dbId string, conn *gorm.DB) {
+	expirationDate, err := d.getExpirationDate(conn)
 
 	if err != nil {
 		slog.Error("Could not get expiration date. This db will not be scheduled for removal", "err", err)
@@ -108,49 +99,146 @@ func (d *DbDirectory) scheduleRemove(dbId string) {
 		}
 	})
 
-	entry, _ := d.getEntry(dbId)
+	entry, ok := d.getEntry(dbId)
+	if !ok {
+		// The db was removed in the meantime.
+		expirationTimer.Stop()
+		return
+	}
 	entry.expirationTimer = expirationTimer
 }
 
-func (d *DbDirectory) restoreExistingDbs() error {
-	fsEntries, err := os.ReadDir(d.rootDir)
+func (d *DbDirectory) dropPurgedLocked(dbId string, entry *entry) {
+	slog.Info("Db was removed from outside the dbDirectory", "session", d.pseudonym(dbId))
+
+	if entry.expirationTimer != nil {
+		entry.expirationTimer.Stop()
+	}
+	d.closeConnLocked(entry)
+	d.entries.Delete(dbId)
+}
+
+// makeRoomLocked makes sure that another db can be created without exceeding maxSessions.
+func (d *DbDirectory) makeRoomLocked() error {
+	if d.maxSessions <= 0 {
+		return nil
+	}
+
+	for {
+		count := 0
+		var oldestId string
+		var oldest *entry
+		for dbId, entry := range d.iterEntries() {
+			count++
+			if entry.refs == 0 && (oldest == nil || entry.createdAt.Before(oldest.createdAt)) {
+				oldestId, oldest = dbId, entry
+			}
+		}
+
+		if count < d.maxSessions {
+			return nil
+		}
+
+		if !d.evictOldest || oldest == nil {
+			return ErrTooManySessions
+		}
+
+		slog.Info("Evicting oldest db to make room for a new one", "session", d.pseudonym(oldestId), "createdAt", oldest.createdAt)
+
+		if oldest.expirationTimer != nil {
+			oldest.expirationTimer.Stop()
+		}
+
+		if err := d.removeLocked(oldestId); err != nil {
+			return err
+		}
+	}
+}
+
+// touchLocked marks the open connection of the entry as the most recently used one.
+func (d *DbDirectory) touchLocked(dbId string, entry *entry) {
+	if entry.lruElement == nil {
+		entry.lruElement = d.lru.PushFront(dbId)
+		return
+	}
+
+	d.lru.MoveToFront(entry.lruElement)
+}
+
+// closeConnLocked closes the connection of the entry, if it is open. The db itself stays untouched.
+func (d *DbDirectory) closeConnLocked(entry *entry) {
+	if entry.conn == nil {
+		return
+	}
+
+	closeConn(entry.conn)
+
+	entry.conn = nil
+	if entry.lruElement != nil {
+		d.lru.Remove(entry.lruElement)
+		entry.lruElement = nil
+	}
+}
+
+func closeConn(db *gorm.DB) {
+	conn, err := db.DB()
+
+	if err == nil {
+		err = conn.Close()
+	}
 
 	if err != nil {
-		return err
+		slog.Warn("Tried to close connection do db, but got an error", "err", err)
 	}
+}
 
-	for _, entry := range fsEntries {
-		if !entry.Type().IsRegular() || !strings.HasSuffix(entry.Name(), ".sqlite") {
-			continue
+// evictLocked closes the least recently used connections that are not in use, until at most maxOpen are open.
+func (d *DbDirectory) evictLocked() {
+	if d.maxOpen <= 0 {
+		return
+	}
+
+	element := d.lru.Back()
+	for element != nil && d.lru.Len() > d.maxOpen {
+		previous := element.Prev()
+
+		dbId, _ := element.Value.(string)
+		if entry, ok := d.getEntry(dbId); ok && entry.refs == 0 {
+			d.closeConnLocked(entry)
 		}
 
-		candidateUuid := strings.Replace(entry.Name(), ".sqlite", "", 1)
-		_, err := uuid.Parse(candidateUuid)
+		element = previous
+	}
+}
+
+func (d *DbDirectory) restoreExistingDbs() error {
+	dbIds, err := d.storage.List()
+
+	if err != nil {
+		return err
+	}
+
+	for _, candidateUuid := range dbIds {
+		expiresAt, err := d.inspect(candidateUuid)
 
 		if err != nil {
-			slog.Info("There was a file with sqlite file-extension, which name was not parsable as uuid", "filname", entry.Name())
+			d.quarantine(candidateUuid, expiresAt, err)
 			continue
 		}
 
 		_, err = d.Open(candidateUuid)
 
 		if err != nil {
-			return err
+			d.quarantine(candidateUuid, expiresAt, err)
 		}
 	}
 
 	return nil
 }
 
-func (d *DbDirectory) getExpirationDate(dbId string) (time.Time, error) {
-	entry, ok := d.getEntry(dbId)
-
-	if !ok {
-		return time.Time{}, fmt.Errorf("requested expiration date for db that is not known to dbDirectory. dbId=%s", dbId)
-	}
-
+func (d *DbDirectory) getExpirationDate(conn *gorm.DB) (time.Time, error) {
 	var session Session
-	result := entry.conn.First(&session)
+	result := conn.First(&session)
 
 	if result.Error != nil {
 		return time.Time{}, result.Error
@@ -159,6 +247,7 @@ func (d *DbDirectory) getExpirationDate(dbId string) (time.Time, error) {
 	return session.ExpiresAt, nil
 }
 
-func (d *DbDirectory) path(dbId string) string {
-	return path.Join(d.rootDir, fmt.Sprintf("%s.sqlite", dbId))
+// pseudonym names the db in log lines without revealing its id.
+func (d *DbDirectory) pseudonym(dbId string) string {
+	return crypt.Pseudonym(dbId, d.pseudonymSecret)
 }
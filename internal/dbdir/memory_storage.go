@@ -0,0 +1,93 @@
+package dbdir
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// MemoryStorage keeps dbs in memory, which is useful for tests and demos that should not leave files behind.
+// All dbs are lost when the process ends.
+type MemoryStorage struct {
+	// name separates the dbs of different storages in the same process.
+	name string
+	mu   sync.Mutex
+	// keepers hold a connection to every db, since sqlite drops an in-memory db as soon as its last connection is
+	// closed. Otherwise closing idle connections would remove dbs.
+	keepers map[string]*sql.DB
+}
+
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{name: uuid.NewString(), keepers: make(map[string]*sql.DB)}
+}
+
+func (m *MemoryStorage) Dialector(dbId string) (gorm.Dialector, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	dsn := m.dsn(dbId)
+
+	if _, ok := m.keepers[dbId]; !ok {
+		keeper, err := sql.Open("sqlite3", dsn)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if err := keeper.Ping(); err != nil {
+			keeper.Close()
+			return nil, err
+		}
+
+		m.keepers[dbId] = keeper
+	}
+
+	return sqlite.Open(dsn), nil
+}
+
+func (m *MemoryStorage) Exists(dbId string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, ok := m.keepers[dbId]
+
+	return ok
+}
+
+func (m *MemoryStorage) Remove(dbId string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	keeper, ok := m.keepers[dbId]
+
+	if !ok {
+		return nil
+	}
+
+	delete(m.keepers, dbId)
+
+	return keeper.Close()
+}
+
+func (m *MemoryStorage) List() ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	dbIds := make([]string, 0, len(m.keepers))
+	for dbId := range m.keepers {
+		dbIds = append(dbIds, dbId)
+	}
+	slices.Sort(dbIds)
+
+	return dbIds, nil
+}
+
+func (m *MemoryStorage) dsn(dbId string) string {
+	// The memdb VFS shares a db between all connections that use the same name, with the same locking as files.
+	return fmt.Sprintf("file:/%s/%s?vfs=memdb", m.name, dbId)
+}
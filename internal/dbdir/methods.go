@@ -1,6 +1,7 @@
 package dbdir
 
 import (
+	"container/list"
 	"fmt"
 	"sync"
 	"time"
@@ -8,52 +9,293 @@ import (
 	"github.com/jonboulle/clockwork"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+	"softbaer.dev/ass/internal/crypt"
 )
 
-func New(rootDir string, maxAge time.Duration, clock clockwork.Clock, models []any) (*DbDirectory, error) {
-	dbdir := &DbDirectory{rootDir: rootDir, maxAge: maxAge, entries: sync.Map{}, clock: clock, models: models}
+func New(rootDir string, maxAge time.Duration, clock clockwork.Clock, models []any, opts ...Option) (*DbDirectory, error) {
+	dbdir := &DbDirectory{
+		storage:         NewFileStorage(rootDir),
+		maxAge:          maxAge,
+		entries:         sync.Map{},
+		lru:             list.New(),
+		clock:           clock,
+		models:          models,
+		pseudonymSecret: crypt.GenerateSecret(),
+	}
+	for _, opt := range opts {
+		opt(dbdir)
+	}
+	dbdir.maxLifetime = max(dbdir.maxLifetime, maxAge)
+
+	if err := dbdir.restoreQuarantinedDbs(); err != nil {
+		return dbdir, err
+	}
 
 	err := dbdir.restoreExistingDbs()
 
 	return dbdir, err
 }
 
+// Open returns the connection to the db with the given id. If the db does not exist yet, it is created and
+// expires after maxAge.
+// The connection may be closed as soon as more than the maximum number of open connections are in use. Use Acquire
+// to keep it open.
 func (d *DbDirectory) Open(dbId string) (*gorm.DB, error) {
-	existingEntry, ok := d.getEntry(dbId)
-
-	if ok {
-		return existingEntry.conn, nil
+	conn, release, err := d.acquire(dbId, d.maxAge, true)
+	if err != nil {
+		return nil, err
 	}
+	release()
 
-	dbPath := d.path(dbId)
+	return conn, nil
+}
+
+// Acquire does the same as Open, but keeps the connection open until release is called.
+func (d *DbDirectory) Acquire(dbId string) (conn *gorm.DB, release func(), err error) {
+	return d.acquire(dbId, d.maxAge, true)
+}
 
-	db, err := NewDb(dbPath, d.models)
+// Create creates the db with the given id, which expires after retention. Activity extends it by retention as well.
+// The retention must not be longer than the max lifetime of the directory.
+func (d *DbDirectory) Create(dbId string, retention time.Duration) (*gorm.DB, error) {
+	if !d.ValidRetention(retention) {
+		return nil, fmt.Errorf("%w: %s (maximum: %s)", ErrInvalidRetention, retention, d.maxLifetime)
+	}
+
+	conn, release, err := d.acquire(dbId, retention, true)
 	if err != nil {
 		return nil, err
 	}
-	if err := db.AutoMigrate(&Session{}); err != nil {
+	release()
+
+	return conn, nil
+}
+
+// ValidRetention reports whether Create accepts the retention.
+func (d *DbDirectory) ValidRetention(retention time.Duration) bool {
+	return retention > 0 && retention <= d.maxLifetime
+}
+
+// MaxLifetime returns how long a db can live at most, no matter its retention and activity.
+func (d *DbDirectory) MaxLifetime() time.Duration {
+	return d.maxLifetime
+}
+
+// Remove closes and removes the db with the given id right away. It returns the point in time of the removal.
+func (d *DbDirectory) Remove(dbId string) (time.Time, error) {
+	entry, ok := d.getEntry(dbId)
+	if !ok {
+		return time.Time{}, fmt.Errorf("tried to remove db that is not known to dbDirectory. dbId=%s", dbId)
+	}
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	if entry.expirationTimer != nil && !entry.expirationTimer.Stop() {
+		// The timer already fired and removes the db anyway.
+		return d.clock.Now(), nil
+	}
+
+	if err := d.remove(dbId); err != nil {
+		return time.Time{}, err
+	}
+
+	return d.clock.Now(), nil
+}
+
+// acquire returns an open connection to the db with the given id and holds a reference to it until release is
+// called. Dbs that are not known yet are only created if mayCreate is true. Then they expire after retention.
+func (d *DbDirectory) acquire(dbId string, retention time.Duration, mayCreate bool) (*gorm.DB, func(), error) {
+	d.mu.Lock()
+
+	entry, ok := d.getEntry(dbId)
+	if ok && !d.storage.Exists(dbId) {
+		// The db was purged from outside. An open connection would keep working on the removed files, so it is closed
+		// and the db is forgotten like an expired one.
+		d.dropPurgedLocked(dbId, entry)
+		ok = false
+	}
+
+	created := false
+	switch {
+	case !ok && !mayCreate:
+		d.mu.Unlock()
+		return nil, nil, fmt.Errorf("db is not known to dbDirectory. dbId=%s", dbId)
+	case !ok:
+		var err error
+		if !d.storage.Exists(dbId) {
+			err = d.makeRoomLocked()
+		}
+		if err == nil {
+			entry, err = d.createLocked(dbId, retention)
+		}
+		if err != nil {
+			d.mu.Unlock()
+			return nil, nil, err
+		}
+		created = true
+	case entry.conn == nil:
+		conn, err := d.openConn(dbId)
+		if err != nil {
+			d.mu.Unlock()
+			return nil, nil, err
+		}
+		entry.conn = conn
+	}
+
+	entry.refs++
+	d.touchLocked(dbId, entry)
+	d.evictLocked()
+	conn := entry.conn
+
+	d.mu.Unlock()
+
+	release := sync.OnceFunc(func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+
+		entry.refs--
+		d.evictLocked()
+	})
+
+	if created {
+		d.scheduleRemove(dbId, conn)
+	}
+
+	return conn, release, nil
+}
+
+// createLocked opens the file of the db, registers it in the directory and makes sure it has a session row.
+func (d *DbDirectory) createLocked(dbId string, retention time.Duration) (*entry, error) {
+	db, err := d.openConn(dbId)
+	if err != nil {
 		return nil, err
 	}
 
-	d.setEntry(dbId, &entry{conn: db})
 	var count int64
 	db.Model(&Session{}).Count(&count)
 
 	if count == 0 {
-		db.Create(&Session{ExpiresAt: d.clock.Now().Add(d.maxAge)})
+		now := d.clock.Now()
+		db.Create(&Session{Model: gorm.Model{CreatedAt: now}, ExpiresAt: now.Add(retention), Retention: retention})
 	}
 
 	if count > 1 {
+		closeConn(db)
 		return nil, fmt.Errorf("critical! Found multiple session entries in session table. dbId=%s, count=%d", dbId, count)
 	}
 
-	d.scheduleRemove(dbId)
+	var session Session
+	if err := db.First(&session).Error; err != nil {
+		closeConn(db)
+		return nil, err
+	}
 
-	return db, err
+	entry := &entry{conn: db, createdAt: session.CreatedAt}
+	d.setEntry(dbId, entry)
+
+	return entry, nil
+}
+
+func (d *DbDirectory) openConn(dbId string) (*gorm.DB, error) {
+	dialector, err := d.storage.Dialector(dbId)
+	if err != nil {
+		return nil, err
+	}
+	db, err := newDb(dialector, d.models)
+	if err != nil {
+		return nil, err
+	}
+	if err := Migrate(db, d.migrations); err != nil {
+		closeConn(db)
+		return nil, err
+	}
+	if err := db.AutoMigrate(&Session{}); err != nil {
+		closeConn(db)
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// Expiration returns when the db with the given id will be removed.
+func (d *DbDirectory) Expiration(dbId string) (Expiration, error) {
+	conn, release, err := d.acquire(dbId, 0, false)
+	if err != nil {
+		return Expiration{}, err
+	}
+	defer release()
+
+	var session Session
+	if err := conn.First(&session).Error; err != nil {
+		return Expiration{}, err
+	}
+
+	return d.expirationOf(session), nil
+}
+
+// Extend slides the expiration of the db with the given id to its retention from now, but never beyond maxLifetime after
+// the creation of the db. The removal of the db is rescheduled accordingly.
+func (d *DbDirectory) Extend(dbId string) (Expiration, error) {
+	conn, release, err := d.acquire(dbId, 0, false)
+	if err != nil {
+		return Expiration{}, err
+	}
+	defer release()
+
+	entry, ok := d.getEntry(dbId)
+	if !ok {
+		return Expiration{}, fmt.Errorf("tried to extend db that is not known to dbDirectory. dbId=%s", dbId)
+	}
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	var session Session
+	if err := conn.First(&session).Error; err != nil {
+		return Expiration{}, err
+	}
+
+	expiration := d.expirationOf(session)
+	extendedExpiresAt := d.clock.Now().Add(session.retentionOr(d.maxAge))
+	if extendedExpiresAt.After(expiration.HardCap) {
+		extendedExpiresAt = expiration.HardCap
+	}
+
+	if !extendedExpiresAt.After(session.ExpiresAt) {
+		return expiration, nil
+	}
+
+	if entry.expirationTimer != nil && !entry.expirationTimer.Stop() {
+		// The timer already fired, so the db is about to be removed. There is nothing left to extend.
+		return expiration, nil
+	}
+
+	if err := conn.Model(&session).Update("ExpiresAt", extendedExpiresAt).Error; err != nil {
+		d.scheduleRemove(dbId, conn)
+		return expiration, err
+	}
+	session.ExpiresAt = extendedExpiresAt
+
+	d.scheduleRemove(dbId, conn)
+
+	return d.expirationOf(session), nil
+}
+
+func (d *DbDirectory) expirationOf(session Session) Expiration {
+	return Expiration{
+		ExpiresAt: session.ExpiresAt,
+		HardCap:   session.CreatedAt.Add(d.maxLifetime),
+		Remaining: session.ExpiresAt.Sub(d.clock.Now()),
+	}
 }
 
 func NewDb(dbPath string, models []any) (*gorm.DB, error) {
-	db, err := gorm.Open(sqlite.Open(dbPath), &gorm.Config{})
+	return newDb(sqlite.Open(dbPath), models)
+}
+
+func newDb(dialector gorm.Dialector, models []any) (*gorm.DB, error) {
+	db, err := gorm.Open(dialector, &gorm.Config{})
 
 	if err != nil {
 		return nil, err
@@ -63,32 +305,57 @@ func NewDb(dbPath string, models []any) (*gorm.DB, error) {
 	db.Exec("PRAGMA journal_mode = WAL;")
 	db.Exec("PRAGMA busy_timeout = 5000;")
 	if err := db.AutoMigrate(models...); err != nil {
+		closeConn(db)
 		return nil, err
 	}
 	return db, err
 }
 
+// Stats counts the sessions and the connections that are currently open, e.g. to report them as metrics.
+func (d *DbDirectory) Stats() Stats {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	stats := Stats{OpenConnections: d.lru.Len()}
+	for range d.iterEntries() {
+		stats.Sessions++
+	}
+
+	return stats
+}
+
 func (d *DbDirectory) Close() []error {
 	errs := make([]error, 0)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
 	for dbId, entry := range d.iterEntries() {
-		conn, err := entry.conn.DB()
+		if entry.conn != nil {
+			conn, err := entry.conn.DB()
 
-		if err != nil {
-			errs = append(errs, err)
-		}
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
 
-		err = conn.Close()
+			err = conn.Close()
 
-		if err != nil {
-			errs = append(errs, err)
+			if err != nil {
+				errs = append(errs, err)
+			}
 		}
 
 		if entry.expirationTimer == nil {
 			errs = append(errs, fmt.Errorf("no expiration timer found for %s", dbId))
+			continue
 		}
 
 		entry.expirationTimer.Stop()
 	}
 
+	for _, timer := range d.quarantineTimers {
+		timer.Stop()
+	}
+
 	return errs
 }
@@ -0,0 +1,75 @@
+package dbdir
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migration brings the schema and data of a db from Version-1 to Version.
+type Migration struct {
+	Version     int
+	Description string
+	// Up runs in a transaction together with recording the new version.
+	Up func(tx *gorm.DB) error
+}
+
+// schemaVersion records an applied migration in the db itself.
+type schemaVersion struct {
+	Version     int `gorm:"primaryKey"`
+	Description string
+	AppliedAt   time.Time
+}
+
+var ErrUnknownSchemaVersion = errors.New("db has a schema version this build does not know")
+
+// WithMigrations applies the migrations to every db when it is opened. The versions of the migrations have to count
+// up from 1 without gaps.
+func WithMigrations(migrations []Migration) Option {
+	return func(d *DbDirectory) {
+		d.migrations = migrations
+	}
+}
+
+// Migrate applies all migrations that were not applied to db yet, in the order of their versions.
+func Migrate(db *gorm.DB, migrations []Migration) error {
+	for i, migration := range migrations {
+		if migration.Version != i+1 {
+			return fmt.Errorf("migrations must count up from 1 without gaps, found version %d at position %d", migration.Version, i+1)
+		}
+	}
+
+	if err := db.AutoMigrate(&schemaVersion{}); err != nil {
+		return err
+	}
+
+	var current int
+	if err := db.Model(&schemaVersion{}).Select("COALESCE(MAX(version), 0)").Scan(&current).Error; err != nil {
+		return err
+	}
+
+	if current > len(migrations) {
+		return fmt.Errorf("%w: %d (latest known: %d)", ErrUnknownSchemaVersion, current, len(migrations))
+	}
+
+	for _, migration := range migrations[current:] {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := migration.Up(tx); err != nil {
+				return err
+			}
+
+			return tx.Create(&schemaVersion{Version: migration.Version, Description: migration.Description, AppliedAt: time.Now()}).Error
+		})
+
+		if err != nil {
+			return fmt.Errorf("migration %d (%s) failed: %w", migration.Version, migration.Description, err)
+		}
+
+		slog.Info("Applied migration", "version", migration.Version, "description", migration.Description)
+	}
+
+	return nil
+}
@@ -0,0 +1,126 @@
+package dbdir
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// QuarantineStorage is implemented by storages that can keep broken dbs aside until they expire. Broken dbs in other
+// storages are removed right away.
+type QuarantineStorage interface {
+	// Quarantine moves the db out of the way and returns the name it is kept under.
+	Quarantine(dbId string, expiresAt time.Time) (string, error)
+	// Quarantined returns the expiration of all quarantined dbs by name.
+	Quarantined() (map[string]time.Time, error)
+	RemoveQuarantined(name string) error
+}
+
+// inspect checks the integrity of the db without migrating it. The expiration date is returned even if the check
+// fails, as long as it is readable. Otherwise it is the zero time.
+func (d *DbDirectory) inspect(dbId string) (time.Time, error) {
+	dialector, err := d.storage.Dialector(dbId)
+
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	db, err := gorm.Open(dialector, &gorm.Config{})
+
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	defer closeConn(db)
+
+	var result string
+	integrityErr := db.Raw("PRAGMA integrity_check").Row().Scan(&result)
+
+	if integrityErr == nil && result != "ok" {
+		integrityErr = fmt.Errorf("integrity check failed: %s", result)
+	}
+
+	// Dbs of older versions might not have a session yet. Open creates it, so that is no reason for quarantine.
+	var session Session
+	if err := db.First(&session).Error; err != nil {
+		return time.Time{}, integrityErr
+	}
+
+	return session.ExpiresAt, integrityErr
+}
+
+// quarantine moves a broken db out of the way, so that the remaining dbs can be served. It is removed at expiresAt
+// or, if that is unknown, maxAge from now.
+func (d *DbDirectory) quarantine(dbId string, expiresAt time.Time, cause error) {
+	quarantineStorage, ok := d.storage.(QuarantineStorage)
+
+	if !ok {
+		slog.Warn("Removing broken db, because the storage has no quarantine", "session", d.pseudonym(dbId), "cause", cause)
+
+		if err := d.storage.Remove(dbId); err != nil {
+			slog.Error("Could not remove broken db :(", "session", d.pseudonym(dbId), "err", err)
+		}
+
+		return
+	}
+
+	if expiresAt.IsZero() {
+		expiresAt = d.clock.Now().Add(d.maxAge)
+	}
+
+	name, err := quarantineStorage.Quarantine(dbId, expiresAt)
+
+	if err != nil {
+		slog.Error("Could not move broken db to quarantine. Leaving it in place", "session", d.pseudonym(dbId), "err", err)
+		return
+	}
+
+	slog.Warn("Moved broken db to quarantine", "session", d.pseudonym(dbId), "expiresAt", expiresAt, "cause", cause)
+
+	d.scheduleQuarantineRemoval(quarantineStorage, name, expiresAt)
+}
+
+// restoreQuarantinedDbs schedules the removal of the dbs quarantined in earlier runs.
+func (d *DbDirectory) restoreQuarantinedDbs() error {
+	quarantineStorage, ok := d.storage.(QuarantineStorage)
+
+	if !ok {
+		return nil
+	}
+
+	quarantined, err := quarantineStorage.Quarantined()
+
+	if err != nil {
+		return err
+	}
+
+	for name, expiresAt := range quarantined {
+		d.scheduleQuarantineRemoval(quarantineStorage, name, expiresAt)
+	}
+
+	return nil
+}
+
+func (d *DbDirectory) scheduleQuarantineRemoval(quarantineStorage QuarantineStorage, name string, expiresAt time.Time) {
+	removeQuarantined := func() {
+		if err := quarantineStorage.RemoveQuarantined(name); err != nil {
+			slog.Error("Could not remove quarantined db :(", "name", name, "err", err)
+		}
+	}
+
+	expireIn := expiresAt.Sub(d.clock.Now())
+
+	if expireIn <= 0 {
+		removeQuarantined()
+		return
+	}
+
+	timer := d.clock.AfterFunc(expireIn, removeQuarantined)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.quarantineTimers = append(d.quarantineTimers, timer)
+}
@@ -0,0 +1,178 @@
+package dbdir
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// Storage keeps the dbs of a DbDirectory. Each db is a sqlite database of its own. The DbDirectory decides when dbs
+// expire, so that expiration works the same for all storages.
+type Storage interface {
+	// Dialector returns what gorm opens the db with. Opening it creates the db if it does not exist yet.
+	Dialector(dbId string) (gorm.Dialector, error)
+	Exists(dbId string) bool
+	// Remove removes the db. Removing a db that does not exist is no error, since it might have been purged already.
+	// All connections to the db are closed before.
+	Remove(dbId string) error
+	// List returns the ids of all existing dbs, so that a DbDirectory can take over dbs of an earlier one.
+	List() ([]string, error)
+}
+
+// FileStorage keeps every db in a file <dbId>.sqlite in its root dir.
+type FileStorage struct {
+	rootDir string
+}
+
+// quarantineDirName is the subdirectory of the root dir that broken dbs are moved to. Quarantined files are named
+// <dbId>.<expiration as unix timestamp>.sqlite, so that they can still be removed at their expiration after a restart.
+const quarantineDirName = "quarantine"
+
+// sideFileSuffixes are appended to the path of a db to get the files sqlite keeps next to it in WAL mode.
+var sideFileSuffixes = []string{"-wal", "-shm"}
+
+func NewFileStorage(rootDir string) *FileStorage {
+	return &FileStorage{rootDir: rootDir}
+}
+
+func (f *FileStorage) Dialector(dbId string) (gorm.Dialector, error) {
+	return sqlite.Open(f.path(dbId)), nil
+}
+
+func (f *FileStorage) Exists(dbId string) bool {
+	return fileExists(f.path(dbId))
+}
+
+func (f *FileStorage) Remove(dbId string) error {
+	return removeDbFiles(f.path(dbId))
+}
+
+func (f *FileStorage) List() ([]string, error) {
+	return dbIdsIn(f.rootDir)
+}
+
+func (f *FileStorage) Quarantine(dbId string, expiresAt time.Time) (string, error) {
+	quarantineDir := path.Join(f.rootDir, quarantineDirName)
+
+	if err := os.MkdirAll(quarantineDir, 0o700); err != nil {
+		return "", err
+	}
+
+	name := fmt.Sprintf("%s.%d.sqlite", dbId, expiresAt.Unix())
+	dbPath := f.path(dbId)
+
+	if err := os.Rename(dbPath, path.Join(quarantineDir, name)); err != nil {
+		return "", err
+	}
+
+	for _, suffix := range sideFileSuffixes {
+		err := os.Rename(dbPath+suffix, path.Join(quarantineDir, name+suffix))
+
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			// The paths in err would reveal the db id, which gives access to the session.
+			slog.Error("Could not move side file of broken db to quarantine", "suffix", suffix, "err", errors.Unwrap(err))
+		}
+	}
+
+	return name, nil
+}
+
+func (f *FileStorage) Quarantined() (map[string]time.Time, error) {
+	fsEntries, err := os.ReadDir(path.Join(f.rootDir, quarantineDirName))
+
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	quarantined := make(map[string]time.Time)
+	for _, entry := range fsEntries {
+		if !entry.Type().IsRegular() || !strings.HasSuffix(entry.Name(), ".sqlite") {
+			continue
+		}
+
+		dbIdAndExpiration := strings.TrimSuffix(entry.Name(), ".sqlite")
+		dot := strings.LastIndex(dbIdAndExpiration, ".")
+		expiresAtUnix, err := strconv.ParseInt(dbIdAndExpiration[dot+1:], 10, 64)
+
+		if dot < 0 || err != nil {
+			slog.Info("There was a file in the quarantine dir without expiration in its name", "filename", entry.Name())
+			continue
+		}
+
+		quarantined[entry.Name()] = time.Unix(expiresAtUnix, 0)
+	}
+
+	return quarantined, nil
+}
+
+func (f *FileStorage) RemoveQuarantined(name string) error {
+	return removeDbFiles(path.Join(f.rootDir, quarantineDirName, name))
+}
+
+func (f *FileStorage) path(dbId string) string {
+	return dbPathIn(f.rootDir, dbId)
+}
+
+func dbPathIn(rootDir string, dbId string) string {
+	return path.Join(rootDir, fmt.Sprintf("%s.sqlite", dbId))
+}
+
+// dbIdsIn returns the ids of all dbs in rootDir.
+func dbIdsIn(rootDir string) ([]string, error) {
+	fsEntries, err := os.ReadDir(rootDir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var dbIds []string
+	for _, entry := range fsEntries {
+		if !entry.Type().IsRegular() || !strings.HasSuffix(entry.Name(), ".sqlite") {
+			continue
+		}
+
+		candidateUuid := strings.Replace(entry.Name(), ".sqlite", "", 1)
+		_, err := uuid.Parse(candidateUuid)
+
+		if err != nil {
+			slog.Info("There was a file with sqlite file-extension, which name was not parsable as uuid", "filname", entry.Name())
+			continue
+		}
+
+		dbIds = append(dbIds, candidateUuid)
+	}
+
+	return dbIds, nil
+}
+
+// removeDbFiles removes the db at dbPath. Files that do not exist are skipped, because the db might have been purged
+// from outside the DbDirectory already.
+func removeDbFiles(dbPath string) error {
+	// In WAL mode sqlite keeps data in side files, which have to be removed as well.
+	for _, suffix := range append([]string{""}, sideFileSuffixes...) {
+		if err := os.Remove(dbPath + suffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+
+	return !errors.Is(err, os.ErrNotExist)
+}
@@ -1,27 +1,133 @@
 package dbdir
 
 import (
+	"container/list"
+	"errors"
 	"sync"
 	"time"
 
 	"github.com/jonboulle/clockwork"
 	"gorm.io/gorm"
+	"softbaer.dev/ass/internal/crypt"
 )
 
 type DbDirectory struct {
-	rootDir string
+	storage Storage
 	maxAge  time.Duration
-	entries sync.Map 
-	clock   clockwork.Clock
-	models  []any
+	// maxLifetime caps how far activity can extend a db beyond its creation. It is never shorter than maxAge.
+	maxLifetime time.Duration
+	entries     sync.Map
+	// mu serializes creating, opening, closing and removing dbs, so that a db is never opened while its files are
+	// being removed. It also guards the connections of the entries, their reference counts and lru.
+	mu sync.Mutex
+	// maxOpen is the maximum number of connections kept open. Zero means no limit.
+	maxOpen int
+	// lru holds the ids of all dbs with an open connection, the most recently used one at the front.
+	lru *list.List
+	// maxSessions is the maximum number of dbs. Zero means no limit.
+	maxSessions int
+	evictOldest bool
+	// quarantineTimers remove quarantined db files at their expiration. They are guarded by mu.
+	quarantineTimers []clockwork.Timer
+	clock            clockwork.Clock
+	// models are auto-migrated when a db is opened. Schemas that evolve should use migrations instead.
+	models     []any
+	migrations []Migration
+	// pseudonymSecret keys the pseudonyms that name dbs in log lines, since their ids give access to the sessions.
+	pseudonymSecret crypt.Secret
+}
+
+type Option func(*DbDirectory)
+
+// WithMaxLifetime allows Extend to push the expiration of a db up to maxLifetime after its creation.
+// Without this option, dbs expire maxAge after their creation regardless of activity.
+func WithMaxLifetime(maxLifetime time.Duration) Option {
+	return func(d *DbDirectory) {
+		d.maxLifetime = maxLifetime
+	}
+}
+
+// WithStorage keeps the dbs in storage instead of files in the root dir passed to New.
+func WithStorage(storage Storage) Option {
+	return func(d *DbDirectory) {
+		d.storage = storage
+	}
+}
+
+// WithPseudonymSecret names dbs in log lines by the same pseudonyms as the request logs of their sessions. Without this
+// option, the pseudonyms are keyed by a random secret and can not be correlated with other logs.
+func WithPseudonymSecret(secret crypt.Secret) Option {
+	return func(d *DbDirectory) {
+		d.pseudonymSecret = secret
+	}
+}
+
+// WithMaxOpenConnections limits how many dbs are kept open at the same time. The least recently used connections
+// beyond the limit are closed and reopened on demand. Connections in use are never closed, so the limit can be
+// exceeded temporarily.
+func WithMaxOpenConnections(maxOpen int) Option {
+	return func(d *DbDirectory) {
+		d.maxOpen = maxOpen
+	}
+}
+
+// WithMaxSessions limits how many dbs can exist at the same time. Creating another one fails with ErrTooManySessions,
+// unless evictOldest is set. Then the oldest db that is not in use is removed to make room.
+func WithMaxSessions(maxSessions int, evictOldest bool) Option {
+	return func(d *DbDirectory) {
+		d.maxSessions = maxSessions
+		d.evictOldest = evictOldest
+	}
 }
 
 type entry struct {
-	conn            *gorm.DB
+	// conn is nil while the db is closed.
+	conn *gorm.DB
+	// refs counts the callers of Acquire that did not release the connection yet.
+	refs       int
+	lruElement *list.Element
+	createdAt  time.Time
+	// mu guards rescheduling the expiration timer.
+	mu              sync.Mutex
 	expirationTimer clockwork.Timer
 }
 
+// Stats describes the state of a DbDirectory at one point in time.
+type Stats struct {
+	Sessions        int
+	OpenConnections int
+}
+
+// Expiration describes when a db will be removed.
+type Expiration struct {
+	ExpiresAt time.Time
+	// HardCap is the point in time the expiration can not be extended beyond.
+	HardCap   time.Time
+	Remaining time.Duration
+}
+
+// Extendable reports whether activity can still push the expiration further.
+func (e Expiration) Extendable() bool {
+	return e.ExpiresAt.Before(e.HardCap)
+}
+
+var (
+	ErrInvalidRetention = errors.New("invalid retention")
+	ErrTooManySessions  = errors.New("too many sessions")
+)
+
 type Session struct {
 	gorm.Model
 	ExpiresAt time.Time
+	// Retention is how long the db is kept after the last activity. Dbs created before retention was configurable
+	// have a retention of 0 and fall back to the maxAge of the directory.
+	Retention time.Duration
+}
+
+func (s Session) retentionOr(fallback time.Duration) time.Duration {
+	if s.Retention <= 0 {
+		return fallback
+	}
+
+	return s.Retention
 }
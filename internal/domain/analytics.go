@@ -0,0 +1,97 @@
+package domain
+
+// DemandOutlook classifies what will certainly happen to a course, independently of how the solver decides.
+type DemandOutlook string
+
+const (
+	DemandOutlookOpen DemandOutlook = "open"
+	// DemandOutlookOverflow means that more participants are assigned to the course or have no other choice than the
+	// course than it can take.
+	DemandOutlookOverflow DemandOutlook = "overflow"
+	// DemandOutlookCancelled means that the course is empty and too few participants prioritized it to ever reach its
+	// minimum capacity.
+	DemandOutlookCancelled DemandOutlook = "cancelled"
+)
+
+// Label describes the outlook for the user.
+func (o DemandOutlook) Label() string {
+	switch o {
+	case DemandOutlookOverflow:
+		return "Überbucht"
+	case DemandOutlookCancelled:
+		return "Fällt aus"
+	default:
+		return ""
+	}
+}
+
+// CourseDemand compares how often a course was prioritized with how many participants it can take.
+type CourseDemand struct {
+	Course CourseData
+	// CountsByLevel holds at index i the number of participants that prioritized the course with level i+1.
+	CountsByLevel []int
+	// WeightedDemand sums up the priorities of the course. A first priority counts fully, every further level counts
+	// less, in the same way the linear objective of the solver weighs them.
+	WeightedDemand float64
+	// Ratio is WeightedDemand divided by MaxCapacity. It is 0 for courses without capacity.
+	Ratio   float64
+	Outlook DemandOutlook
+}
+
+// AnalyzeDemand computes the demand of every course of the scenario from its priorities.
+// The result has the same order as AllCourses.
+func AnalyzeDemand(s *Scenario) []CourseDemand {
+	maxLevel := s.MaxAmountOfPriorities()
+
+	countsByCourse := make(map[CourseID][]int)
+	priorityCountByParticipant := make(map[ParticipantID]int)
+	for priority := range s.AllPriorities() {
+		counts, ok := countsByCourse[priority.CourseID]
+		if !ok {
+			counts = make([]int, maxLevel)
+			countsByCourse[priority.CourseID] = counts
+		}
+		counts[priority.Level-1]++
+		priorityCountByParticipant[priority.ParticipantID]++
+	}
+
+	// Unassigned participants with a single priority can only ever end up in that course.
+	withoutAlternativeByCourse := make(map[CourseID]int)
+	for _, p := range s.Unassigned() {
+		if priorityCountByParticipant[p.ID] == 1 {
+			withoutAlternativeByCourse[s.priorityTable[p.ID][0].ID]++
+		}
+	}
+
+	var demands []CourseDemand
+	for course := range s.AllCourses() {
+		counts, ok := countsByCourse[course.ID]
+		if !ok {
+			counts = make([]int, maxLevel)
+		}
+
+		demand := CourseDemand{Course: course, CountsByLevel: counts, Outlook: DemandOutlookOpen}
+
+		interested := 0
+		for i, count := range counts {
+			interested += count
+			demand.WeightedDemand += float64(count) * float64(maxLevel-i) / float64(maxLevel)
+		}
+
+		if course.MaxCapacity > 0 {
+			demand.Ratio = demand.WeightedDemand / float64(course.MaxCapacity)
+		}
+
+		allocation := s.AllocationOf(course.ID)
+		switch {
+		case allocation+withoutAlternativeByCourse[course.ID] > course.MaxCapacity:
+			demand.Outlook = DemandOutlookOverflow
+		case allocation == 0 && interested < course.MinCapacity:
+			demand.Outlook = DemandOutlookCancelled
+		}
+
+		demands = append(demands, demand)
+	}
+
+	return demands
+}
@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestAnalyzeDemandCountsAndWeighsPriorities(t *testing.T) {
+	is := is.New(t)
+	s := newVerificationScenario()
+	is.NoErr(s.Prioritize(1, []CourseID{1, 2}))
+	is.NoErr(s.Prioritize(2, []CourseID{2, 1}))
+	is.NoErr(s.Prioritize(3, []CourseID{1}))
+
+	demands := AnalyzeDemand(s)
+
+	is.Equal(len(demands), 2)
+	is.Equal(demands[0].CountsByLevel, []int{2, 1})
+	is.Equal(demands[0].WeightedDemand, 2.5)
+	is.Equal(demands[0].Ratio, 2.5/3)
+	is.Equal(demands[0].Outlook, DemandOutlookOpen)
+	is.Equal(demands[1].CountsByLevel, []int{1, 1})
+	is.Equal(demands[1].WeightedDemand, 1.5)
+}
+
+func TestAnalyzeDemandHighlightsCertainOutcomes(t *testing.T) {
+	is := is.New(t)
+	s := newVerificationScenario()
+	// Course "bar" takes only one participant, but two of them have no alternative.
+	is.NoErr(s.Prioritize(1, []CourseID{2}))
+	is.NoErr(s.Prioritize(2, []CourseID{2}))
+	// Course "foo" needs two participants, but only one is interested.
+	is.NoErr(s.Prioritize(3, []CourseID{1, 2}))
+
+	demands := AnalyzeDemand(s)
+
+	is.Equal(demands[0].Outlook, DemandOutlookCancelled)
+	is.Equal(demands[1].Outlook, DemandOutlookOverflow)
+}
@@ -0,0 +1,162 @@
+package domain
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"softbaer.dev/ass/internal/crypt"
+	"softbaer.dev/ass/internal/model"
+)
+
+// BindCiphertexts binds names that were encrypted before ciphertexts were bound to their row and field, and encrypts
+// names of courses and participants that were stored in plain text, including those in the history and in snapshots. It does nothing once
+// they are all bound. Parameter tx should be a transaction, so that the db is either bound completely or not at all.
+func BindCiphertexts(tx *gorm.DB, secret crypt.Secret) error {
+	var binding model.CiphertextBinding
+	err := tx.Take(&binding).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !binding.Pending {
+		return nil
+	}
+
+	if err := encryptParticipantNames(tx, secret); err != nil {
+		return err
+	}
+	if err := bindParticipants(tx, secret); err != nil {
+		return err
+	}
+	if err := encryptCourseNames(tx, secret); err != nil {
+		return err
+	}
+
+	bind := rowRewrite{
+		participant: func(id int, row *participantRow) error { return row.bindCiphertexts(id, secret) },
+		course:      func(id int, row *courseRow) error { return row.encryptName(id, secret) },
+	}
+	if err := bind.apply(tx); err != nil {
+		return err
+	}
+
+	return tx.Model(&binding).Update("pending", false).Error
+}
+
+func bindParticipants(tx *gorm.DB, secret crypt.Secret) error {
+	var participants []model.Participant
+	if err := tx.Unscoped().Find(&participants).Error; err != nil {
+		return err
+	}
+
+	for _, participant := range participants {
+		bound, err := participant.BindCiphertexts(secret)
+		if err != nil {
+			return err
+		}
+		if !bound {
+			continue
+		}
+
+		if err := updateParticipantNames(tx, participant); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func encryptParticipantNames(tx *gorm.DB, secret crypt.Secret) error {
+	// Dbs created before names of participants were encrypted keep the plain names in these columns until now.
+	if !tx.Migrator().HasColumn("participants", "plain_prename") {
+		return nil
+	}
+
+	var plainParticipants []struct {
+		ID           int
+		PlainPrename string
+		PlainSurname string
+	}
+	err := tx.Table("participants").
+		Select("id", "COALESCE(plain_prename, '') AS plain_prename", "COALESCE(plain_surname, '') AS plain_surname").
+		Where("plain_prename IS NOT NULL OR plain_surname IS NOT NULL").
+		Find(&plainParticipants).Error
+	if err != nil {
+		return err
+	}
+
+	for _, plainParticipant := range plainParticipants {
+		participant := model.Participant{ID: plainParticipant.ID}
+		if err := participant.SetNames(plainParticipant.PlainPrename, plainParticipant.PlainSurname, secret); err != nil {
+			return err
+		}
+
+		err := tx.Table("participants").Where("id = ?", participant.ID).Updates(map[string]any{"encrypted_prename": participant.EncryptedPrename, "encrypted_surname": participant.EncryptedSurname, "plain_prename": nil, "plain_surname": nil}).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func encryptCourseNames(tx *gorm.DB, secret crypt.Secret) error {
+	// Dbs created before course names were encrypted keep the plain names in this column until now.
+	if !tx.Migrator().HasColumn("courses", "plain_name") {
+		return nil
+	}
+
+	var plainCourses []struct {
+		ID        int
+		PlainName string
+	}
+	if err := tx.Table("courses").Select("id", "plain_name").Where("plain_name IS NOT NULL").Find(&plainCourses).Error; err != nil {
+		return err
+	}
+
+	for _, plainCourse := range plainCourses {
+		course := model.Course{ID: plainCourse.ID}
+		if err := course.SetName(plainCourse.PlainName, secret); err != nil {
+			return err
+		}
+
+		err := tx.Table("courses").Where("id = ?", course.ID).Updates(map[string]any{"encrypted_name": course.EncryptedName, "name_index": course.NameIndex, "plain_name": nil}).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// bindCiphertexts binds the names of the row to the participant with the given id. A nil row is left as it is.
+func (r *participantRow) bindCiphertexts(id int, secret crypt.Secret) error {
+	if r == nil {
+		return nil
+	}
+
+	participant := r.dbModel(id)
+	if _, err := participant.BindCiphertexts(secret); err != nil {
+		return err
+	}
+	r.EncryptedPrename, r.EncryptedSurname = participant.EncryptedPrename, participant.EncryptedSurname
+
+	return nil
+}
+
+// encryptName encrypts the plain name of rows recorded before course names were encrypted. A nil row is left as it is.
+func (r *courseRow) encryptName(id int, secret crypt.Secret) error {
+	if r == nil || r.PlainName == "" {
+		return nil
+	}
+
+	course := model.Course{ID: id}
+	if err := course.SetName(r.PlainName, secret); err != nil {
+		return err
+	}
+	r.EncryptedName, r.NameIndex, r.PlainName = course.EncryptedName, course.NameIndex, ""
+
+	return nil
+}
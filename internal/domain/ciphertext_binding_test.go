@@ -0,0 +1,119 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/matryer/is"
+	"softbaer.dev/ass/internal/crypt"
+	"softbaer.dev/ass/internal/dbdir"
+	"softbaer.dev/ass/internal/model"
+)
+
+func TestBindCiphertextsBindsNamesOfOlderDbs(t *testing.T) {
+	is := is.New(t)
+	db, err := dbdir.NewDb(fmt.Sprintf("%s/older.sqlite", t.TempDir()), nil)
+	is.NoErr(err)
+	is.NoErr(dbdir.Migrate(db, model.Migrations))
+
+	secret := crypt.GenerateSecret()
+	unbound := func(plaintext string) string {
+		ciphertext, err := crypt.Encrypt(plaintext, secret, nil)
+		is.NoErr(err)
+		return ciphertext
+	}
+
+	// Names encrypted before they were bound to their rows, as found in older dbs.
+	is.NoErr(db.Create(&model.Participant{ID: 1, EncryptedPrename: unbound("Ada"), EncryptedSurname: unbound("Lovelace")}).Error)
+	snapshot, err := SaveSnapshot(db, "alt")
+	is.NoErr(err)
+	is.NoErr(db.Model(&model.CiphertextBinding{}).Where("1 = 1").Update("pending", true).Error)
+
+	_, err = LoadScenario(db, secret)
+	var decryptionErr *model.DecryptionError
+	is.True(errors.As(err, &decryptionErr)) // want unbound names to be refused
+	is.Equal(decryptionErr.ID, 1)           // want the error to name the row
+	is.True(errors.Is(err, model.ErrUnboundCiphertext))
+
+	is.NoErr(BindCiphertexts(db, secret))
+
+	scenario, err := LoadScenario(db, secret)
+	is.NoErr(err) // want bound names to be readable
+	for participant := range scenario.AllParticipants() {
+		is.Equal(participant.Prename, "Ada")
+	}
+
+	_, err = CompareSnapshots(db, secret, snapshot.ID, snapshot.ID)
+	is.NoErr(err) // want names in snapshots to be bound as well
+
+	var binding model.CiphertextBinding
+	is.NoErr(db.Take(&binding).Error)
+	is.True(!binding.Pending) // want binding to be done once
+}
+
+func TestDecryptingNameOfAnotherRowFails(t *testing.T) {
+	is := is.New(t)
+	secret := crypt.GenerateSecret()
+
+	ada, err := model.NewParticipant("Ada", "Lovelace", secret, model.WithParticipantId(1))
+	is.NoErr(err)
+	alan, err := model.NewParticipant("Alan", "Turing", secret, model.WithParticipantId(2))
+	is.NoErr(err)
+
+	alan.EncryptedPrename = ada.EncryptedPrename
+	_, err = alan.Prename(secret)
+	is.True(errors.Is(err, crypt.ErrTampered)) // want a prename swapped into another row to be detected
+
+	ada.EncryptedSurname = ada.EncryptedPrename
+	_, err = ada.Surname(secret)
+	is.True(errors.Is(err, crypt.ErrTampered)) // want a prename swapped into the surname column to be detected
+}
+
+func TestBindCiphertextsEncryptsPlainCourseNames(t *testing.T) {
+	is := is.New(t)
+	db, err := dbdir.NewDb(fmt.Sprintf("%s/older.sqlite", t.TempDir()), nil)
+	is.NoErr(err)
+	is.NoErr(dbdir.Migrate(db, model.Migrations[:6]))
+
+	// Before course names were encrypted, they were stored in plain text.
+	is.NoErr(db.Exec("INSERT INTO courses (id, name, max_capacity, min_capacity) VALUES (1, 'Töpfern', 10, 1)").Error)
+	is.NoErr(dbdir.Migrate(db, model.Migrations))
+
+	secret := crypt.GenerateSecret()
+	is.NoErr(BindCiphertexts(db, secret))
+
+	course, err := FindCourseByName(db, "Töpfern", secret)
+	is.NoErr(err) // want the course to be found by its blind index
+	is.Equal(course.ID, CourseID(1))
+	is.Equal(course.Name, "Töpfern")
+
+	var plainNames int64
+	is.NoErr(db.Table("courses").Where("plain_name IS NOT NULL").Count(&plainNames).Error)
+	is.Equal(plainNames, int64(0)) // want no plain name to stay on disk
+}
+
+func TestBindCiphertextsEncryptsPlainParticipantNames(t *testing.T) {
+	is := is.New(t)
+	db, err := dbdir.NewDb(fmt.Sprintf("%s/older.sqlite", t.TempDir()), nil)
+	is.NoErr(err)
+
+	// Before names of participants were encrypted, they were stored in plain text.
+	is.NoErr(db.Exec("CREATE TABLE participants (id integer PRIMARY KEY AUTOINCREMENT, prename text, surname text, course_id integer)").Error)
+	is.NoErr(db.Exec("INSERT INTO participants (id, prename, surname) VALUES (1, 'Ada', 'Lovelace')").Error)
+	is.NoErr(dbdir.Migrate(db, model.Migrations))
+
+	secret := crypt.GenerateSecret()
+	is.NoErr(BindCiphertexts(db, secret))
+
+	scenario, err := LoadScenario(db, secret)
+	is.NoErr(err)
+	participant, ok := scenario.participant(1)
+	is.True(ok)
+	is.Equal(participant.Prename, "Ada") // want the plain names to be kept
+	is.Equal(participant.Surname, "Lovelace")
+
+	var plainNames int64
+	is.NoErr(db.Table("participants").Where("plain_prename IS NOT NULL OR plain_surname IS NOT NULL").Count(&plainNames).Error)
+	is.Equal(plainNames, int64(0)) // want no plain name to stay on disk
+}
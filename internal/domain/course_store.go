@@ -1,26 +1,87 @@
 package domain
 
 import (
+	"errors"
+	"strings"
+
 	"gorm.io/gorm"
+	"softbaer.dev/ass/internal/crypt"
 	"softbaer.dev/ass/internal/model"
 )
 
-func FindSingleCourseData(db *gorm.DB, cid CourseID) (CourseData, error) {
+var ErrCourseNameTaken = errors.New("course name is taken")
+
+func FindSingleCourseData(db *gorm.DB, cid CourseID, secret crypt.Secret) (CourseData, error) {
 	var model model.Course
 	if err := db.First(&model, "id = ?", cid).Error; err != nil {
 		return CourseData{}, err
 	}
 
-	return courseFromDbModel(model), nil
+	return courseFromDbModel(model, secret)
+}
+
+// FindCourseByName looks the course up by the blind index of its name. It returns ErrCourseNotFound if there is no
+// course with that name.
+func FindCourseByName(db *gorm.DB, name string, secret crypt.Secret) (CourseData, error) {
+	var course model.Course
+	err := db.Take(&course, "name_index = ?", model.CourseNameIndex(strings.TrimSpace(name), secret)).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return CourseData{}, ErrCourseNotFound
+	}
+	if err != nil {
+		return CourseData{}, err
+	}
+
+	return courseFromDbModel(course, secret)
 }
 
-func findCourseDataById(db *gorm.DB, cids []CourseID) (result []CourseData, err error) {
+// FindAllCourseData returns all courses ordered by id.
+func FindAllCourseData(db *gorm.DB, secret crypt.Secret) ([]CourseData, error) {
+	var models []model.Course
+	if err := db.Order("id").Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	return coursesFromDbModels(models, secret)
+}
+
+func findCourseDataById(db *gorm.DB, cids []CourseID, secret crypt.Secret) (result []CourseData, err error) {
 	var models []model.Course
 	if err := db.Where("id IN ?", cids).Find(&models).Error; err != nil {
 		return result, err
 	}
 
-	return coursesFromDbModels(models), nil
+	return coursesFromDbModels(models, secret)
+}
+
+// CreateCourse saves a new course and returns it with its id. It fails with ErrCourseNameTaken if another course
+// has the same name.
+func CreateCourse(tx *gorm.DB, course CourseData, secret crypt.Secret) (CourseData, error) {
+	if _, err := FindCourseByName(tx, course.Name, secret); !errors.Is(err, ErrCourseNotFound) {
+		if err == nil {
+			return CourseData{}, ErrCourseNameTaken
+		}
+		return CourseData{}, err
+	}
+
+	// The name is bound to the id of the course, which is only known after the insert. The index is needed right
+	// away, since it is unique.
+	dbModel := model.Course{NameIndex: model.CourseNameIndex(course.Name, secret), MaxCapacity: course.MaxCapacity, MinCapacity: course.MinCapacity}
+	if err := tx.Create(&dbModel).Error; err != nil {
+		return CourseData{}, err
+	}
+
+	if err := dbModel.SetName(course.Name, secret); err != nil {
+		return CourseData{}, err
+	}
+
+	if err := tx.Model(&dbModel).Update("encrypted_name", dbModel.EncryptedName).Error; err != nil {
+		return CourseData{}, err
+	}
+
+	course.ID = CourseID(dbModel.ID)
+
+	return course, nil
 }
 
 // DeleteCourse deletes the course with the specified id together with all existing associations.
@@ -40,37 +101,50 @@ func DeleteCourse(tx *gorm.DB, courseId int) error {
 	return tx.Unscoped().Delete(&course).Error
 }
 
-func courseFromDbModel(model model.Course) CourseData {
+func courseFromDbModel(model model.Course, secret crypt.Secret) (CourseData, error) {
+	name, err := model.Name(secret)
+	if err != nil {
+		return CourseData{}, err
+	}
+
 	return CourseData{ID: CourseID(model.ID),
-		Name:        model.Name,
+		Name:        name,
 		MaxCapacity: model.MaxCapacity,
 		MinCapacity: model.MinCapacity,
-	}
+	}, nil
 }
 
-func coursesFromDbModels(models []model.Course) []CourseData {
+func coursesFromDbModels(models []model.Course, secret crypt.Secret) ([]CourseData, error) {
 	courses := make([]CourseData, len(models))
 	for i, dbCourse := range models {
-		courses[i] = courseFromDbModel(dbCourse)
+		course, err := courseFromDbModel(dbCourse, secret)
+		if err != nil {
+			return nil, err
+		}
+		courses[i] = course
 	}
-	return courses
+	return courses, nil
 }
 
-func courseToDbModel(course CourseData) model.Course {
-	return model.Course{
+func courseToDbModel(course CourseData, secret crypt.Secret) (model.Course, error) {
+	dbModel := model.Course{
 		ID:          int(course.ID),
-		Name:        course.Name,
 		MaxCapacity: course.MaxCapacity,
 		MinCapacity: course.MinCapacity,
 	}
+
+	return dbModel, dbModel.SetName(course.Name, secret)
 }
 
-func coursesToDbModels(courses []CourseData) []model.Course {
+func coursesToDbModels(courses []CourseData, secret crypt.Secret) ([]model.Course, error) {
 	dbModels := make([]model.Course, len(courses))
 	for i, course := range courses {
-		dbModels[i] = courseToDbModel(course)
+		var err error
+		if dbModels[i], err = courseToDbModel(course, secret); err != nil {
+			return nil, err
+		}
 	}
-	return dbModels
+	return dbModels, nil
 }
 
 func toCourseIds(ids []int) []CourseID {
@@ -0,0 +1,127 @@
+package domain
+
+import "fmt"
+
+// Diagnostic is the outcome of a cheap check that can be run before invoking the solver.
+type Diagnostic struct {
+	// Check describes what was checked.
+	Check  string
+	Passed bool
+	// Blocking is true if the solver can not find any solution as long as this check fails.
+	// Non-blocking checks hint at participants that will stay unassigned or courses that will stay below their minimum.
+	Blocking bool
+	Issues   []string
+}
+
+func newDiagnostic(check string, blocking bool, issues []string) Diagnostic {
+	return Diagnostic{Check: check, Passed: len(issues) == 0, Blocking: blocking && len(issues) > 0, Issues: issues}
+}
+
+// Diagnose runs all pre-solve checks on the scenario. Unlike the solver, it does not need z3 and returns quickly.
+func Diagnose(s *Scenario) []Diagnostic {
+	unassigned := s.Unassigned()
+
+	return []Diagnostic{
+		s.diagnoseRemainingCapacity(unassigned),
+		s.diagnoseParticipantsWithoutPriorities(unassigned),
+		s.diagnoseFullyBookedPriorities(unassigned),
+		s.diagnoseCourseInterest(unassigned),
+		s.diagnoseOccupiedCourses(),
+	}
+}
+
+// diagnoseRemainingCapacity checks that there are enough free seats for every unassigned participant that the solver
+// has to assign, i.e. every participant with a prioritized course that still has free seats. The solver leaves the
+// others out, so they can not make the scenario unsolvable.
+func (s *Scenario) diagnoseRemainingCapacity(unassigned []ParticipantData) Diagnostic {
+	required := 0
+	for _, p := range unassigned {
+		if s.hasFreePriority(p.ID) {
+			required++
+		}
+	}
+
+	available := 0
+	for _, c := range s.courses {
+		available += max(0, c.MaxCapacity-s.AllocationOf(c.ID))
+	}
+
+	var issues []string
+	if available < required {
+		issues = append(issues, fmt.Sprintf("Es gibt nur noch %d freie Plätze, aber %d nicht zugeteilte Teilnehmer mit Prioritäten", available, required))
+	}
+
+	return newDiagnostic("Genug freie Plätze für alle nicht zugeteilten Teilnehmer", true, issues)
+}
+
+func (s *Scenario) diagnoseParticipantsWithoutPriorities(unassigned []ParticipantData) Diagnostic {
+	var issues []string
+	for _, p := range unassigned {
+		if len(s.priorityTable[p.ID]) == 0 {
+			issues = append(issues, fmt.Sprintf("%s, %s hat keine Prioritäten und bleibt nicht zugeteilt", p.Surname, p.Prename))
+		}
+	}
+
+	return newDiagnostic("Alle nicht zugeteilten Teilnehmer haben Prioritäten", false, issues)
+}
+
+func (s *Scenario) diagnoseFullyBookedPriorities(unassigned []ParticipantData) Diagnostic {
+	var issues []string
+	for _, p := range unassigned {
+		if len(s.priorityTable[p.ID]) > 0 && !s.hasFreePriority(p.ID) {
+			issues = append(issues, fmt.Sprintf("Alle priorisierten Kurse von %s, %s sind bereits voll", p.Surname, p.Prename))
+		}
+	}
+
+	return newDiagnostic("Jeder nicht zugeteilte Teilnehmer hat eine Priorität mit freien Plätzen", false, issues)
+}
+
+// hasFreePriority reports whether one of the courses the participant prioritized still has free seats.
+func (s *Scenario) hasFreePriority(pid ParticipantID) bool {
+	for _, c := range s.priorityTable[pid] {
+		if s.AllocationOf(c.ID) < c.MaxCapacity {
+			return true
+		}
+	}
+
+	return false
+}
+
+// diagnoseCourseInterest checks that every course can reach its minimum capacity with the participants that are
+// already assigned to it or could be assigned to it by the solver.
+func (s *Scenario) diagnoseCourseInterest(unassigned []ParticipantData) Diagnostic {
+	interestedByCourse := make(map[CourseID]int)
+	for _, p := range unassigned {
+		for _, c := range s.priorityTable[p.ID] {
+			interestedByCourse[c.ID]++
+		}
+	}
+
+	var issues []string
+	for _, c := range s.courses {
+		interested := s.AllocationOf(c.ID) + interestedByCourse[c.ID]
+		if interested < c.MinCapacity {
+			issues = append(issues, fmt.Sprintf("Kurs '%s' hat nur %d Interessenten, braucht aber mindestens %d", c.Name, interested, c.MinCapacity))
+		}
+	}
+
+	return newDiagnostic("Alle Kurse haben genug Interessenten für ihre Mindestbelegung", false, issues)
+}
+
+// diagnoseOccupiedCourses checks the courses that already have participants assigned. These courses can not stay
+// empty anymore, so together they need at least the sum of their minimum capacities as participants.
+func (s *Scenario) diagnoseOccupiedCourses() Diagnostic {
+	requiredParticipants := 0
+	for _, c := range s.courses {
+		if s.AllocationOf(c.ID) > 0 {
+			requiredParticipants += c.MinCapacity
+		}
+	}
+
+	var issues []string
+	if requiredParticipants > len(s.participants) {
+		issues = append(issues, fmt.Sprintf("Die bereits belegten Kurse brauchen zusammen mindestens %d Teilnehmer, es gibt aber nur %d", requiredParticipants, len(s.participants)))
+	}
+
+	return newDiagnostic("Bereits belegte Kurse können ihre Mindestbelegung erreichen", false, issues)
+}
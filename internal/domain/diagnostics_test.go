@@ -0,0 +1,81 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func failedChecks(diagnostics []Diagnostic) (checks []string) {
+	for _, d := range diagnostics {
+		if !d.Passed {
+			checks = append(checks, d.Check)
+		}
+	}
+
+	return
+}
+
+func TestDiagnosePassesForSolvableScenario(t *testing.T) {
+	is := is.New(t)
+	s := newVerificationScenario()
+	for pid := range 4 {
+		is.NoErr(s.Prioritize(ParticipantID(pid+1), []CourseID{1, 2}))
+	}
+
+	is.Equal(failedChecks(Diagnose(s)), nil)
+}
+
+func TestDiagnoseReportsMissingCapacityAsBlocking(t *testing.T) {
+	is := is.New(t)
+	s := EmptyScenario()
+	s.AddCourse(CourseData{ID: 1, Name: "foo", MinCapacity: 0, MaxCapacity: 1})
+	s.AddParticipant(ParticipantData{ID: 1})
+	s.AddParticipant(ParticipantData{ID: 2})
+	is.NoErr(s.Prioritize(1, []CourseID{1}))
+	is.NoErr(s.Prioritize(2, []CourseID{1}))
+
+	diagnostics := Diagnose(s)
+
+	is.True(!diagnostics[0].Passed)
+	is.True(diagnostics[0].Blocking)
+	is.Equal(len(failedChecks(diagnostics)), 1)
+}
+
+func TestDiagnoseIgnoresParticipantsWithOnlyFullPrioritiesForCapacity(t *testing.T) {
+	is := is.New(t)
+	s := EmptyScenario()
+	s.AddCourse(CourseData{ID: 1, Name: "foo", MinCapacity: 0, MaxCapacity: 1})
+	s.AddCourse(CourseData{ID: 2, Name: "bar", MinCapacity: 0, MaxCapacity: 1})
+	s.AddParticipant(ParticipantData{ID: 1})
+	s.AddParticipant(ParticipantData{ID: 2})
+	s.AddParticipant(ParticipantData{ID: 3})
+	is.NoErr(s.Prioritize(1, []CourseID{1}))
+	is.NoErr(s.Prioritize(2, []CourseID{1}))
+	is.NoErr(s.Prioritize(3, []CourseID{2}))
+	is.NoErr(s.Assign(1, 1))
+
+	diagnostics := Diagnose(s)
+
+	is.True(diagnostics[0].Passed)
+	is.Equal(failedChecks(diagnostics), []string{"Jeder nicht zugeteilte Teilnehmer hat eine Priorität mit freien Plätzen"})
+}
+
+func TestDiagnoseReportsNonBlockingIssues(t *testing.T) {
+	is := is.New(t)
+	s := newVerificationScenario()
+	is.NoErr(s.Prioritize(1, []CourseID{2}))
+	is.NoErr(s.Prioritize(2, []CourseID{2}))
+	is.NoErr(s.Assign(2, 2))
+
+	diagnostics := Diagnose(s)
+
+	is.Equal(failedChecks(diagnostics), []string{
+		"Alle nicht zugeteilten Teilnehmer haben Prioritäten",
+		"Jeder nicht zugeteilte Teilnehmer hat eine Priorität mit freien Plätzen",
+		"Alle Kurse haben genug Interessenten für ihre Mindestbelegung",
+	})
+	for _, d := range diagnostics {
+		is.True(!d.Blocking)
+	}
+}
@@ -0,0 +1,131 @@
+package domain
+
+// Occupant is a participant assigned to a course, together with how they prioritized it.
+type Occupant struct {
+	Participant ParticipantData
+	Level       PriorityLevel
+	// Prioritized is false if the occupant did not prioritize the course, in which case Level is 0.
+	Prioritized bool
+}
+
+// CourseExplanation describes a course the participant prioritized higher than the one they got.
+type CourseExplanation struct {
+	Course     CourseData
+	Level      PriorityLevel
+	Allocation int
+	Full       bool
+	Occupants  []Occupant
+}
+
+// Improvement is a change that would give the participant a course they prioritized higher, without making anyone
+// else worse off and without breaking capacity rules.
+type Improvement struct {
+	Course CourseData
+	Level  PriorityLevel
+	// Partner is the participant to swap courses with. If IsSwap is false, the participant can simply move.
+	Partner ParticipantData
+	IsSwap  bool
+}
+
+// Explanation answers why a participant got the course they got.
+type Explanation struct {
+	Participant ParticipantData
+	Assigned    CourseData
+	IsAssigned  bool
+	// AssignedLevel is the level with which the participant prioritized the assigned course or 0 if they did not.
+	AssignedLevel PriorityLevel
+	// HigherPriorities contains all prioritized courses that rank above the assigned one, ordered by level.
+	// For unassigned participants these are all prioritized courses.
+	HigherPriorities []CourseExplanation
+	Improvements     []Improvement
+}
+
+// Explain explains the assignment of the participant with the given ID.
+// It returns ErrNotFound if the participant is not part of the scenario.
+func Explain(s *Scenario, pid ParticipantID) (Explanation, error) {
+	participant, ok := s.participant(pid)
+	if !ok {
+		return Explanation{}, ErrNotFound
+	}
+
+	explanation := Explanation{Participant: *participant}
+	explanation.Assigned, explanation.IsAssigned = s.AssignedCourse(pid)
+	if explanation.IsAssigned {
+		explanation.AssignedLevel, _ = s.PriorityLevelOf(pid, explanation.Assigned.ID)
+	}
+
+	level := PriorityLevel(0)
+	for course := range s.PrioritizedCoursesOrdered(pid) {
+		level++
+		if explanation.IsAssigned && course.ID == explanation.Assigned.ID {
+			break
+		}
+
+		courseExplanation := s.explainCourse(course, level)
+		explanation.HigherPriorities = append(explanation.HigherPriorities, courseExplanation)
+		explanation.Improvements = append(explanation.Improvements, s.improvementsInto(explanation, courseExplanation)...)
+	}
+
+	return explanation, nil
+}
+
+func (s *Scenario) explainCourse(course CourseData, level PriorityLevel) CourseExplanation {
+	courseExplanation := CourseExplanation{Course: course, Level: level}
+
+	for _, occupant := range s.ParticipantsAssignedTo(course.ID) {
+		occupantLevel, prioritized := s.PriorityLevelOf(occupant.ID, course.ID)
+		courseExplanation.Occupants = append(courseExplanation.Occupants, Occupant{Participant: occupant, Level: occupantLevel, Prioritized: prioritized})
+	}
+
+	courseExplanation.Allocation = len(courseExplanation.Occupants)
+	courseExplanation.Full = courseExplanation.Allocation >= course.MaxCapacity
+
+	return courseExplanation
+}
+
+// improvementsInto lists the ways the explained participant could get into the higher prioritized course.
+func (s *Scenario) improvementsInto(explanation Explanation, target CourseExplanation) (improvements []Improvement) {
+	if !target.Full && s.canMove(explanation, target) {
+		improvements = append(improvements, Improvement{Course: target.Course, Level: target.Level})
+	}
+
+	// Unassigned participants have nothing to offer in a swap, the occupant would end up without a course.
+	if !explanation.IsAssigned {
+		return
+	}
+
+	for _, occupant := range target.Occupants {
+		if s.notWorseOff(occupant, explanation.Assigned.ID) {
+			improvements = append(improvements, Improvement{Course: target.Course, Level: target.Level, Partner: occupant.Participant, IsSwap: true})
+		}
+	}
+
+	return
+}
+
+// canMove reports whether the participant can leave their course for the target without breaking the minimum
+// capacity of either course.
+func (s *Scenario) canMove(explanation Explanation, target CourseExplanation) bool {
+	if target.Allocation+1 < target.Course.MinCapacity {
+		return false
+	}
+
+	if !explanation.IsAssigned {
+		return true
+	}
+
+	remaining := s.AllocationOf(explanation.Assigned.ID) - 1
+
+	return remaining == 0 || remaining >= explanation.Assigned.MinCapacity
+}
+
+// notWorseOff reports whether the occupant would be at least as happy in the offered course as in their current one.
+func (s *Scenario) notWorseOff(occupant Occupant, offered CourseID) bool {
+	offeredLevel, offeredPrioritized := s.PriorityLevelOf(occupant.Participant.ID, offered)
+
+	if !occupant.Prioritized {
+		return true
+	}
+
+	return offeredPrioritized && offeredLevel <= occupant.Level
+}
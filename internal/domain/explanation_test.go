@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestExplainListsHigherPrioritiesAndSwaps(t *testing.T) {
+	is := is.New(t)
+	s := newVerificationScenario()
+	is.NoErr(s.Prioritize(1, []CourseID{2, 1}))
+	is.NoErr(s.Prioritize(2, []CourseID{1, 2}))
+	is.NoErr(s.Prioritize(3, []CourseID{1}))
+	is.NoErr(s.Assign(1, 1))
+	is.NoErr(s.Assign(3, 1))
+	// Participant 2 would rather be in course 1, which they would gladly swap against.
+	is.NoErr(s.Assign(2, 2))
+
+	explanation, err := Explain(s, 1)
+	is.NoErr(err)
+
+	is.Equal(explanation.AssignedLevel, PriorityLevel(2))
+	is.Equal(len(explanation.HigherPriorities), 1)
+	is.Equal(explanation.HigherPriorities[0].Course.ID, CourseID(2))
+	is.True(explanation.HigherPriorities[0].Full)
+	is.Equal(explanation.HigherPriorities[0].Occupants, []Occupant{{Participant: ParticipantData{ID: 2}, Level: 2, Prioritized: true}})
+	is.Equal(explanation.Improvements, []Improvement{{Course: explanation.HigherPriorities[0].Course, Level: 1, Partner: ParticipantData{ID: 2}, IsSwap: true}})
+}
+
+func TestExplainOffersNoSwapThatMakesOthersWorseOff(t *testing.T) {
+	is := is.New(t)
+	s := newVerificationScenario()
+	is.NoErr(s.Prioritize(1, []CourseID{2, 1}))
+	is.NoErr(s.Prioritize(2, []CourseID{2, 1}))
+	is.NoErr(s.Assign(1, 1))
+	is.NoErr(s.Assign(3, 1))
+	is.NoErr(s.Assign(2, 2))
+
+	explanation, err := Explain(s, 1)
+	is.NoErr(err)
+
+	is.Equal(len(explanation.HigherPriorities), 1)
+	is.Equal(len(explanation.Improvements), 0)
+}
+
+func TestExplainUnknownParticipant(t *testing.T) {
+	is := is.New(t)
+
+	_, err := Explain(newVerificationScenario(), 42)
+
+	is.Equal(err, ErrNotFound)
+}
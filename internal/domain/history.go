@@ -0,0 +1,478 @@
+package domain
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"softbaer.dev/ass/internal/model"
+)
+
+// maxHistoryEntries limits how many actions can be undone, so that the history does not outgrow the scenario.
+const maxHistoryEntries = 100
+
+var (
+	ErrNothingToUndo = errors.New("nothing to undo")
+	ErrNothingToRedo = errors.New("nothing to redo")
+)
+
+type Action string
+
+const (
+	ActionAssign            Action = "assign"
+	ActionReassign          Action = "reassign"
+	ActionUnassign          Action = "unassign"
+	ActionCreateCourse      Action = "create-course"
+	ActionCreateParticipant Action = "create-participant"
+	ActionDeleteCourse      Action = "delete-course"
+	ActionDeleteParticipant Action = "delete-participant"
+	ActionSolve             Action = "solve"
+	ActionLoad              Action = "load"
+	ActionRestoreSnapshot   Action = "restore-snapshot"
+)
+
+var actionLabels = map[Action]string{
+	ActionAssign:            "Teilnehmer zugeteilt",
+	ActionReassign:          "Teilnehmer umgeteilt",
+	ActionUnassign:          "Zuteilung aufgehoben",
+	ActionCreateCourse:      "Kurs erstellt",
+	ActionCreateParticipant: "Teilnehmer erstellt",
+	ActionDeleteCourse:      "Kurs gelöscht",
+	ActionDeleteParticipant: "Teilnehmer gelöscht",
+	ActionSolve:             "Automatisch zugeteilt",
+	ActionLoad:              "Excel-Datei geladen",
+	ActionRestoreSnapshot:   "Version wiederhergestellt",
+}
+
+func (a Action) Label() string {
+	if label, ok := actionLabels[a]; ok {
+		return label
+	}
+
+	return string(a)
+}
+
+// HistoryEntry is an action as shown in the history.
+type HistoryEntry struct {
+	ID        int
+	Action    Action
+	CreatedAt time.Time
+	Summary   string
+	Undone    bool
+}
+
+// Scope names the rows an action can touch, so that Record only has to compare those instead of the whole scenario.
+// Besides the given courses and participants, it covers their priorities and the participants assigned to the given
+// courses. Actions that create rows add them to the scope in mutate, since their ids are not known before.
+type Scope struct {
+	CourseIDs      []CourseID
+	ParticipantIDs []ParticipantID
+	whole          bool
+}
+
+// WholeScenario is the scope of actions like solving, which can touch any row.
+func WholeScenario() *Scope {
+	return &Scope{whole: true}
+}
+
+func CourseScope(ids ...CourseID) *Scope {
+	return &Scope{CourseIDs: ids}
+}
+
+func ParticipantScope(ids ...ParticipantID) *Scope {
+	return &Scope{ParticipantIDs: ids}
+}
+
+func (s *Scope) AddCourse(id CourseID) {
+	s.CourseIDs = append(s.CourseIDs, id)
+}
+
+func (s *Scope) AddParticipant(id ParticipantID) {
+	s.ParticipantIDs = append(s.ParticipantIDs, id)
+}
+
+// capture returns the rows in scope. The rows captured before the action are passed as known, so that they are
+// captured again even if the action moved them out of scope, e.g. participants unassigned from a deleted course.
+func (s *Scope) capture(tx *gorm.DB, known rows) (rows, error) {
+	if s.whole {
+		return captureRows(tx)
+	}
+
+	courseIDs := append(slices.Collect(maps.Keys(known.Courses)), toInts(s.CourseIDs)...)
+	participantIDs := append(slices.Collect(maps.Keys(known.Participants)), toInts(s.ParticipantIDs)...)
+
+	return findRows(
+		tx.Where("id IN ?", courseIDs),
+		tx.Where("id IN ? OR course_id IN ?", participantIDs, s.CourseIDs),
+		tx.Where("id IN ? OR participant_id IN ? OR course_id IN ?", slices.Collect(maps.Keys(known.Priorities)), s.ParticipantIDs, s.CourseIDs),
+	)
+}
+
+func toInts[T ~int](ids []T) []int {
+	result := make([]int, len(ids))
+	for i, id := range ids {
+		result[i] = int(id)
+	}
+
+	return result
+}
+
+// Record runs mutate and appends the rows it changed to the history, so that the action can be undone later. Only
+// rows in scope are compared, so the scope has to cover every row mutate touches. Like in any editor, recording an
+// action discards the actions that were undone before. Parameter tx should be a transaction, so that the change and
+// its history entry are saved together.
+func Record(tx *gorm.DB, action Action, scope *Scope, mutate func(tx *gorm.DB) error) error {
+	before, err := scope.capture(tx, rows{})
+	if err != nil {
+		return err
+	}
+
+	if err := mutate(tx); err != nil {
+		return err
+	}
+
+	after, err := scope.capture(tx, before)
+	if err != nil {
+		return err
+	}
+
+	changes := diffRows(before, after)
+	if changes.empty() {
+		return nil
+	}
+
+	changesJson, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Where("undone = ?", true).Delete(&model.HistoryEntry{}).Error; err != nil {
+		return err
+	}
+
+	entry := model.HistoryEntry{Action: string(action), Summary: changes.summary(), Changes: string(changesJson)}
+	if err := tx.Create(&entry).Error; err != nil {
+		return err
+	}
+
+	return tx.Where("id <= ?", entry.ID-maxHistoryEntries).Delete(&model.HistoryEntry{}).Error
+}
+
+// Undo reverts the latest action that was not undone yet and returns it.
+func Undo(tx *gorm.DB) (HistoryEntry, error) {
+	var entry model.HistoryEntry
+	err := tx.Where("undone = ?", false).Order("id desc").Take(&entry).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return HistoryEntry{}, ErrNothingToUndo
+	}
+	if err != nil {
+		return HistoryEntry{}, err
+	}
+
+	return replay(tx, entry, true)
+}
+
+// Redo applies the earliest undone action again and returns it.
+func Redo(tx *gorm.DB) (HistoryEntry, error) {
+	var entry model.HistoryEntry
+	err := tx.Where("undone = ?", true).Order("id asc").Take(&entry).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return HistoryEntry{}, ErrNothingToRedo
+	}
+	if err != nil {
+		return HistoryEntry{}, err
+	}
+
+	return replay(tx, entry, false)
+}
+
+// History returns all entries, newest first. Undone entries are included, since they can still be redone.
+func History(db *gorm.DB) ([]HistoryEntry, error) {
+	var entries []model.HistoryEntry
+	if err := db.Select("id", "created_at", "action", "summary", "undone").Order("id desc").Find(&entries).Error; err != nil {
+		return nil, err
+	}
+
+	result := make([]HistoryEntry, len(entries))
+	for i, entry := range entries {
+		result[i] = historyEntryFromDbModel(entry)
+	}
+
+	return result, nil
+}
+
+func replay(tx *gorm.DB, entry model.HistoryEntry, undo bool) (HistoryEntry, error) {
+	var changes changeSet
+	if err := json.Unmarshal([]byte(entry.Changes), &changes); err != nil {
+		return HistoryEntry{}, fmt.Errorf("history entry %d is unreadable: %w", entry.ID, err)
+	}
+
+	if err := changes.apply(tx, undo); err != nil {
+		return HistoryEntry{}, err
+	}
+
+	entry.Undone = undo
+	if err := tx.Model(&entry).Update("undone", undo).Error; err != nil {
+		return HistoryEntry{}, err
+	}
+
+	return historyEntryFromDbModel(entry), nil
+}
+
+func historyEntryFromDbModel(entry model.HistoryEntry) HistoryEntry {
+	return HistoryEntry{
+		ID:        entry.ID,
+		Action:    Action(entry.Action),
+		CreatedAt: entry.CreatedAt,
+		Summary:   entry.Summary,
+		Undone:    entry.Undone,
+	}
+}
+
+// The rows are stored with their plain columns, so that the history does not change with the model structs. Names stay
+// encrypted.
+type courseRow struct {
+	EncryptedName string
+	NameIndex     string
+	// PlainName is only found in entries recorded before course names were encrypted, until BindCiphertexts
+	// encrypts it.
+	PlainName   string `json:"Name,omitempty"`
+	MaxCapacity int
+	MinCapacity int
+}
+
+type participantRow struct {
+	EncryptedPrename string
+	EncryptedSurname string
+	CourseID         sql.NullInt64
+}
+
+type priorityRow struct {
+	Level         model.PriorityLevel
+	CourseID      int
+	ParticipantID int
+}
+
+// rows holds the content of all tables by id. It is stored as JSON in snapshots.
+type rows struct {
+	Courses      map[int]courseRow
+	Participants map[int]participantRow
+	Priorities   map[int]priorityRow
+}
+
+func captureRows(tx *gorm.DB) (rows, error) {
+	return findRows(tx, tx, tx)
+}
+
+// findRows captures the rows the given queries find in the respective tables.
+func findRows(courseQuery, participantQuery, priorityQuery *gorm.DB) (rows, error) {
+	var courses []model.Course
+	var participants []model.Participant
+	var priorities []model.Priority
+
+	if err := courseQuery.Find(&courses).Error; err != nil {
+		return rows{}, err
+	}
+	if err := participantQuery.Find(&participants).Error; err != nil {
+		return rows{}, err
+	}
+	if err := priorityQuery.Find(&priorities).Error; err != nil {
+		return rows{}, err
+	}
+
+	result := rows{
+		Courses:      make(map[int]courseRow, len(courses)),
+		Participants: make(map[int]participantRow, len(participants)),
+		Priorities:   make(map[int]priorityRow, len(priorities)),
+	}
+	for _, c := range courses {
+		result.Courses[c.ID] = courseRow{EncryptedName: c.EncryptedName, NameIndex: c.NameIndex, MaxCapacity: c.MaxCapacity, MinCapacity: c.MinCapacity}
+	}
+	for _, p := range participants {
+		result.Participants[p.ID] = participantRow{EncryptedPrename: p.EncryptedPrename, EncryptedSurname: p.EncryptedSurname, CourseID: p.CourseID}
+	}
+	for _, p := range priorities {
+		result.Priorities[int(p.ID)] = priorityRow{Level: p.Level, CourseID: p.CourseID, ParticipantID: p.ParticipantID}
+	}
+
+	return result, nil
+}
+
+// change holds the images of a row before and after an action. A nil image means that the row did not exist.
+type change[T any] struct {
+	ID     int
+	Before *T `json:",omitempty"`
+	After  *T `json:",omitempty"`
+}
+
+// target returns the image the row has to be restored to.
+func (c change[T]) target(undo bool) *T {
+	if undo {
+		return c.Before
+	}
+
+	return c.After
+}
+
+// current returns the image the row has when the change is replayed.
+func (c change[T]) current(undo bool) *T {
+	return c.target(!undo)
+}
+
+type changeSet struct {
+	Courses      []change[courseRow]      `json:",omitempty"`
+	Participants []change[participantRow] `json:",omitempty"`
+	Priorities   []change[priorityRow]    `json:",omitempty"`
+}
+
+func diffRows(before, after rows) changeSet {
+	return changeSet{
+		Courses:      diffTable(before.Courses, after.Courses),
+		Participants: diffTable(before.Participants, after.Participants),
+		Priorities:   diffTable(before.Priorities, after.Priorities),
+	}
+}
+
+func diffTable[T comparable](before, after map[int]T) []change[T] {
+	ids := slices.Sorted(maps.Keys(before))
+	for id := range after {
+		if _, ok := before[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+
+	var changes []change[T]
+	for _, id := range ids {
+		b, inBefore := before[id]
+		a, inAfter := after[id]
+
+		if inBefore && inAfter && a == b {
+			continue
+		}
+
+		c := change[T]{ID: id}
+		if inBefore {
+			c.Before = &b
+		}
+		if inAfter {
+			c.After = &a
+		}
+		changes = append(changes, c)
+	}
+
+	return changes
+}
+
+func (cs changeSet) empty() bool {
+	return len(cs.Courses) == 0 && len(cs.Participants) == 0 && len(cs.Priorities) == 0
+}
+
+func (cs changeSet) summary() string {
+	var parts []string
+	if n := len(cs.Participants); n > 0 {
+		parts = append(parts, fmt.Sprintf("%d Teilnehmer", n))
+	}
+	if n := len(cs.Courses); n > 0 {
+		parts = append(parts, pluralize(n, "Kurs", "Kurse"))
+	}
+	if n := len(cs.Priorities); n > 0 {
+		parts = append(parts, pluralize(n, "Priorität", "Prioritäten"))
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+func pluralize(n int, singular, plural string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, singular)
+	}
+
+	return fmt.Sprintf("%d %s", n, plural)
+}
+
+// apply restores the images before the action if undo is set and the images after it otherwise. Rows are written
+// parents first and removed children first, so that foreign keys hold at every step.
+func (cs changeSet) apply(tx *gorm.DB, undo bool) error {
+	for _, c := range cs.Courses {
+		target := c.target(undo)
+		if target == nil {
+			continue
+		}
+
+		course := target.dbModel(c.ID)
+		columns := map[string]any{"encrypted_name": target.EncryptedName, "name_index": target.NameIndex, "max_capacity": target.MaxCapacity, "min_capacity": target.MinCapacity}
+		if err := upsert(tx, c.current(undo) == nil, &course, columns); err != nil {
+			return err
+		}
+	}
+
+	for _, c := range cs.Participants {
+		target := c.target(undo)
+		if target == nil {
+			continue
+		}
+
+		participant := target.dbModel(c.ID)
+		if err := upsert(tx, c.current(undo) == nil, &participant, map[string]any{"encrypted_prename": target.EncryptedPrename, "encrypted_surname": target.EncryptedSurname, "course_id": target.CourseID}); err != nil {
+			return err
+		}
+	}
+
+	for _, c := range cs.Priorities {
+		target := c.target(undo)
+		if target == nil {
+			continue
+		}
+
+		priority := model.Priority{Level: target.Level, CourseID: target.CourseID, ParticipantID: target.ParticipantID}
+		priority.ID = uint(c.ID)
+		if err := upsert(tx, c.current(undo) == nil, &priority, map[string]any{"level": target.Level, "course_id": target.CourseID, "participant_id": target.ParticipantID}); err != nil {
+			return err
+		}
+	}
+
+	for _, c := range cs.Priorities {
+		if c.target(undo) == nil {
+			if err := tx.Unscoped().Delete(&model.Priority{}, c.ID).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, c := range cs.Participants {
+		if c.target(undo) == nil {
+			if err := tx.Unscoped().Where("id = ?", c.ID).Delete(model.EmptyParticipantPointer()).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, c := range cs.Courses {
+		if c.target(undo) == nil {
+			if err := tx.Unscoped().Delete(&model.Course{ID: c.ID}).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// upsert inserts the record if it does not exist yet and updates the columns otherwise. The columns are passed
+// separately, since gorm skips zero values when updating from a struct.
+func upsert(tx *gorm.DB, insert bool, record any, columns map[string]any) error {
+	if insert {
+		// The models carry empty associations, which must not be saved along with them.
+		return tx.Omit(clause.Associations).Create(record).Error
+	}
+
+	return tx.Model(record).Updates(columns).Error
+}
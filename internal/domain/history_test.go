@@ -0,0 +1,74 @@
+package domain
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/matryer/is"
+	"gorm.io/gorm"
+	"softbaer.dev/ass/internal/crypt"
+	"softbaer.dev/ass/internal/dbdir"
+	"softbaer.dev/ass/internal/model"
+)
+
+func setupHistoryDb(t *testing.T, secret crypt.Secret) *gorm.DB {
+	is := is.New(t)
+	db, err := dbdir.NewDb(fmt.Sprintf("%s/history.sqlite", t.TempDir()), nil)
+	is.NoErr(err)
+	is.NoErr(dbdir.Migrate(db, model.Migrations))
+
+	_, err = CreateCourse(db, CourseData{Name: "foo", MinCapacity: 0, MaxCapacity: 5}, secret)
+	is.NoErr(err)
+	for id := range 2 {
+		participant, err := model.NewParticipant("Ada", "Lovelace", secret, model.WithParticipantId(id+1))
+		is.NoErr(err)
+		is.NoErr(db.Create(&participant).Error)
+	}
+
+	return db
+}
+
+func TestRecordComparesOnlyRowsInScope(t *testing.T) {
+	is := is.New(t)
+	db := setupHistoryDb(t, crypt.GenerateSecret())
+
+	err := Record(db, ActionAssign, ParticipantScope(1), func(tx *gorm.DB) error {
+		if err := InitialAssign(tx, 1, 1); err != nil {
+			return err
+		}
+
+		return InitialAssign(tx, 2, 1)
+	})
+	is.NoErr(err)
+
+	entries, err := History(db)
+	is.NoErr(err)
+	is.Equal(len(entries), 1)
+	is.Equal(entries[0].Summary, "1 Teilnehmer") // want only the participant in scope to be recorded
+
+	_, err = Undo(db)
+	is.NoErr(err)
+	allocation, err := CountAllocation(db, 1)
+	is.NoErr(err)
+	is.Equal(allocation, 1) // want the participant outside of the scope to stay assigned
+}
+
+func TestRecordCoversRowsAddedToScope(t *testing.T) {
+	is := is.New(t)
+	secret := crypt.GenerateSecret()
+	db := setupHistoryDb(t, secret)
+
+	scope := CourseScope()
+	err := Record(db, ActionCreateCourse, scope, func(tx *gorm.DB) error {
+		created, err := CreateCourse(tx, CourseData{Name: "bar", MinCapacity: 0, MaxCapacity: 5}, secret)
+		scope.AddCourse(created.ID)
+		return err
+	})
+	is.NoErr(err)
+
+	_, err = Undo(db)
+	is.NoErr(err)
+	var courses int64
+	is.NoErr(db.Model(&model.Course{}).Count(&courses).Error)
+	is.Equal(courses, int64(1)) // want undo to remove the created course
+}
@@ -46,17 +46,19 @@ func (pc *ParticipantCandidate) Save(db *gorm.DB, secret crypt.Secret) (Particip
 	if pc.isAssigned {
 		courseId = model.WithSomeCourseId(int64(pc.assignedCourseId))
 	}
-	dbModel, err := model.NewParticipant(
-		pc.Prename,
-		pc.Surname,
-		secret,
-		courseId,
-	)
-	if err != nil {
+	dbModel := model.Participant{}
+	courseId(&dbModel)
+
+	// The names are bound to the id of the participant, which is only known after the insert.
+	if err := db.Create(&dbModel).Error; err != nil {
 		return Participant{}, err
 	}
 
-	if err := db.Create(&dbModel).Error; err != nil {
+	if err := dbModel.SetNames(pc.Prename, pc.Surname, secret); err != nil {
+		return Participant{}, err
+	}
+
+	if err := db.Model(&dbModel).Updates(map[string]any{"encrypted_prename": dbModel.EncryptedPrename, "encrypted_surname": dbModel.EncryptedSurname}).Error; err != nil {
 		return Participant{}, err
 	}
 
@@ -78,7 +80,7 @@ func (pc *ParticipantCandidate) Save(db *gorm.DB, secret crypt.Secret) (Particip
 		ParticipantData: savedData,
 	}
 
-	result.PrioritizedCourses, err = findCourseDataById(db, pc.prioritizedCourseIds)
+	result.PrioritizedCourses, err = findCourseDataById(db, pc.prioritizedCourseIds, secret)
 	if err != nil {
 		return Participant{}, err
 	}
@@ -89,7 +91,10 @@ func (pc *ParticipantCandidate) Save(db *gorm.DB, secret crypt.Secret) (Particip
 			return Participant{}, err
 		}
 
-		result.assignedCourse = courseFromDbModel(assignedCourseRow)
+		result.assignedCourse, err = courseFromDbModel(assignedCourseRow, secret)
+		if err != nil {
+			return Participant{}, err
+		}
 	}
 
 	return result, nil
@@ -14,13 +14,13 @@ var (
 	ErrCourseNotFound      = errors.New("course not found")
 )
 
-func FindAssignedCourse(db *gorm.DB, pid ParticipantID) (CourseData, error) {
+func FindAssignedCourse(db *gorm.DB, pid ParticipantID, secret crypt.Secret) (CourseData, error) {
 	var courseID int64
 	if err := db.Model(model.EmptyParticipantPointer()).Where("id = ?", pid).Select("course_id").First(&courseID).Error; err != nil {
 		return CourseData{}, err
 	}
 
-	return FindSingleCourseData(db, CourseID(courseID))
+	return FindSingleCourseData(db, CourseID(courseID), secret)
 }
 
 func InitialAssign(tx *gorm.DB, pid ParticipantID, cid CourseID) error {
@@ -88,18 +88,18 @@ func DeleteParticipant(tx *gorm.DB, ParticipantID ParticipantID) error {
 }
 
 func participantDataFromDbModel(dbModel model.Participant, secret crypt.Secret) (ParticipantData, error) {
-	encryptName := encryptedParticipantName{
-		Prename: dbModel.EncryptedPrename,
-		Surname: dbModel.EncryptedSurname,
+	prename, err := dbModel.Prename(secret)
+	if err != nil {
+		return ParticipantData{}, err
 	}
-	decryptedName, err := encryptName.decrypt(secret)
+	surname, err := dbModel.Surname(secret)
 	if err != nil {
 		return ParticipantData{}, err
 	}
 
 	return ParticipantData{
 		ID:              ParticipantID(dbModel.ID),
-		ParticipantName: decryptedName,
+		ParticipantName: ParticipantName{Prename: prename, Surname: surname},
 	}, nil
 }
 
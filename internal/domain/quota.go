@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+	"softbaer.dev/ass/internal/model"
+)
+
+// Quota limits how much a single session can store. Zero values mean no limit.
+type Quota struct {
+	MaxParticipants int
+	MaxCourses      int
+	// MaxDbBytes limits the size of the session's db as reported by sqlite.
+	MaxDbBytes int64
+}
+
+// QuotaExceededError is returned if a change would make a session store more than its quota allows.
+// Its message is meant to be shown to the user.
+type QuotaExceededError struct {
+	message string
+}
+
+func (e QuotaExceededError) Error() string {
+	return e.message
+}
+
+// Check returns a QuotaExceededError if the data in db exceeds the quota. It is meant to be called inside the
+// transaction that adds data, after the data was added, so that exceeding changes can be rolled back.
+func (q Quota) Check(db *gorm.DB) error {
+	if q.MaxParticipants > 0 {
+		var count int64
+		if err := db.Model(model.EmptyParticipantPointer()).Count(&count).Error; err != nil {
+			return err
+		}
+
+		if count > int64(q.MaxParticipants) {
+			return QuotaExceededError{fmt.Sprintf("Ein Szenario darf höchstens %d Teilnehmer enthalten.", q.MaxParticipants)}
+		}
+	}
+
+	if q.MaxCourses > 0 {
+		var count int64
+		if err := db.Model(&model.Course{}).Count(&count).Error; err != nil {
+			return err
+		}
+
+		if count > int64(q.MaxCourses) {
+			return QuotaExceededError{fmt.Sprintf("Ein Szenario darf höchstens %d Kurse enthalten.", q.MaxCourses)}
+		}
+	}
+
+	if q.MaxDbBytes > 0 {
+		var size int64
+		if err := db.Raw("SELECT page_count * page_size FROM pragma_page_count(), pragma_page_size()").Scan(&size).Error; err != nil {
+			return err
+		}
+
+		if size > q.MaxDbBytes {
+			return QuotaExceededError{fmt.Sprintf("Die Daten eines Szenarios dürfen höchstens %.1f MB belegen.", float64(q.MaxDbBytes)/1e6)}
+		}
+	}
+
+	return nil
+}
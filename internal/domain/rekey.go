@@ -0,0 +1,124 @@
+package domain
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"softbaer.dev/ass/internal/crypt"
+	"softbaer.dev/ass/internal/model"
+)
+
+// RekeySession rekeys the session in a transaction, see Rekey. It commits only after storeSecret kept the new secret,
+// e.g. in the session cookie. If that fails, the data stays encrypted with oldSecret, so that it remains readable.
+func RekeySession(db *gorm.DB, dbId uuid.UUID, oldSecret, newSecret crypt.Secret, passphrase string, storeSecret func(crypt.Secret) error) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := Rekey(tx, dbId, oldSecret, newSecret, passphrase); err != nil {
+			return err
+		}
+
+		return storeSecret(newSecret)
+	})
+}
+
+// Rekey re-encrypts all names of the session, which are encrypted with oldSecret, with newSecret. This includes the
+// history, the snapshots and the wrapped secret of a passphrase-protected session, which requires its passphrase. It
+// fails with crypt.ErrWrongPassphrase if the passphrase does not match. Parameter tx should be a transaction, since
+// a partly re-encrypted session can not be read with either secret.
+func Rekey(tx *gorm.DB, dbId uuid.UUID, oldSecret, newSecret crypt.Secret, passphrase string) error {
+	protected, err := HasPassphrase(tx)
+	if err != nil {
+		return err
+	}
+	if protected {
+		if _, err := UnlockSecret(tx, dbId, passphrase); err != nil {
+			return err
+		}
+		if err := SetPassphrase(tx, dbId, newSecret, passphrase); err != nil {
+			return err
+		}
+	}
+
+	var participants []model.Participant
+	if err := tx.Unscoped().Find(&participants).Error; err != nil {
+		return err
+	}
+	for _, participant := range participants {
+		if err := participant.Rekey(oldSecret, newSecret); err != nil {
+			return err
+		}
+		if err := updateParticipantNames(tx, participant); err != nil {
+			return err
+		}
+	}
+
+	var courses []model.Course
+	if err := tx.Unscoped().Find(&courses).Error; err != nil {
+		return err
+	}
+	for _, course := range courses {
+		if err := course.Rekey(oldSecret, newSecret); err != nil {
+			return err
+		}
+		if err := tx.Model(&course).Updates(map[string]any{"encrypted_name": course.EncryptedName, "name_index": course.NameIndex}).Error; err != nil {
+			return err
+		}
+	}
+
+	rekey := rowRewrite{
+		participant: func(id int, row *participantRow) error {
+			if row == nil {
+				return nil
+			}
+
+			participant := row.dbModel(id)
+			if err := participant.Rekey(oldSecret, newSecret); err != nil {
+				return err
+			}
+			row.EncryptedPrename, row.EncryptedSurname = participant.EncryptedPrename, participant.EncryptedSurname
+
+			return nil
+		},
+		course: func(id int, row *courseRow) error {
+			if row == nil {
+				return nil
+			}
+
+			course := row.dbModel(id)
+			if err := course.Rekey(oldSecret, newSecret); err != nil {
+				return err
+			}
+			row.EncryptedName, row.NameIndex = course.EncryptedName, course.NameIndex
+
+			return nil
+		},
+	}
+
+	return rekey.apply(tx)
+}
+
+// VerifySecret checks that the secret decrypts the data of the session, e.g. because it might be outdated by Rekey.
+// It fails with crypt.ErrTampered if it does not. Sessions without data accept every secret.
+func VerifySecret(db *gorm.DB, secret crypt.Secret) error {
+	var participant model.Participant
+	err := db.Unscoped().Take(&participant).Error
+	if err == nil {
+		_, err = participant.Prename(secret)
+		return err
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	var course model.Course
+	err = db.Unscoped().Take(&course).Error
+	if err == nil {
+		_, err = course.Name(secret)
+		return err
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+
+	return err
+}
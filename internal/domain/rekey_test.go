@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/matryer/is"
+	"softbaer.dev/ass/internal/crypt"
+	"softbaer.dev/ass/internal/dbdir"
+	"softbaer.dev/ass/internal/model"
+)
+
+func TestRekeySessionCommitsOnlyAfterSecretIsStored(t *testing.T) {
+	errStore := errors.New("cookie could not be saved")
+
+	testcases := []struct {
+		name       string
+		storeErr   error
+		wantNewKey bool
+	}{
+		{"Stored secret", nil, true},
+		{"Failed store", errStore, false},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			is := is.New(t)
+			db, err := dbdir.NewDb(fmt.Sprintf("%s/rekey.sqlite", t.TempDir()), nil)
+			is.NoErr(err)
+			is.NoErr(dbdir.Migrate(db, model.Migrations))
+
+			oldSecret, newSecret := crypt.GenerateSecret(), crypt.GenerateSecret()
+			ada, err := model.NewParticipant("Ada", "Lovelace", oldSecret, model.WithParticipantId(1))
+			is.NoErr(err)
+			is.NoErr(db.Create(&ada).Error)
+
+			var stored crypt.Secret
+			err = RekeySession(db, uuid.New(), oldSecret, newSecret, "", func(secret crypt.Secret) error {
+				stored = secret
+				return tc.storeErr
+			})
+			is.True(errors.Is(err, tc.storeErr))
+			is.Equal(stored, newSecret) // want the new secret to be stored before committing
+
+			readableWith := oldSecret
+			if tc.wantNewKey {
+				readableWith = newSecret
+			}
+			scenario, err := LoadScenario(db, readableWith)
+			is.NoErr(err) // want the data to be readable with the secret the cookie holds
+			for participant := range scenario.AllParticipants() {
+				is.Equal(participant.Prename, "Ada")
+			}
+		})
+	}
+}
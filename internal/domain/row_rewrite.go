@@ -0,0 +1,113 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"gorm.io/gorm"
+	"softbaer.dev/ass/internal/model"
+)
+
+// rowRewrite changes the images of rows that are stored as JSON in the history and in snapshots, e.g. to encrypt
+// them differently. The functions must leave nil rows as they are, since they stand for rows that did not exist.
+type rowRewrite struct {
+	participant func(id int, row *participantRow) error
+	course      func(id int, row *courseRow) error
+}
+
+func (rw rowRewrite) apply(tx *gorm.DB) error {
+	if err := rw.history(tx); err != nil {
+		return err
+	}
+
+	return rw.snapshots(tx)
+}
+
+func (rw rowRewrite) history(tx *gorm.DB) error {
+	var entries []model.HistoryEntry
+	if err := tx.Find(&entries).Error; err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		var changes changeSet
+		if err := json.Unmarshal([]byte(entry.Changes), &changes); err != nil {
+			return fmt.Errorf("history entry %d is unreadable: %w", entry.ID, err)
+		}
+
+		for _, c := range changes.Courses {
+			for _, image := range []*courseRow{c.Before, c.After} {
+				if err := rw.course(c.ID, image); err != nil {
+					return fmt.Errorf("history entry %d: %w", entry.ID, err)
+				}
+			}
+		}
+		for _, c := range changes.Participants {
+			for _, image := range []*participantRow{c.Before, c.After} {
+				if err := rw.participant(c.ID, image); err != nil {
+					return fmt.Errorf("history entry %d: %w", entry.ID, err)
+				}
+			}
+		}
+
+		changesJson, err := json.Marshal(changes)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&entry).Update("changes", string(changesJson)).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (rw rowRewrite) snapshots(tx *gorm.DB) error {
+	var snapshots []model.Snapshot
+	if err := tx.Find(&snapshots).Error; err != nil {
+		return err
+	}
+
+	for _, snapshot := range snapshots {
+		var content rows
+		if err := json.Unmarshal([]byte(snapshot.Rows), &content); err != nil {
+			return fmt.Errorf("snapshot %d is unreadable: %w", snapshot.ID, err)
+		}
+
+		for id, row := range content.Courses {
+			if err := rw.course(id, &row); err != nil {
+				return fmt.Errorf("snapshot %d: %w", snapshot.ID, err)
+			}
+			content.Courses[id] = row
+		}
+		for id, row := range content.Participants {
+			if err := rw.participant(id, &row); err != nil {
+				return fmt.Errorf("snapshot %d: %w", snapshot.ID, err)
+			}
+			content.Participants[id] = row
+		}
+
+		rowsJson, err := json.Marshal(content)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&snapshot).Update("rows", string(rowsJson)).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// updateParticipantNames saves the encrypted names of the participant.
+func updateParticipantNames(tx *gorm.DB, participant model.Participant) error {
+	return tx.Model(&participant).Updates(map[string]any{"encrypted_prename": participant.EncryptedPrename, "encrypted_surname": participant.EncryptedSurname}).Error
+}
+
+func (r participantRow) dbModel(id int) model.Participant {
+	return model.Participant{ID: id, EncryptedPrename: r.EncryptedPrename, EncryptedSurname: r.EncryptedSurname, CourseID: r.CourseID}
+}
+
+func (r courseRow) dbModel(id int) model.Course {
+	return model.Course{ID: id, EncryptedName: r.EncryptedName, NameIndex: r.NameIndex, MaxCapacity: r.MaxCapacity, MinCapacity: r.MinCapacity}
+}
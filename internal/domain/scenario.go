@@ -15,6 +15,8 @@ type Scenario struct {
 	participants    []ParticipantData
 	assignmentTable map[ParticipantID]*CourseData
 	priorityTable   map[ParticipantID][]*CourseData
+	// storedLevels holds the levels of the priorities as loaded from the db, see StoredPriorityLevelOf.
+	storedLevels map[ParticipantID]map[CourseID]PriorityLevel
 }
 
 func EmptyScenario() *Scenario {
@@ -23,6 +25,7 @@ func EmptyScenario() *Scenario {
 		participants:    make([]ParticipantData, 0),
 		assignmentTable: make(map[ParticipantID]*CourseData),
 		priorityTable:   make(map[ParticipantID][]*CourseData),
+		storedLevels:    make(map[ParticipantID]map[CourseID]PriorityLevel),
 	}
 }
 
@@ -100,6 +103,7 @@ func (s *Scenario) Prioritize(pid ParticipantID, cids []CourseID) error {
 		prioCourses = append(prioCourses, c)
 	}
 	s.priorityTable[pid] = prioCourses
+	delete(s.storedLevels, pid)
 	return nil
 }
 
@@ -208,6 +212,29 @@ func (s *Scenario) PrioritizedCoursesOrdered(pid ParticipantID) iter.Seq[CourseD
 	}
 }
 
+// PriorityLevelOf returns the level with which the participant prioritized the course.
+// The second return value is false, if the participant did not prioritize the course at all.
+func (s *Scenario) PriorityLevelOf(pid ParticipantID, cid CourseID) (PriorityLevel, bool) {
+	for i, course := range s.priorityTable[pid] {
+		if course.ID == cid {
+			return PriorityLevel(i + 1), true
+		}
+	}
+
+	return 0, false
+}
+
+// StoredPriorityLevelOf returns the level with which the priority is stored in the db. Deleting a course leaves gaps
+// in the stored levels (e.g. 1, 3), so they can be higher than the ones of PriorityLevelOf. Priorities that were not
+// loaded from the db or changed since have the level of PriorityLevelOf.
+func (s *Scenario) StoredPriorityLevelOf(pid ParticipantID, cid CourseID) (PriorityLevel, bool) {
+	if level, ok := s.storedLevels[pid][cid]; ok {
+		return level, true
+	}
+
+	return s.PriorityLevelOf(pid, cid)
+}
+
 func (s *Scenario) MaxAmountOfPriorities() (result int) {
 	for _, courses := range s.priorityTable {
 		count := len(courses)
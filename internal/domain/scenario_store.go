@@ -8,10 +8,10 @@ import (
 	"softbaer.dev/ass/internal/model"
 )
 
-func LoadScenario(db *gorm.DB, secret crypt.Secret) (scenario *Scenario, err error) {
-	scenario = EmptyScenario()
+func LoadScenario(db *gorm.DB, secret crypt.Secret) (*Scenario, error) {
 	var participants []model.Participant
 	var courses []model.Course
+	var priorities []model.Priority
 
 	if err := db.Find(&participants).Error; err != nil {
 		return nil, err
@@ -19,10 +19,23 @@ func LoadScenario(db *gorm.DB, secret crypt.Secret) (scenario *Scenario, err err
 	if err := db.Find(&courses).Error; err != nil {
 		return nil, err
 	}
+	if err := db.Order("participant_id, level").Find(&priorities).Error; err != nil {
+		return nil, err
+	}
+
+	return scenarioFromDbModels(participants, courses, priorities, secret)
+}
+
+// scenarioFromDbModels builds a scenario from rows. The priorities have to be ordered by participant and level.
+func scenarioFromDbModels(participants []model.Participant, courses []model.Course, priorities []model.Priority, secret crypt.Secret) (scenario *Scenario, err error) {
+	scenario = EmptyScenario()
+
 	if scenario.participants, err = participantsFromDbModel(participants, secret); err != nil {
 		return nil, err
 	}
-	scenario.courses = coursesFromDbModels(courses)
+	if scenario.courses, err = coursesFromDbModels(courses, secret); err != nil {
+		return nil, err
+	}
 
 	for _, participant := range participants {
 		if participant.CourseID.Valid {
@@ -32,9 +45,6 @@ func LoadScenario(db *gorm.DB, secret crypt.Secret) (scenario *Scenario, err err
 		}
 	}
 
-	var priorities []model.Priority
-	db.Find(&priorities)
-
 	priosPerParticipantId := make(map[int][]int)
 	for _, prio := range priorities {
 		priosPerParticipantId[prio.ParticipantID] = append(priosPerParticipantId[prio.ParticipantID], prio.CourseID)
@@ -46,10 +56,20 @@ func LoadScenario(db *gorm.DB, secret crypt.Secret) (scenario *Scenario, err err
 		}
 	}
 
+	for _, prio := range priorities {
+		pid := ParticipantID(prio.ParticipantID)
+		if scenario.storedLevels[pid] == nil {
+			scenario.storedLevels[pid] = make(map[CourseID]PriorityLevel)
+		}
+		scenario.storedLevels[pid][CourseID(prio.CourseID)] = PriorityLevel(prio.Level)
+	}
+
 	return
 }
 
-func OverwriteScenario(db *gorm.DB, scenario *Scenario, secret crypt.Secret) error {
+// OverwriteScenario replaces the data in db with the scenario. It fails with a QuotaExceededError if the scenario does
+// not fit into the quota, in which case the transaction db belongs to has to be rolled back.
+func OverwriteScenario(db *gorm.DB, scenario *Scenario, secret crypt.Secret, quota Quota) error {
 	tablesToDelete := []any{
 		&model.Priority{},
 		model.EmptyParticipantPointer(),
@@ -62,7 +82,10 @@ func OverwriteScenario(db *gorm.DB, scenario *Scenario, secret crypt.Secret) err
 
 	}
 
-	courseRecords := coursesToDbModels(scenario.courses)
+	courseRecords, err := coursesToDbModels(scenario.courses, secret)
+	if err != nil {
+		return err
+	}
 	db.CreateInBatches(courseRecords, 100)
 
 	participantRecords, err := scenario.allParticipantsAsDbModels(secret)
@@ -77,5 +100,5 @@ func OverwriteScenario(db *gorm.DB, scenario *Scenario, secret crypt.Secret) err
 		return err
 	}
 
-	return nil
+	return quota.Check(db)
 }
@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"softbaer.dev/ass/internal/crypt"
+	"softbaer.dev/ass/internal/model"
+)
+
+var ErrNoPassphrase = errors.New("session is not protected by a passphrase")
+
+// HasPassphrase reports whether the secret of the session is protected by a passphrase.
+func HasPassphrase(db *gorm.DB) (bool, error) {
+	var count int64
+	err := db.Model(&model.SessionKey{}).Count(&count).Error
+
+	return count > 0, err
+}
+
+// SetPassphrase protects the secret with the passphrase by storing it wrapped in the db. An existing passphrase is
+// replaced. The db id is bound to the wrapped secret, so that it can not be copied into another session's db.
+func SetPassphrase(tx *gorm.DB, dbId uuid.UUID, secret crypt.Secret, passphrase string) error {
+	wrapped, err := crypt.WrapSecret(secret, passphrase, dbId[:])
+	if err != nil {
+		return err
+	}
+
+	if err := RemovePassphrase(tx); err != nil {
+		return err
+	}
+
+	return tx.Create(&model.SessionKey{Wrapped: wrapped}).Error
+}
+
+// RemovePassphrase drops the wrapped secret. Afterward, the session can not be locked anymore.
+func RemovePassphrase(tx *gorm.DB) error {
+	return tx.Where("1 = 1").Delete(&model.SessionKey{}).Error
+}
+
+// UnlockSecret unwraps the secret of the session with the passphrase. It returns crypt.ErrWrongPassphrase if the
+// passphrase does not match and ErrNoPassphrase if there is nothing to unlock.
+func UnlockSecret(db *gorm.DB, dbId uuid.UUID, passphrase string) (crypt.Secret, error) {
+	var key model.SessionKey
+	err := db.Take(&key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNoPassphrase
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return crypt.UnwrapSecret(key.Wrapped, passphrase, dbId[:])
+}
@@ -0,0 +1,298 @@
+package domain
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"softbaer.dev/ass/internal/crypt"
+	"softbaer.dev/ass/internal/model"
+)
+
+// maxAutomaticSnapshots limits how many automatic snapshots are kept. Older ones are removed, named ones never.
+const maxAutomaticSnapshots = 5
+
+const snapshotNameBeforeLoad = "Vor dem Laden einer Excel-Datei"
+
+var ErrSnapshotNotFound = errors.New("snapshot not found")
+
+type SnapshotID int
+
+// Snapshot describes a saved version of the scenario without its content.
+type Snapshot struct {
+	ID               SnapshotID
+	Name             string
+	CreatedAt        time.Time
+	Automatic        bool
+	CourseCount      int
+	ParticipantCount int
+}
+
+// SaveSnapshot copies the current scenario into a snapshot with the given name.
+func SaveSnapshot(tx *gorm.DB, name string) (Snapshot, error) {
+	return saveSnapshot(tx, strings.TrimSpace(name), false)
+}
+
+// SnapshotBeforeLoad saves the current scenario before it is overwritten by a loaded one. Empty scenarios are not
+// worth a snapshot and skipped.
+func SnapshotBeforeLoad(tx *gorm.DB) error {
+	var count int64
+	if err := tx.Model(&model.Course{}).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		if err := tx.Model(model.EmptyParticipantPointer()).Count(&count).Error; err != nil {
+			return err
+		}
+	}
+	if count == 0 {
+		return nil
+	}
+
+	if _, err := saveSnapshot(tx, snapshotNameBeforeLoad, true); err != nil {
+		return err
+	}
+
+	var outdated []int
+	err := tx.Model(&model.Snapshot{}).Where("automatic = ?", true).Order("id desc").Offset(maxAutomaticSnapshots).Pluck("id", &outdated).Error
+	if err != nil || len(outdated) == 0 {
+		return err
+	}
+
+	return tx.Delete(&model.Snapshot{}, outdated).Error
+}
+
+func saveSnapshot(tx *gorm.DB, name string, automatic bool) (Snapshot, error) {
+	current, err := captureRows(tx)
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	rowsJson, err := json.Marshal(current)
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	snapshot := model.Snapshot{
+		Name:             name,
+		Automatic:        automatic,
+		CourseCount:      len(current.Courses),
+		ParticipantCount: len(current.Participants),
+		Rows:             string(rowsJson),
+	}
+	if err := tx.Create(&snapshot).Error; err != nil {
+		return Snapshot{}, err
+	}
+
+	return snapshotFromDbModel(snapshot), nil
+}
+
+// Snapshots returns all snapshots, newest first.
+func Snapshots(db *gorm.DB) ([]Snapshot, error) {
+	var snapshots []model.Snapshot
+	err := db.Select("id", "created_at", "name", "automatic", "course_count", "participant_count").Order("id desc").Find(&snapshots).Error
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Snapshot, len(snapshots))
+	for i, snapshot := range snapshots {
+		result[i] = snapshotFromDbModel(snapshot)
+	}
+
+	return result, nil
+}
+
+// RestoreSnapshot replaces the scenario with the content of the snapshot. The snapshot itself is kept, and restoring
+// it is recorded in the history, so that it can be undone.
+func RestoreSnapshot(tx *gorm.DB, id SnapshotID) error {
+	snapshot, target, err := loadSnapshot(tx, id)
+	if err != nil {
+		return err
+	}
+
+	return Record(tx, ActionRestoreSnapshot, WholeScenario(), func(tx *gorm.DB) error {
+		current, err := captureRows(tx)
+		if err != nil {
+			return err
+		}
+
+		if err := diffRows(current, target).apply(tx, false); err != nil {
+			return fmt.Errorf("restoring snapshot %d (%s) failed: %w", snapshot.ID, snapshot.Name, err)
+		}
+
+		return nil
+	})
+}
+
+func DeleteSnapshot(tx *gorm.DB, id SnapshotID) error {
+	result := tx.Delete(&model.Snapshot{}, int(id))
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return ErrSnapshotNotFound
+	}
+
+	return nil
+}
+
+func loadSnapshot(db *gorm.DB, id SnapshotID) (model.Snapshot, rows, error) {
+	var snapshot model.Snapshot
+	err := db.Take(&snapshot, int(id)).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return snapshot, rows{}, ErrSnapshotNotFound
+	}
+	if err != nil {
+		return snapshot, rows{}, err
+	}
+
+	var content rows
+	if err := json.Unmarshal([]byte(snapshot.Rows), &content); err != nil {
+		return snapshot, rows{}, fmt.Errorf("snapshot %d is unreadable: %w", snapshot.ID, err)
+	}
+
+	return snapshot, content, nil
+}
+
+func snapshotFromDbModel(snapshot model.Snapshot) Snapshot {
+	return Snapshot{
+		ID:               SnapshotID(snapshot.ID),
+		Name:             snapshot.Name,
+		CreatedAt:        snapshot.CreatedAt,
+		Automatic:        snapshot.Automatic,
+		CourseCount:      snapshot.CourseCount,
+		ParticipantCount: snapshot.ParticipantCount,
+	}
+}
+
+// SnapshotComparison lists the differences between two snapshots.
+type SnapshotComparison struct {
+	Left, Right                     Snapshot
+	UnassignedLeft, UnassignedRight int
+	// Courses are matched by name, since loading a file may change their ids.
+	Courses []CourseComparison
+	// Participants only contains the participants whose assignment differs.
+	Participants []ParticipantComparison
+}
+
+type CourseComparison struct {
+	Name                              string
+	InLeft, InRight                   bool
+	AllocationLeft, AllocationRight   int
+	MaxCapacityLeft, MaxCapacityRight int
+}
+
+// ParticipantComparison tells where a participant is assigned in both snapshots. An empty course name means the
+// participant is not assigned.
+type ParticipantComparison struct {
+	ParticipantName
+	InLeft, InRight         bool
+	CourseLeft, CourseRight string
+}
+
+func CompareSnapshots(db *gorm.DB, secret crypt.Secret, leftID, rightID SnapshotID) (SnapshotComparison, error) {
+	left, leftScenario, err := loadSnapshotScenario(db, secret, leftID)
+	if err != nil {
+		return SnapshotComparison{}, err
+	}
+	right, rightScenario, err := loadSnapshotScenario(db, secret, rightID)
+	if err != nil {
+		return SnapshotComparison{}, err
+	}
+
+	comparison := SnapshotComparison{
+		Left:            left,
+		Right:           right,
+		UnassignedLeft:  len(leftScenario.Unassigned()),
+		UnassignedRight: len(rightScenario.Unassigned()),
+	}
+
+	coursesByName := make(map[string]*CourseComparison)
+	courseComparison := func(name string) *CourseComparison {
+		if _, ok := coursesByName[name]; !ok {
+			coursesByName[name] = &CourseComparison{Name: name}
+		}
+		return coursesByName[name]
+	}
+	for course := range leftScenario.AllCourses() {
+		c := courseComparison(course.Name)
+		c.InLeft, c.AllocationLeft, c.MaxCapacityLeft = true, leftScenario.AllocationOf(course.ID), course.MaxCapacity
+	}
+	for course := range rightScenario.AllCourses() {
+		c := courseComparison(course.Name)
+		c.InRight, c.AllocationRight, c.MaxCapacityRight = true, rightScenario.AllocationOf(course.ID), course.MaxCapacity
+	}
+	for _, name := range slices.Sorted(maps.Keys(coursesByName)) {
+		comparison.Courses = append(comparison.Courses, *coursesByName[name])
+	}
+
+	participantsByID := make(map[ParticipantID]*ParticipantComparison)
+	for participant := range leftScenario.AllParticipants() {
+		course, _ := leftScenario.AssignedCourse(participant.ID)
+		participantsByID[participant.ID] = &ParticipantComparison{ParticipantName: participant.ParticipantName, InLeft: true, CourseLeft: course.Name}
+	}
+	for participant := range rightScenario.AllParticipants() {
+		p, ok := participantsByID[participant.ID]
+		if !ok {
+			p = &ParticipantComparison{ParticipantName: participant.ParticipantName}
+			participantsByID[participant.ID] = p
+		}
+		course, _ := rightScenario.AssignedCourse(participant.ID)
+		p.InRight, p.CourseRight = true, course.Name
+	}
+	for _, p := range participantsByID {
+		if p.InLeft != p.InRight || p.CourseLeft != p.CourseRight {
+			comparison.Participants = append(comparison.Participants, *p)
+		}
+	}
+	slices.SortFunc(comparison.Participants, func(a, b ParticipantComparison) int {
+		return cmp.Or(cmp.Compare(a.Surname, b.Surname), cmp.Compare(a.Prename, b.Prename))
+	})
+
+	return comparison, nil
+}
+
+func loadSnapshotScenario(db *gorm.DB, secret crypt.Secret, id SnapshotID) (Snapshot, *Scenario, error) {
+	snapshot, content, err := loadSnapshot(db, id)
+	if err != nil {
+		return Snapshot{}, nil, err
+	}
+
+	scenario, err := content.scenario(secret)
+	if err != nil {
+		return Snapshot{}, nil, err
+	}
+
+	return snapshotFromDbModel(snapshot), scenario, nil
+}
+
+// scenario builds a scenario from the rows, just like LoadScenario does from the tables.
+func (r rows) scenario(secret crypt.Secret) (*Scenario, error) {
+	var courses []model.Course
+	for _, id := range slices.Sorted(maps.Keys(r.Courses)) {
+		courses = append(courses, r.Courses[id].dbModel(id))
+	}
+
+	var participants []model.Participant
+	for _, id := range slices.Sorted(maps.Keys(r.Participants)) {
+		participants = append(participants, r.Participants[id].dbModel(id))
+	}
+
+	var priorities []model.Priority
+	for _, p := range r.Priorities {
+		priorities = append(priorities, model.Priority{Level: p.Level, CourseID: p.CourseID, ParticipantID: p.ParticipantID})
+	}
+	slices.SortFunc(priorities, func(a, b model.Priority) int {
+		return cmp.Or(cmp.Compare(a.ParticipantID, b.ParticipantID), cmp.Compare(a.Level, b.Level))
+	})
+
+	return scenarioFromDbModels(participants, courses, priorities, secret)
+}
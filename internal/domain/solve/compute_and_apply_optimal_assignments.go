@@ -4,21 +4,88 @@ import (
 	"context"
 
 	"gorm.io/gorm"
+	"softbaer.dev/ass/internal/domain"
 )
 
+// Result summarizes a successful computation of optimal assignments.
+type Result struct {
+	// Assignments maps each participant that was assigned by the solver to its new course.
+	// Participants that were already assigned beforehand are not contained.
+	Assignments map[domain.ParticipantID]domain.CourseID
+	// ObjectiveValue is the value of the objective function as reported by z3.
+	ObjectiveValue int
+
+	objective            Objective
+	maximumPriorityLevel domain.PriorityLevel
+	// levels holds the priority level each assignment was weighted with.
+	levels map[domain.ParticipantID]domain.PriorityLevel
+}
+
+func newResult(s solution, opts options, priorities []priorityConstraint) Result {
+	result := Result{
+		Assignments:          make(map[domain.ParticipantID]domain.CourseID),
+		ObjectiveValue:       s.objectiveValue,
+		objective:            opts.objective,
+		maximumPriorityLevel: s.maximumPriorityLevel,
+		levels:               make(map[domain.ParticipantID]domain.PriorityLevel),
+	}
+	for _, assignment := range s.assignments {
+		result.Assignments[assignment.participantID] = assignment.courseID
+	}
+	for _, prio := range priorities {
+		if courseID, ok := result.Assignments[prio.participantID]; ok && courseID == prio.courseConstraint.courseId {
+			result.levels[prio.participantID] = prio.level
+		}
+	}
+
+	return result
+}
+
+// Claim returns what the solver reports about its result, so that it can be checked by domain.VerifySolve.
+func (r Result) Claim() domain.SolveClaim {
+	return domain.SolveClaim{
+		Assignments:    r.Assignments,
+		ObjectiveValue: r.ObjectiveValue,
+		Levels:         r.levels,
+		Weight: func(level domain.PriorityLevel) int {
+			return r.objective.weight(level, r.maximumPriorityLevel)
+		},
+	}
+}
+
 // ComputeAndApplyOptimalAssignments reads current scenario from the DB, computes which assignments would be optimal
 // to satisfy the prioritization of the still unassigned participants and writes these assignments to the DB.
 // Prefer passing a transaction as DB-handle so that partial updates will be rolled back in case of an error.
-func ComputeAndApplyOptimalAssignments(ctx context.Context, tx *gorm.DB) error {
+func ComputeAndApplyOptimalAssignments(ctx context.Context, tx *gorm.DB, opts ...Option) (Result, error) {
 	priorityConstraints, err := queryPriorityConstraints(tx)
 	if err != nil {
-		return err
+		return Result{}, err
 	}
 
-	optimalAssignments, err := computeOptimalAssignments(ctx, priorityConstraints)
+	solveOptions := buildOptions(opts)
+	optimalAssignments, err := computeOptimalAssignments(ctx, priorityConstraints, solveOptions)
 	if err != nil {
-		return err
+		return Result{}, err
+	}
+
+	return newResult(optimalAssignments, solveOptions, priorityConstraints), applyAssignments(tx, optimalAssignments.assignments)
+}
+
+// ComputeAndApplyOptimalAssignmentsToScenario does the same as ComputeAndApplyOptimalAssignments, but works on an
+// in-memory scenario instead of the DB. The scenario is modified in place.
+func ComputeAndApplyOptimalAssignmentsToScenario(ctx context.Context, scenario *domain.Scenario, opts ...Option) (Result, error) {
+	solveOptions := buildOptions(opts)
+	priorityConstraints := priorityConstraintsFromScenario(scenario)
+	optimalAssignments, err := computeOptimalAssignments(ctx, priorityConstraints, solveOptions)
+	if err != nil {
+		return Result{}, err
+	}
+
+	for _, assignment := range optimalAssignments.assignments {
+		if err := scenario.Assign(assignment.participantID, assignment.courseID); err != nil {
+			return Result{}, err
+		}
 	}
 
-	return applyAssignments(tx, optimalAssignments)
+	return newResult(optimalAssignments, solveOptions, priorityConstraints), nil
 }
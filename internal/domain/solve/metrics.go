@@ -0,0 +1,59 @@
+package solve
+
+import (
+	"context"
+	"errors"
+
+	"softbaer.dev/ass/internal/domain"
+	"softbaer.dev/ass/internal/metrics"
+)
+
+var instanceSizeBuckets = []float64{10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000}
+
+var (
+	solvesTotal = metrics.Default.NewCounterVec("priobaer_solves_total",
+		"Computations of optimal assignments by outcome.", "outcome")
+	solveDuration = metrics.Default.NewHistogramVec("priobaer_solve_duration_seconds",
+		"Time z3 took to compute optimal assignments by outcome.", metrics.DurationBuckets, "outcome")
+	solveWait = metrics.Default.NewHistogramVec("priobaer_solve_wait_seconds",
+		"Time computations waited for other computations to finish.", metrics.DurationBuckets)
+	solveParticipants = metrics.Default.NewHistogramVec("priobaer_solve_participants",
+		"Participants with priorities per computation.", instanceSizeBuckets)
+	solveCourses = metrics.Default.NewHistogramVec("priobaer_solve_courses",
+		"Prioritized courses with remaining capacity per computation.", instanceSizeBuckets)
+	solvePriorities = metrics.Default.NewHistogramVec("priobaer_solve_priorities",
+		"Priorities, i.e. variables of the optimization problem, per computation.", instanceSizeBuckets)
+)
+
+// solveOutcome turns the error of a computation into the label of the metrics.
+func solveOutcome(err error) string {
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.Is(err, NotSolvable):
+		return "not_solvable"
+	case errors.Is(err, Timeout), errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, UserCancelled), errors.Is(err, context.Canceled):
+		return "user_cancelled"
+	default:
+		return "error"
+	}
+}
+
+// observeInstanceSize records how large the problem is. Only counts are recorded, nothing about the participants.
+func observeInstanceSize(priorities []priorityConstraint) {
+	participants := make(map[domain.ParticipantID]struct{})
+	courses := make(map[domain.CourseID]struct{})
+	for _, prio := range priorities {
+		if prio.courseConstraint.remainingCapacity <= 0 {
+			continue
+		}
+		participants[prio.participantID] = struct{}{}
+		courses[prio.courseConstraint.courseId] = struct{}{}
+	}
+
+	solveParticipants.Observe(float64(len(participants)))
+	solveCourses.Observe(float64(len(courses)))
+	solvePriorities.Observe(float64(len(priorities)))
+}
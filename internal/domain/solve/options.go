@@ -0,0 +1,82 @@
+package solve
+
+import (
+	"fmt"
+	"time"
+
+	"softbaer.dev/ass/internal/domain"
+)
+
+// Objective determines how strongly the solver prefers high priorities over low ones.
+type Objective string
+
+const (
+	// ObjectiveLinear scores an assignment by its inverted priority level, i.e. each priority level is worth one
+	// point more than the next lower one. This is the default.
+	ObjectiveLinear Objective = "linear"
+	// ObjectiveQuadratic squares the linear score. Giving a participant their first instead of their second priority
+	// is therefore worth more than giving someone their second instead of their third priority.
+	ObjectiveQuadratic Objective = "quadratic"
+)
+
+// ParseObjective returns the objective of the given name, e.g. as passed via the -objective flag of cmd/priobaer.
+func ParseObjective(s string) (Objective, error) {
+	switch Objective(s) {
+	case ObjectiveLinear, ObjectiveQuadratic:
+		return Objective(s), nil
+	default:
+		return "", fmt.Errorf("unknown objective '%s'. Valid objectives are '%s' and '%s'", s, ObjectiveLinear, ObjectiveQuadratic)
+	}
+}
+
+// weight returns the coefficient of an assignment with the given priority level,
+// so that numerically low levels map to high coefficients.
+func (o Objective) weight(level, maximumLevel domain.PriorityLevel) int {
+	linear := (int(maximumLevel) + 1) - int(level)
+
+	if o == ObjectiveQuadratic {
+		return linear * linear
+	}
+
+	return linear
+}
+
+type options struct {
+	objective Objective
+	timeout   time.Duration
+	seed      uint
+}
+
+func defaultOptions() options {
+	return options{objective: ObjectiveLinear, timeout: solveTimeout, seed: 0}
+}
+
+type Option func(*options)
+
+func WithObjective(objective Objective) Option {
+	return func(o *options) {
+		o.objective = objective
+	}
+}
+
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.timeout = timeout
+	}
+}
+
+// WithSeed sets the random seed used by z3. Solving the same scenario with the same seed yields the same assignments.
+func WithSeed(seed uint) Option {
+	return func(o *options) {
+		o.seed = seed
+	}
+}
+
+func buildOptions(opts []Option) options {
+	result := defaultOptions()
+	for _, opt := range opts {
+		opt(&result)
+	}
+
+	return result
+}
@@ -0,0 +1,19 @@
+package solve
+
+import "softbaer.dev/ass/internal/domain"
+
+// priorityConstraintsFromScenario is the in-memory counterpart of queryPriorityConstraints.
+// Only priorities of unassigned participants are considered and course capacities are reduced by existing assignments.
+func priorityConstraintsFromScenario(scenario *domain.Scenario) (result []priorityConstraint) {
+	for _, participant := range scenario.Unassigned() {
+		level := domain.PriorityLevel(1)
+		for course := range scenario.PrioritizedCoursesOrdered(participant.ID) {
+			allocation := scenario.AllocationOf(course.ID)
+			constraint := newCourseConstraint(course.ID, course.MinCapacity-allocation, course.MaxCapacity-allocation)
+			result = append(result, newPriorityConstraint(level, constraint, participant.ID))
+			level++
+		}
+	}
+
+	return
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"strconv"
 	"time"
 
 	"golang.org/x/sync/semaphore"
@@ -22,15 +23,39 @@ var rateLimit = semaphore.NewWeighted(1)
 
 const solveTimeout = time.Minute * 10
 
-func computeOptimalAssignments(ctx context.Context, priorities []priorityConstraint) (assignments []computedAssignment, err error) {
-	if err = rateLimit.Acquire(ctx, 1); err != nil {
-		return nil, err
+type solution struct {
+	assignments []computedAssignment
+	// objectiveValue is the value of the objective function as reported by z3.
+	objectiveValue int
+	// maximumPriorityLevel is the highest level considered when weighting the assignments in the objective function.
+	maximumPriorityLevel domain.PriorityLevel
+}
+
+func computeOptimalAssignments(ctx context.Context, priorities []priorityConstraint, opts options) (result solution, err error) {
+	waitStart := time.Now()
+	if err := rateLimit.Acquire(ctx, 1); err != nil {
+		solveWait.Observe(time.Since(waitStart).Seconds())
+		solvesTotal.Inc(solveOutcome(err))
+		return solution{}, err
 	}
 	defer rateLimit.Release(1)
+	solveWait.Observe(time.Since(waitStart).Seconds())
+
+	observeInstanceSize(priorities)
+	solveStart := time.Now()
+	defer func() {
+		outcome := solveOutcome(err)
+		solvesTotal.Inc(outcome)
+		solveDuration.Observe(time.Since(solveStart).Seconds(), outcome)
+	}()
 
-	ctx, cancel := context.WithTimeout(ctx, solveTimeout)
+	// Z3 only accepts the seed as global parameter. This is fine as long as rateLimit makes sure that
+	// there is only one problem solved at a time.
+	z3.SetGlobalParam("smt.random_seed", strconv.FormatUint(uint64(opts.seed), 10))
+
+	ctx, cancel := context.WithTimeout(ctx, opts.timeout)
 	defer cancel()
-	optimizationProblem := newOptimizationProblem(priorities)
+	optimizationProblem := newOptimizationProblem(priorities, opts.objective)
 	defer optimizationProblem.Close()
 
 	return optimizationProblem.solve(ctx)
@@ -40,12 +65,13 @@ type optimizationProblem struct {
 	ctx        *z3.Context
 	optimize   *z3.Optimize
 	priorities []priorityConstraint
+	objective  Objective
 }
 
-func newOptimizationProblem(priorities []priorityConstraint) *optimizationProblem {
+func newOptimizationProblem(priorities []priorityConstraint, objective Objective) *optimizationProblem {
 	ctx, o := newZ3Optimizer()
 
-	return &optimizationProblem{ctx: ctx, optimize: o, priorities: priorities}
+	return &optimizationProblem{ctx: ctx, optimize: o, priorities: priorities, objective: objective}
 }
 
 func (p *optimizationProblem) Close() {
@@ -148,12 +174,14 @@ type varWithPriorityLevel struct {
 type maximizeHighPrioritiesObjective struct {
 	ctx                         *z3.Context
 	optimize                    *z3.Optimize
+	objective                   Objective
 	variablesWithPriorityLevels []varWithPriorityLevel
 	maximumPrioLevel            domain.PriorityLevel
+	term                        *z3.AST
 }
 
 func newPreferHighPrioritiesObjective(s *optimizationProblem) *maximizeHighPrioritiesObjective {
-	return &maximizeHighPrioritiesObjective{ctx: s.ctx, optimize: s.optimize}
+	return &maximizeHighPrioritiesObjective{ctx: s.ctx, optimize: s.optimize, objective: s.objective}
 }
 
 func (o *maximizeHighPrioritiesObjective) add(prio priorityConstraint, variable *z3.AST) {
@@ -171,13 +199,19 @@ func (o *maximizeHighPrioritiesObjective) build() {
 		objective = objective.Add(o.weightedTerm(varWithPriorityLevel))
 	}
 
+	o.term = objective
 	o.optimize.Maximize(objective)
 }
 
+// value evaluates the objective function within the model found by z3.
+func (o *maximizeHighPrioritiesObjective) value(m *z3.Model) int {
+	return m.Eval(o.term).Int()
+}
+
 // invertPriorityLevel turns a raw PriorityLevel into a Z3 coefficient,
 // so that numerically low levels map to high coefficients.
 func (o *maximizeHighPrioritiesObjective) invertPriorityLevel(level domain.PriorityLevel) *z3.AST {
-	coeff := (int(o.maximumPrioLevel) + 1) - int(level)
+	coeff := o.objective.weight(level, o.maximumPrioLevel)
 	return o.ctx.Int(coeff, o.ctx.IntSort())
 }
 
@@ -185,18 +219,19 @@ func (o *maximizeHighPrioritiesObjective) weightedTerm(varWithPriorityLevel varW
 	return o.invertPriorityLevel(varWithPriorityLevel.prioLevel).Mul(varWithPriorityLevel.variable)
 }
 
-func (p *optimizationProblem) solve(ctx context.Context) (assignments []computedAssignment, err error) {
+func (p *optimizationProblem) solve(ctx context.Context) (solution, error) {
 	// The goroutine listening for ctx.Done should stop listening when this method finishes
 	finished := make(chan bool)
 	defer func() {
 		finished <- true
 	}()
 
+	objective := newPreferHighPrioritiesObjective(p)
 	constrainBuilders := []constraintBuilder{
 		newExactlyOneCoursePerParticipantConstraint(p),
 		newMaximumCapacityConstraint(p),
 		newMinimumCapacityConstraint(p),
-		newPreferHighPrioritiesObjective(p),
+		objective,
 	}
 
 	for _, prio := range p.priorities {
@@ -227,28 +262,31 @@ func (p *optimizationProblem) solve(ctx context.Context) (assignments []computed
 	}()
 	checkResult := p.optimize.Check()
 	if checkResult == z3.False {
-		return assignments, NotSolvable
+		return solution{}, NotSolvable
 	}
 
 	if checkResult == z3.Undef {
 		switch {
 		case errors.Is(ctxErr, context.Canceled):
-			return assignments, UserCancelled
+			return solution{}, UserCancelled
 		case errors.Is(ctxErr, context.DeadlineExceeded):
-			return assignments, Timeout
+			return solution{}, Timeout
 		default:
-			return assignments, fmt.Errorf("z3 returned Undef but ctx.Err() is something unexpected: %w", ctxErr)
+			return solution{}, fmt.Errorf("z3 returned Undef but ctx.Err() is something unexpected: %w", ctxErr)
 		}
 	}
 
 	if checkResult != z3.True {
-		return assignments, fmt.Errorf("z3 returned sth that is neither False, True or Undef: %v", checkResult)
+		return solution{}, fmt.Errorf("z3 returned sth that is neither False, True or Undef: %v", checkResult)
 	}
 
 	m := p.optimize.Model()
-	solution := m.Assignments()
+	assignments, err := parseSolution(m.Assignments())
+	if err != nil {
+		return solution{}, err
+	}
 
-	return parseSolution(solution)
+	return solution{assignments: assignments, objectiveValue: objective.value(m), maximumPriorityLevel: objective.maximumPrioLevel}, nil
 }
 
 func (p *optimizationProblem) priorityVariable(prio priorityConstraint) *z3.AST {
@@ -103,7 +103,8 @@ func TestSolveAssignmentSolvesDifferentScenariosCorrectly(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			priorityConstraints := buildPriorityConstraints(tc.participantsPriosBuilders, tc.courseConstraints)
 
-			assignments, err := computeOptimalAssignments(context.Background(), priorityConstraints)
+			solution, err := computeOptimalAssignments(context.Background(), priorityConstraints, defaultOptions())
+			assignments := solution.assignments
 
 			if tc.printInsteadOfAssert {
 				assignmentsMap := make(map[domain.ParticipantID]domain.CourseID)
@@ -209,3 +210,25 @@ func buildPriorityConstraints(prioMappings []participantPriosBuilder, courseCons
 
 	return result
 }
+
+func TestComputeAndApplyOptimalAssignmentsToScenarioRespectsExistingAssignments(t *testing.T) {
+	is := is.New(t)
+
+	scenario := domain.EmptyScenario()
+	scenario.AddCourse(domain.CourseData{ID: 1, Name: "foo", MinCapacity: 0, MaxCapacity: 2})
+	scenario.AddCourse(domain.CourseData{ID: 2, Name: "bar", MinCapacity: 0, MaxCapacity: 2})
+	for pid := range 4 {
+		scenario.AddParticipant(domain.ParticipantData{ID: domain.ParticipantID(pid + 1)})
+		is.NoErr(scenario.Prioritize(domain.ParticipantID(pid+1), []domain.CourseID{1, 2}))
+	}
+	is.NoErr(scenario.Assign(1, 1))
+
+	result, err := ComputeAndApplyOptimalAssignmentsToScenario(context.Background(), scenario, WithSeed(42))
+	is.NoErr(err)
+
+	is.Equal(len(result.Assignments), 3)    // want only the unassigned participants to be assigned by the solver
+	is.Equal(len(scenario.Unassigned()), 0) // want the scenario to be updated in place
+	is.Equal(scenario.AllocationOf(1), 2)   // want max capacity to be respected including the existing assignment
+	is.Equal(scenario.AllocationOf(2), 2)
+	is.Equal(result.ObjectiveValue, 1*2+2*1) // one more participant gets the first, two get their second priority
+}
@@ -0,0 +1,150 @@
+package domain
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// Rule names a rule that the assignments of a scenario have to satisfy.
+type Rule string
+
+const (
+	RuleMaxCapacity             Rule = "max-capacity"
+	RuleMinCapacityOrEmpty      Rule = "min-capacity-or-empty"
+	RuleOneCoursePerParticipant Rule = "one-course-per-participant"
+	RuleCourseExists            Rule = "course-exists"
+	RuleSolverAssignment        Rule = "solver-assignment"
+	RuleObjective               Rule = "objective"
+)
+
+// Violation describes a single instance of a broken rule.
+// The scenario has no notion of vetoes or pinned assignments yet, so there are no rules for them.
+type Violation struct {
+	Rule          Rule
+	CourseID      CourseID
+	ParticipantID ParticipantID
+	// Message describes the violation for the user. It never contains participant names, so it is safe to log.
+	Message string
+}
+
+// LogArgs returns the violation as key-value pairs for slog.
+func (v Violation) LogArgs() []any {
+	return []any{"rule", v.Rule, "courseId", v.CourseID, "participantId", v.ParticipantID, "msg", v.Message}
+}
+
+// SolveClaim is what a solver reports about the assignments it has computed.
+type SolveClaim struct {
+	// Assignments contains only the participants assigned by the solver.
+	Assignments    map[ParticipantID]CourseID
+	ObjectiveValue int
+	// Levels contains the priority level the solver weighted each assignment with. They have to match the stored
+	// levels of the scenario.
+	Levels map[ParticipantID]PriorityLevel
+	// Weight returns the coefficient the solver used in its objective function for an assignment of the given level.
+	Weight func(level PriorityLevel) int
+}
+
+// Verify checks the scenario against all rules, independently of how its assignments came into being.
+// It returns nil if no rule is violated.
+func Verify(s *Scenario) (violations []Violation) {
+	seen := make(map[ParticipantID]bool)
+	for _, p := range s.participants {
+		if seen[p.ID] {
+			violations = append(violations, Violation{
+				Rule:          RuleOneCoursePerParticipant,
+				ParticipantID: p.ID,
+				Message:       fmt.Sprintf("Teilnehmer mit ID %d ist mehrfach vorhanden und könnte dadurch mehreren Kursen zugeteilt sein", p.ID),
+			})
+		}
+		seen[p.ID] = true
+	}
+
+	for _, pid := range slices.Sorted(maps.Keys(s.assignmentTable)) {
+		assigned := s.assignmentTable[pid]
+		if _, ok := s.course(assigned.ID); !ok {
+			violations = append(violations, Violation{
+				Rule:          RuleCourseExists,
+				CourseID:      assigned.ID,
+				ParticipantID: pid,
+				Message:       fmt.Sprintf("Teilnehmer mit ID %d ist einem Kurs zugeteilt, der nicht existiert", pid),
+			})
+		}
+	}
+
+	for _, c := range s.courses {
+		allocation := s.AllocationOf(c.ID)
+
+		if allocation > c.MaxCapacity {
+			violations = append(violations, Violation{
+				Rule:     RuleMaxCapacity,
+				CourseID: c.ID,
+				Message:  fmt.Sprintf("Kurs '%s' ist mit %d Teilnehmern überbelegt (maximal %d)", c.Name, allocation, c.MaxCapacity),
+			})
+		}
+
+		if allocation > 0 && allocation < c.MinCapacity {
+			violations = append(violations, Violation{
+				Rule:     RuleMinCapacityOrEmpty,
+				CourseID: c.ID,
+				Message:  fmt.Sprintf("Kurs '%s' hat %d Teilnehmer, braucht aber mindestens %d oder gar keine", c.Name, allocation, c.MinCapacity),
+			})
+		}
+	}
+
+	return
+}
+
+// VerifySolve does the same as Verify. Additionally, it checks that the claimed assignments are part of the scenario,
+// that the solver only assigned participants to courses they prioritized and that the claimed levels and objective
+// value match the ones recomputed from the stored priority levels of the scenario.
+func VerifySolve(s *Scenario, claim SolveClaim) []Violation {
+	violations := Verify(s)
+
+	recomputedObjective := 0
+	for _, pid := range slices.Sorted(maps.Keys(claim.Assignments)) {
+		claimedCourseID := claim.Assignments[pid]
+
+		assigned, ok := s.AssignedCourse(pid)
+		if !ok || assigned.ID != claimedCourseID {
+			violations = append(violations, Violation{
+				Rule:          RuleSolverAssignment,
+				CourseID:      claimedCourseID,
+				ParticipantID: pid,
+				Message:       fmt.Sprintf("Die Zuteilung von Teilnehmer mit ID %d durch den Solver wurde nicht übernommen", pid),
+			})
+			continue
+		}
+
+		level, ok := s.StoredPriorityLevelOf(pid, claimedCourseID)
+		if !ok {
+			violations = append(violations, Violation{
+				Rule:          RuleSolverAssignment,
+				CourseID:      claimedCourseID,
+				ParticipantID: pid,
+				Message:       fmt.Sprintf("Teilnehmer mit ID %d wurde einem Kurs zugeteilt, den er nicht priorisiert hat", pid),
+			})
+			continue
+		}
+
+		if claimedLevel, ok := claim.Levels[pid]; ok && claimedLevel != level {
+			violations = append(violations, Violation{
+				Rule:          RuleSolverAssignment,
+				CourseID:      claimedCourseID,
+				ParticipantID: pid,
+				Message:       fmt.Sprintf("Der Solver hat die Zuteilung von Teilnehmer mit ID %d mit Priorität %d statt %d gewichtet", pid, claimedLevel, level),
+			})
+		}
+
+		recomputedObjective += claim.Weight(level)
+	}
+
+	if recomputedObjective != claim.ObjectiveValue {
+		violations = append(violations, Violation{
+			Rule:    RuleObjective,
+			Message: fmt.Sprintf("Der vom Solver gemeldete Zielwert %d weicht vom nachgerechneten Wert %d ab", claim.ObjectiveValue, recomputedObjective),
+		})
+	}
+
+	return violations
+}
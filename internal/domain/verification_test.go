@@ -0,0 +1,134 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func newVerificationScenario() *Scenario {
+	s := EmptyScenario()
+	s.AddCourse(CourseData{ID: 1, Name: "foo", MinCapacity: 2, MaxCapacity: 3})
+	s.AddCourse(CourseData{ID: 2, Name: "bar", MinCapacity: 0, MaxCapacity: 1})
+	for pid := range 4 {
+		s.AddParticipant(ParticipantData{ID: ParticipantID(pid + 1)})
+	}
+
+	return s
+}
+
+func rulesOf(violations []Violation) (rules []Rule) {
+	for _, v := range violations {
+		rules = append(rules, v.Rule)
+	}
+
+	return
+}
+
+func TestVerifyDetectsBrokenRules(t *testing.T) {
+	testcases := []struct {
+		name        string
+		assignments map[ParticipantID]CourseID
+		wantRules   []Rule
+	}{
+		{"Empty courses are valid", map[ParticipantID]CourseID{}, nil},
+		{"Allocation between min and max is valid", map[ParticipantID]CourseID{1: 1, 2: 1, 3: 2}, nil},
+		{"Allocation below min capacity", map[ParticipantID]CourseID{1: 1}, []Rule{RuleMinCapacityOrEmpty}},
+		{"Allocation above max capacity", map[ParticipantID]CourseID{1: 2, 2: 2}, []Rule{RuleMaxCapacity}},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			is := is.New(t)
+			s := newVerificationScenario()
+			for pid, cid := range tc.assignments {
+				is.NoErr(s.Assign(pid, cid))
+			}
+
+			is.Equal(rulesOf(Verify(s)), tc.wantRules)
+		})
+	}
+}
+
+func TestVerifyDetectsDuplicateParticipants(t *testing.T) {
+	is := is.New(t)
+	s := newVerificationScenario()
+	s.AddParticipant(ParticipantData{ID: 1})
+
+	is.Equal(rulesOf(Verify(s)), []Rule{RuleOneCoursePerParticipant})
+}
+
+func TestVerifyDetectsAssignmentsToMissingCourses(t *testing.T) {
+	is := is.New(t)
+	s := newVerificationScenario()
+	s.assignmentTable[1] = &CourseData{ID: 3, MaxCapacity: 1}
+
+	is.Equal(rulesOf(Verify(s)), []Rule{RuleCourseExists})
+}
+
+func TestVerifySolveRecomputesObjective(t *testing.T) {
+	linear := func(level PriorityLevel) int { return 3 - int(level) }
+
+	testcases := []struct {
+		name      string
+		gaps      bool
+		claim     SolveClaim
+		wantRules []Rule
+	}{
+		{
+			"Matching objective",
+			false,
+			SolveClaim{Assignments: map[ParticipantID]CourseID{1: 1, 2: 1, 3: 2}, ObjectiveValue: 2 + 1 + 2, Weight: linear},
+			nil,
+		},
+		{
+			"Objective differs from recomputed one",
+			false,
+			SolveClaim{Assignments: map[ParticipantID]CourseID{1: 1, 2: 1, 3: 2}, ObjectiveValue: 6, Weight: linear},
+			[]Rule{RuleObjective},
+		},
+		{
+			"Stored levels with gaps left by deleted courses",
+			true,
+			SolveClaim{Assignments: map[ParticipantID]CourseID{1: 1, 2: 1, 3: 2}, Levels: map[ParticipantID]PriorityLevel{1: 1, 2: 3, 3: 1}, ObjectiveValue: 2 + 0 + 2, Weight: linear},
+			nil,
+		},
+		{
+			"Objective ignores stored gaps",
+			true,
+			SolveClaim{Assignments: map[ParticipantID]CourseID{1: 1, 2: 1, 3: 2}, ObjectiveValue: 2 + 1 + 2, Weight: linear},
+			[]Rule{RuleObjective},
+		},
+		{
+			"Claimed level differs from stored one",
+			false,
+			SolveClaim{Assignments: map[ParticipantID]CourseID{1: 1, 2: 1, 3: 2}, Levels: map[ParticipantID]PriorityLevel{1: 1, 2: 1, 3: 1}, ObjectiveValue: 2 + 1 + 2, Weight: linear},
+			[]Rule{RuleSolverAssignment},
+		},
+		{
+			"Claimed assignment is missing in scenario",
+			false,
+			SolveClaim{Assignments: map[ParticipantID]CourseID{1: 1, 2: 1, 3: 2, 4: 2}, ObjectiveValue: 5, Weight: linear},
+			[]Rule{RuleSolverAssignment},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			is := is.New(t)
+			s := newVerificationScenario()
+			is.NoErr(s.Prioritize(1, []CourseID{1, 2}))
+			is.NoErr(s.Prioritize(2, []CourseID{2, 1}))
+			is.NoErr(s.Prioritize(3, []CourseID{2, 1}))
+			for pid, cid := range map[ParticipantID]CourseID{1: 1, 2: 1, 3: 2} {
+				is.NoErr(s.Assign(pid, cid))
+			}
+			if tc.gaps {
+				// As loaded from the db after a course in between was deleted.
+				s.storedLevels[2] = map[CourseID]PriorityLevel{2: 1, 1: 3}
+			}
+
+			is.Equal(rulesOf(VerifySolve(s, tc.claim)), tc.wantRules)
+		})
+	}
+}
@@ -0,0 +1,230 @@
+// Package metrics collects counters, histograms and gauges and writes them in the Prometheus text format. Metrics
+// must not contain personal data, so label values are limited to things like routes and outcomes.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Default holds the metrics of packages that are used by every server of the process, e.g. the solver.
+var Default = NewRegistry()
+
+// DurationBuckets suit durations in seconds, from a few milliseconds up to several minutes.
+var DurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600}
+
+type collector interface {
+	write(w io.Writer) error
+}
+
+type Registry struct {
+	mu         sync.Mutex
+	collectors []collector
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.collectors = append(r.collectors, c)
+}
+
+// Write writes all metrics of the registry in the order they were registered.
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.Lock()
+	collectors := slices.Clone(r.collectors)
+	r.mu.Unlock()
+
+	for _, c := range collectors {
+		if err := c.write(w); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CounterVec counts events per combination of label values.
+type CounterVec struct {
+	name       string
+	help       string
+	labelNames []string
+
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+func (r *Registry) NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	c := &CounterVec{name: name, help: help, labelNames: labelNames, values: make(map[string]float64)}
+	r.register(c)
+
+	return c
+}
+
+// Inc counts one event. The label values must be given in the order of the label names.
+func (c *CounterVec) Inc(labelValues ...string) {
+	labels := formatLabels(c.labelNames, labelValues)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.values[labels]++
+}
+
+func (c *CounterVec) write(w io.Writer) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", c.name, c.help, c.name); err != nil {
+		return err
+	}
+	for _, labels := range slices.Sorted(maps.Keys(c.values)) {
+		if _, err := fmt.Fprintf(w, "%s%s %s\n", c.name, labels, formatValue(c.values[labels])); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// HistogramVec counts observations in buckets per combination of label values.
+type HistogramVec struct {
+	name       string
+	help       string
+	labelNames []string
+	buckets    []float64
+
+	mu         sync.Mutex
+	histograms map[string]*histogram
+}
+
+type histogram struct {
+	labelValues []string
+	// counts holds the number of observations per bucket, not cumulated.
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// NewHistogramVec registers a histogram. Buckets are the upper bounds in ascending order; the +Inf bucket is added.
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	h := &HistogramVec{name: name, help: help, labelNames: labelNames, buckets: buckets, histograms: make(map[string]*histogram)}
+	r.register(h)
+
+	return h
+}
+
+// Observe records a value. The label values must be given in the order of the label names.
+func (h *HistogramVec) Observe(value float64, labelValues ...string) {
+	key := formatLabels(h.labelNames, labelValues)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	hist, ok := h.histograms[key]
+	if !ok {
+		hist = &histogram{labelValues: labelValues, counts: make([]uint64, len(h.buckets))}
+		h.histograms[key] = hist
+	}
+
+	if i, _ := slices.BinarySearch(h.buckets, value); i < len(h.buckets) {
+		hist.counts[i]++
+	}
+	hist.sum += value
+	hist.count++
+}
+
+func (h *HistogramVec) write(w io.Writer) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", h.name, h.help, h.name); err != nil {
+		return err
+	}
+
+	bucketLabelNames := append(slices.Clone(h.labelNames), "le")
+	for _, key := range slices.Sorted(maps.Keys(h.histograms)) {
+		hist := h.histograms[key]
+
+		var cumulated uint64
+		for i, upperBound := range h.buckets {
+			cumulated += hist.counts[i]
+			labels := formatLabels(bucketLabelNames, append(slices.Clone(hist.labelValues), formatValue(upperBound)))
+			if _, err := fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, labels, cumulated); err != nil {
+				return err
+			}
+		}
+
+		labels := formatLabels(bucketLabelNames, append(slices.Clone(hist.labelValues), "+Inf"))
+		if _, err := fmt.Fprintf(w, "%s_bucket%s %d\n%s_sum%s %s\n%s_count%s %d\n", h.name, labels, hist.count, h.name, key, formatValue(hist.sum), h.name, key, hist.count); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GaugeFunc reports a value that is computed whenever the metrics are written, e.g. the number of sessions.
+type GaugeFunc struct {
+	name  string
+	help  string
+	value func() (float64, error)
+}
+
+func (r *Registry) NewGaugeFunc(name, help string, value func() (float64, error)) *GaugeFunc {
+	g := &GaugeFunc{name: name, help: help, value: value}
+	r.register(g)
+
+	return g
+}
+
+// write leaves out the value if it can not be computed, so that the other metrics are still reported.
+func (g *GaugeFunc) write(w io.Writer) error {
+	if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", g.name, g.help, g.name); err != nil {
+		return err
+	}
+
+	value, err := g.value()
+	if err != nil {
+		return nil
+	}
+
+	_, err = fmt.Fprintf(w, "%s %s\n", g.name, formatValue(value))
+	return err
+}
+
+func formatLabels(names, values []string) string {
+	if len(names) != len(values) {
+		panic(fmt.Sprintf("metrics: got %d label values for %d label names", len(values), len(names)))
+	}
+	if len(names) == 0 {
+		return ""
+	}
+
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = name + `="` + labelValueReplacer.Replace(values[i]) + `"`
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatValue(value float64) string {
+	if math.IsInf(value, 1) {
+		return "+Inf"
+	}
+
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
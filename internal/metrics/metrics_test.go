@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestRegistryWritesPrometheusTextFormat(t *testing.T) {
+	is := is.New(t)
+	registry := NewRegistry()
+
+	counter := registry.NewCounterVec("test_events_total", "Events.", "outcome")
+	counter.Inc("ok")
+	counter.Inc("ok")
+	counter.Inc(`say "hi"`)
+
+	histogram := registry.NewHistogramVec("test_duration_seconds", "Durations.", []float64{1, 5})
+	histogram.Observe(0.5)
+	histogram.Observe(1)
+	histogram.Observe(3)
+	histogram.Observe(10)
+
+	registry.NewGaugeFunc("test_sessions", "Sessions.", func() (float64, error) { return 3, nil })
+	registry.NewGaugeFunc("test_broken", "Broken.", func() (float64, error) { return 0, errors.New("broken") })
+
+	var out strings.Builder
+	is.NoErr(registry.Write(&out))
+
+	is.Equal(out.String(), `# HELP test_events_total Events.
+# TYPE test_events_total counter
+test_events_total{outcome="ok"} 2
+test_events_total{outcome="say \"hi\""} 1
+# HELP test_duration_seconds Durations.
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{le="1"} 2
+test_duration_seconds_bucket{le="5"} 3
+test_duration_seconds_bucket{le="+Inf"} 4
+test_duration_seconds_sum 14.5
+test_duration_seconds_count 4
+# HELP test_sessions Sessions.
+# TYPE test_sessions gauge
+test_sessions 3
+# HELP test_broken Broken.
+# TYPE test_broken gauge
+`)
+}
@@ -0,0 +1,9 @@
+package model
+
+// CiphertextBinding records whether the db still holds names that were encrypted before ciphertexts were bound to
+// their row and field, or course names that were not encrypted at all. Only the user's cookie holds the secret, so
+// they are bound on the first request of the session instead of by a migration. There is at most one per session db.
+type CiphertextBinding struct {
+	ID      int
+	Pending bool
+}
@@ -2,103 +2,64 @@ package model
 
 import (
 	"errors"
-	"fmt"
-	"strconv"
 	"strings"
 
 	"gorm.io/gorm"
+	"softbaer.dev/ass/internal/crypt"
 )
 
 type Course struct {
 	gorm.Model
-	ID           int
-	Name         string `gorm:"unique"`
+	ID            int
+	EncryptedName string
+	// NameIndex keeps names unique and lets them be looked up without decrypting them. See EncryptedField.BlindIndex.
+	NameIndex    string `gorm:"uniqueIndex"`
 	MaxCapacity  int
 	MinCapacity  int
 	Participants []Participant
 }
 
-func (c *Course) Allocation() int {
-	return len(c.Participants)
-}
-
-func (c *Course) RemainingCapacity() int {
-	return c.MaxCapacity - c.Allocation()
-}
-
-func (c *Course) GapToMinCapacity() int {
-	return c.MinCapacity - c.Allocation()
-}
-
-func (c *Course) Valid() map[string]string {
-	errs := make(map[string]string)
-
-	if c.MinCapacity > c.MaxCapacity {
-		errs["min-capacity"] = "Die minmale Kapazität muss kleiner oder gleich der maximalen Kapazität sein"
-		errs["max-capacity"] = "Die maxmale Kapazität muss größer oder gleich der minimalen Kapazität sein"
-	}
-
-	if c.MaxCapacity <= 0 {
-		errs["max-capacity"] = "Die maximale Kapazität muss größer null sein"
+// SetName encrypts the name and binds it to the id of the course, so the id has to be set before.
+func (c *Course) SetName(plainName string, secret crypt.Secret) error {
+	encryptedName, err := courseName.Encrypt(c.ID, plainName, secret)
+	if err != nil {
+		return err
 	}
 
-	validateNonEmpty(c.Name, "name", "Name darf nicht leer sein", errs)
-
-	c.TrimFields()
+	c.EncryptedName, c.NameIndex = encryptedName, CourseNameIndex(plainName, secret)
 
-	return errs
+	return nil
 }
 
-func (c *Course) TrimFields() {
-	c.Name = strings.TrimSpace(c.Name)
+func (c *Course) Name(secret crypt.Secret) (string, error) {
+	return courseName.Decrypt(c.ID, c.EncryptedName, secret)
 }
 
-func (c *Course) MarshalRecord() []string {
-	return []string{
-		strconv.Itoa(c.ID),
-		c.Name,
-		strconv.Itoa(c.MinCapacity),
-		strconv.Itoa(c.MaxCapacity),
-	}
-}
-
-func (c *Course) UnmarshalRecord(record []string) error {
-	const recordLen int = 4
-	if len(record) != recordLen {
-		return fmt.Errorf("die Zeile hat %d Werte bzw. Spalten. Genau %d sind erwartet", len(record), recordLen)
-	}
-
-	if id, err := strconv.Atoi(record[0]); err == nil {
-		c.ID = id
-	} else {
+// Rekey re-encrypts the name, which is encrypted with oldSecret, with newSecret. The name index changes as well.
+func (c *Course) Rekey(oldSecret, newSecret crypt.Secret) error {
+	name, err := c.Name(oldSecret)
+	if err != nil {
 		return err
 	}
 
-	c.Name = record[1]
-
-	if minCap, err := strconv.Atoi(record[2]); err == nil {
-		c.MinCapacity = minCap
-	} else {
-		return err
-	}
+	return c.SetName(name, newSecret)
+}
 
-	if maxCap, err := strconv.Atoi(record[3]); err == nil {
-		c.MaxCapacity = maxCap
-	} else {
-		return err
-	}
+// CourseNameIndex returns the value of NameIndex for the name.
+func CourseNameIndex(plainName string, secret crypt.Secret) string {
+	return courseName.BlindIndex(plainName, secret)
+}
 
-	return stackValidationErrors(c.Valid())
+func (c *Course) Allocation() int {
+	return len(c.Participants)
 }
 
-type Courses []Course
+func (c *Course) RemainingCapacity() int {
+	return c.MaxCapacity - c.Allocation()
+}
 
-func (cs Courses) Names() []string {
-	var names []string
-	for _, course := range cs {
-		names = append(names, course.Name)
-	}
-	return names
+func (c *Course) GapToMinCapacity() int {
+	return c.MinCapacity - c.Allocation()
 }
 
 func MapToCourseId(courses []Course) []int {
@@ -0,0 +1,114 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"softbaer.dev/ass/internal/crypt"
+)
+
+// boundPrefix marks ciphertexts that are bound to their row and column. Ciphertexts of older dbs lack it until they
+// are bound by EncryptedField.Bind.
+const boundPrefix = "v2:"
+
+var ErrRowWithoutId = errors.New("row needs an id before its fields can be encrypted")
+
+// ErrUnboundCiphertext is returned for ciphertexts that are not bound to their row yet. They can not be trusted,
+// since they could have been moved from another row.
+var ErrUnboundCiphertext = errors.New("ciphertext is not bound to its row")
+
+// EncryptedField is a free-text column that is stored encrypted with the secret of the session. Its ciphertexts are
+// bound to the table, the row and the column, so that they can not be moved into another row or column unnoticed.
+// Every free-text column has to be declared as an EncryptedField.
+type EncryptedField struct {
+	Table  string
+	Column string
+}
+
+var (
+	participantPrename = EncryptedField{Table: "participant", Column: "prename"}
+	participantSurname = EncryptedField{Table: "participant", Column: "surname"}
+	courseName         = EncryptedField{Table: "course", Column: "name"}
+)
+
+// DecryptionError tells which field of which row could not be decrypted.
+type DecryptionError struct {
+	Table  string
+	ID     int
+	Column string
+	Err    error
+}
+
+func (e *DecryptionError) Error() string {
+	return fmt.Sprintf("could not decrypt %s of %s %d: %v", e.Column, e.Table, e.ID, e.Err)
+}
+
+func (e *DecryptionError) Unwrap() error {
+	return e.Err
+}
+
+// Encrypt encrypts the plaintext for the row with the given id.
+func (f EncryptedField) Encrypt(id int, plaintext string, secret crypt.Secret) (string, error) {
+	if id == 0 {
+		return "", ErrRowWithoutId
+	}
+
+	ciphertext, err := crypt.Encrypt(plaintext, secret, f.associatedData(id))
+	if err != nil {
+		return "", err
+	}
+
+	return boundPrefix + ciphertext, nil
+}
+
+// Decrypt decrypts the ciphertext of the row with the given id. It fails with a DecryptionError if the ciphertext
+// was tampered with, belongs to another row or column, or is not bound yet.
+func (f EncryptedField) Decrypt(id int, ciphertext string, secret crypt.Secret) (string, error) {
+	if ciphertext == "" {
+		return "", nil
+	}
+
+	bound, ok := strings.CutPrefix(ciphertext, boundPrefix)
+	if !ok {
+		return "", f.decryptionError(id, ErrUnboundCiphertext)
+	}
+
+	plaintext, err := crypt.Decrypt(bound, secret, f.associatedData(id))
+	if err != nil {
+		return "", f.decryptionError(id, err)
+	}
+
+	return plaintext, nil
+}
+
+// Bind re-encrypts a ciphertext that is not bound to its row yet and reports whether it did. Such ciphertexts are
+// trusted once, so this must only run on data of the session itself.
+func (f EncryptedField) Bind(id int, ciphertext string, secret crypt.Secret) (string, bool, error) {
+	if ciphertext == "" || strings.HasPrefix(ciphertext, boundPrefix) {
+		return ciphertext, false, nil
+	}
+
+	plaintext, err := crypt.Decrypt(ciphertext, secret, nil)
+	if err != nil {
+		return ciphertext, false, f.decryptionError(id, err)
+	}
+
+	bound, err := f.Encrypt(id, plaintext, secret)
+
+	return bound, err == nil, err
+}
+
+// BlindIndex returns an index of the plaintext for lookups and unique constraints. Unlike ciphertexts, it does not
+// depend on the row, so that equal plaintexts in different rows have equal indexes.
+func (f EncryptedField) BlindIndex(plaintext string, secret crypt.Secret) string {
+	return crypt.BlindIndex(plaintext, secret, fmt.Appendf(nil, "%s/%s", f.Table, f.Column))
+}
+
+func (f EncryptedField) associatedData(id int) []byte {
+	return fmt.Appendf(nil, "%s/%d/%s", f.Table, id, f.Column)
+}
+
+func (f EncryptedField) decryptionError(id int, err error) error {
+	return &DecryptionError{Table: f.Table, ID: id, Column: f.Column, Err: err}
+}
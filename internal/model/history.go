@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// HistoryEntry records the rows a single action changed, so that the action can be undone and redone.
+type HistoryEntry struct {
+	ID        int
+	CreatedAt time.Time
+	Action    string
+	// Summary tells how many rows were changed. It must not contain names, since it is stored unencrypted.
+	Summary string
+	// Changes holds the images of the changed rows before and after the action as JSON.
+	Changes string
+	// Undone entries can be redone until the next action is recorded.
+	Undone bool `gorm:"index"`
+}
@@ -27,7 +27,9 @@ type candidatePrioList struct {
 	cidOrdered []domain.CourseID
 }
 
-func LoadScenarioFromExcelFile(fileReader io.Reader) (*domain.Scenario, error) {
+// LoadScenarioFromExcelFile reads a scenario from an Excel file. Sheets beyond the limits are refused with a
+// SheetLimitError.
+func LoadScenarioFromExcelFile(fileReader io.Reader, limits SheetLimits) (*domain.Scenario, error) {
 	scenario := domain.EmptyScenario()
 	var candidateAssignments []candidateAssignment
 	var candidatePrioLists []candidatePrioList
@@ -36,10 +38,13 @@ func LoadScenarioFromExcelFile(fileReader io.Reader) (*domain.Scenario, error) {
 	if err != nil {
 		return scenario, fmt.Errorf("failed to create Excel file from bytes: %w", err)
 	}
-	reader, err := newSheetReader(file, courseSheetName)
+	defer file.Close()
+
+	reader, err := newSheetReader(file, courseSheetName, limits)
 	if err != nil {
 		return scenario, fmt.Errorf("failed to create excel sheet reader: %w", err)
 	}
+	defer reader.close()
 
 	courseHeader, err := reader.read()
 	if err != nil && err != io.EOF {
@@ -61,10 +66,11 @@ func LoadScenarioFromExcelFile(fileReader io.Reader) (*domain.Scenario, error) {
 		scenario.AddCourse(course)
 	}
 
-	reader, err = newSheetReader(file, participantsSheetName)
+	reader, err = newSheetReader(file, participantsSheetName, limits)
 	if err != nil {
 		return scenario, fmt.Errorf("failed to create excel sheet reader: %w", err)
 	}
+	defer reader.close()
 	participantHeader, err := reader.read()
 	if err != nil && err != io.EOF {
 		return scenario, err
@@ -2,6 +2,7 @@ package loadsave
 
 import (
 	"bytes"
+	"errors"
 	"testing"
 
 	"softbaer.dev/ass/internal/domain"
@@ -158,7 +159,7 @@ func TestMarshalModelsIsRoundTripConsistent(t *testing.T) {
 			is.NoErr(err) // exporting should not error
 			is.True(len(excelBytes) > 0)
 
-			imported, err := LoadScenarioFromExcelFile(bytes.NewReader(excelBytes))
+			imported, err := LoadScenarioFromExcelFile(bytes.NewReader(excelBytes), SheetLimits{})
 			is.NoErr(err) // importing should not error
 
 			var gotCourses []domain.CourseData
@@ -227,3 +228,39 @@ func TestMarshalModelsIsRoundTripConsistent(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadRefusesSheetsBeyondLimits(t *testing.T) {
+	scenario := buildScenario(
+		[]domain.CourseData{{ID: 1, Name: "foo", MinCapacity: 0, MaxCapacity: 5}, {ID: 2, Name: "bar", MinCapacity: 0, MaxCapacity: 5}},
+		[]domain.ParticipantData{{ID: 1, ParticipantName: domain.ParticipantName{Prename: "Eva", Surname: "Green"}}},
+		map[domain.ParticipantID]domain.CourseID{},
+		map[domain.ParticipantID][]domain.CourseID{1: {1, 2}},
+	)
+
+	testcases := []struct {
+		name   string
+		limits SheetLimits
+		wantOk bool
+	}{
+		{"Within limits", SheetLimits{MaxRows: 3, MaxColumns: 6}, true},
+		{"Too many rows", SheetLimits{MaxRows: 2}, false},
+		{"Too many columns", SheetLimits{MaxColumns: 5}, false},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			is := is.New(t)
+
+			excelBytes, err := SaveScenarioToExcelFile(scenario)
+			is.NoErr(err)
+
+			_, err = LoadScenarioFromExcelFile(bytes.NewReader(excelBytes), tc.limits)
+
+			var limitErr SheetLimitError
+			is.Equal(errors.As(err, &limitErr), !tc.wantOk)
+			if tc.wantOk {
+				is.NoErr(err)
+			}
+		})
+	}
+}
@@ -3,11 +3,15 @@ package loadsave
 import (
 	"bytes"
 	"fmt"
+	"strconv"
 
 	"github.com/xuri/excelize/v2"
 	"softbaer.dev/ass/internal/domain"
 )
 
+// demandSheetName is only written for the user's information and ignored when loading.
+const demandSheetName = "Nachfrage"
+
 func SaveScenarioToExcelFile(scenario *domain.Scenario) ([]byte, error) {
 	var buf bytes.Buffer
 	var writer *sheetWriter
@@ -57,6 +61,14 @@ func SaveScenarioToExcelFile(scenario *domain.Scenario) ([]byte, error) {
 		}
 	}
 
+	if writer, err = newSheetWriter(file, demandSheetName); err != nil {
+		return buf.Bytes(), err
+	}
+
+	if err := writeDemandSheet(writer, scenario); err != nil {
+		return nil, err
+	}
+
 	if writer, err = newSheetWriter(file, versionSheetName); err != nil {
 		return buf.Bytes(), err
 	}
@@ -72,3 +84,34 @@ func SaveScenarioToExcelFile(scenario *domain.Scenario) ([]byte, error) {
 
 	return buf.Bytes(), nil
 }
+
+// writeDemandSheet exports the course demand analytics. The sheet is informational only and ignored when loading.
+func writeDemandSheet(writer *sheetWriter, scenario *domain.Scenario) error {
+	header := []string{"Kurs", "Minimale Kapazität", "Maximale Kapazität"}
+	for i := range scenario.MaxAmountOfPriorities() {
+		header = append(header, nthPriorityColumnHeader(i+1))
+	}
+	header = append(header, "Gewichtete Nachfrage", "Verhältnis zu maximaler Kapazität", "Prognose")
+
+	if err := writer.write(header); err != nil {
+		return err
+	}
+
+	for _, demand := range domain.AnalyzeDemand(scenario) {
+		row := []string{demand.Course.Name, strconv.Itoa(demand.Course.MinCapacity), strconv.Itoa(demand.Course.MaxCapacity)}
+		for _, count := range demand.CountsByLevel {
+			row = append(row, strconv.Itoa(count))
+		}
+		row = append(row,
+			strconv.FormatFloat(demand.WeightedDemand, 'f', 2, 64),
+			strconv.FormatFloat(demand.Ratio, 'f', 2, 64),
+			demand.Outlook.Label(),
+		)
+
+		if err := writer.write(row); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
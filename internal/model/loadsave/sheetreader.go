@@ -1,19 +1,39 @@
 package loadsave
 
 import (
+	"fmt"
 	"io"
 
 	"github.com/xuri/excelize/v2"
 )
 
+// SheetLimits bounds how many rows and columns are read from a sheet. Rows are streamed, so that sheets beyond the
+// limits are refused before they are held in memory completely. Zero values mean no limit.
+type SheetLimits struct {
+	MaxRows    int
+	MaxColumns int
+}
+
+// SheetLimitError is returned if a sheet exceeds the SheetLimits. Its message is meant to be shown to the user.
+type SheetLimitError struct {
+	message string
+}
+
+func (e SheetLimitError) Error() string {
+	return e.message
+}
+
 type sheetReader struct {
-	file       *excelize.File
-	sheetName  string
-	currentRow int
-	cells      [][]string
+	rows      *excelize.Rows
+	sheetName string
+	limits    SheetLimits
+	rowsRead  int
+	// Empty rows are only passed on if a non-empty row follows, since trailing empty rows are no records.
+	emptyRows int
+	buffered  []string
 }
 
-func newSheetReader(file *excelize.File, sheetName string) (*sheetReader, error) {
+func newSheetReader(file *excelize.File, sheetName string, limits SheetLimits) (*sheetReader, error) {
 	index, err := file.GetSheetIndex(sheetName)
 	if err != nil {
 		return nil, err
@@ -24,23 +44,61 @@ func newSheetReader(file *excelize.File, sheetName string) (*sheetReader, error)
 		}
 	}
 
-	cells, err := file.GetRows(sheetName)
+	rows, err := file.Rows(sheetName)
 	if err != nil {
 		return nil, err
 	}
 	return &sheetReader{
-		file:       file,
-		sheetName:  sheetName,
-		currentRow: 1,
-		cells:      cells,
+		rows:      rows,
+		sheetName: sheetName,
+		limits:    limits,
 	}, nil
 }
 
 func (sr *sheetReader) read() ([]string, error) {
-	if sr.currentRow > len(sr.cells) {
-		return nil, io.EOF
+	if sr.emptyRows > 0 {
+		sr.emptyRows--
+		return []string{}, nil
 	}
-	result := sr.cells[sr.currentRow-1]
-	sr.currentRow++
-	return result, nil
+	if sr.buffered != nil {
+		result := sr.buffered
+		sr.buffered = nil
+		return result, nil
+	}
+
+	emptyRows := 0
+	for sr.rows.Next() {
+		sr.rowsRead++
+		if sr.limits.MaxRows > 0 && sr.rowsRead > sr.limits.MaxRows {
+			return nil, SheetLimitError{fmt.Sprintf("Das Tabellenblatt %s darf höchstens %d Zeilen enthalten.", sr.sheetName, sr.limits.MaxRows)}
+		}
+
+		result, err := sr.rows.Columns()
+		if err != nil {
+			return nil, err
+		}
+		if sr.limits.MaxColumns > 0 && len(result) > sr.limits.MaxColumns {
+			return nil, SheetLimitError{fmt.Sprintf("Das Tabellenblatt %s darf höchstens %d Spalten enthalten.", sr.sheetName, sr.limits.MaxColumns)}
+		}
+
+		if len(result) == 0 {
+			emptyRows++
+			continue
+		}
+		if emptyRows == 0 {
+			return result, nil
+		}
+
+		sr.emptyRows, sr.buffered = emptyRows-1, result
+		return []string{}, nil
+	}
+
+	if err := sr.rows.Error(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+func (sr *sheetReader) close() error {
+	return sr.rows.Close()
 }
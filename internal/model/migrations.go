@@ -0,0 +1,201 @@
+package model
+
+import (
+	"database/sql"
+	"time"
+
+	"gorm.io/gorm"
+	"softbaer.dev/ass/internal/dbdir"
+)
+
+// Migrations evolve the schema of session dbs. Append new migrations at the end and never change applied ones,
+// because dbs of running sessions already recorded them.
+//
+// Migrations must not use the model structs of this package, since those change over time. They use snapshots of the
+// structs as they were at the time of the migration instead.
+var Migrations = []dbdir.Migration{
+	{
+		Version:     1,
+		Description: "create courses, participants and priorities",
+		Up: func(tx *gorm.DB) error {
+			// Dbs created before versioned migrations already have these tables. AutoMigrate only adds what is missing.
+			return tx.AutoMigrate(&courseV1{}, &participantV1{}, &priorityV1{})
+		},
+	},
+	{
+		Version:     2,
+		Description: "keep plaintext names of participants until they are encrypted",
+		Up: func(tx *gorm.DB) error {
+			// Before names were encrypted, they were stored in plain text. Only the user's cookie holds the secret, so
+			// BindCiphertexts encrypts them on the first request and clears the plain columns.
+			for _, column := range []string{"prename", "surname"} {
+				if !tx.Migrator().HasColumn("participants", column) {
+					continue
+				}
+
+				if err := tx.Exec("ALTER TABLE participants RENAME COLUMN " + column + " TO plain_" + column).Error; err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	},
+	{
+		Version:     3,
+		Description: "create history entries",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&historyEntryV3{})
+		},
+	},
+	{
+		Version:     4,
+		Description: "create snapshots",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&snapshotV4{})
+		},
+	},
+	{
+		Version:     5,
+		Description: "create session keys",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&sessionKeyV5{})
+		},
+	},
+	{
+		Version:     6,
+		Description: "track names whose ciphertexts are not bound to their row yet",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(&ciphertextBindingV6{}); err != nil {
+				return err
+			}
+
+			// Names can also hide in the history and in snapshots. New dbs start without any.
+			pending, err := hasRows(tx, "participants", "history_entries", "snapshots")
+			if err != nil {
+				return err
+			}
+
+			return tx.Create(&ciphertextBindingV6{Pending: pending}).Error
+		},
+	},
+	{
+		Version:     7,
+		Description: "encrypt course names",
+		Up: func(tx *gorm.DB) error {
+			// The plain names are kept until they are encrypted with the secret from the user's cookie. The column
+			// can not be dropped afterward, since it is unique, so encrypting a name clears it instead.
+			if tx.Migrator().HasColumn("courses", "name") {
+				if err := tx.Exec("ALTER TABLE courses RENAME COLUMN name TO plain_name").Error; err != nil {
+					return err
+				}
+			}
+
+			// Existing rows get no name index until their names are encrypted. Unlike empty strings, NULLs do not
+			// violate the unique index.
+			statements := []string{
+				"ALTER TABLE courses ADD COLUMN encrypted_name text",
+				"ALTER TABLE courses ADD COLUMN name_index text",
+				"CREATE UNIQUE INDEX idx_courses_name_index ON courses(name_index)",
+			}
+			for _, statement := range statements {
+				if err := tx.Exec(statement).Error; err != nil {
+					return err
+				}
+			}
+
+			pending, err := hasRows(tx, "courses", "history_entries", "snapshots")
+			if err != nil || !pending {
+				return err
+			}
+
+			return tx.Table("ciphertext_bindings").Where("1 = 1").Update("pending", true).Error
+		},
+	},
+}
+
+// hasRows reports whether any of the tables has rows.
+func hasRows(tx *gorm.DB, tables ...string) (bool, error) {
+	for _, table := range tables {
+		var count int64
+		if err := tx.Table(table).Count(&count).Error; err != nil {
+			return false, err
+		}
+		if count > 0 {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+type courseV1 struct {
+	gorm.Model
+	ID           int
+	Name         string `gorm:"unique"`
+	MaxCapacity  int
+	MinCapacity  int
+	Participants []participantV1 `gorm:"foreignKey:CourseID"`
+}
+
+func (courseV1) TableName() string { return "courses" }
+
+type participantV1 struct {
+	gorm.Model
+	ID               int
+	EncryptedPrename string
+	EncryptedSurname string
+	CourseID         sql.NullInt64
+	Course           courseV1 `gorm:"constraint:OnDelete:SET NULL;"`
+}
+
+func (participantV1) TableName() string { return "participants" }
+
+type priorityV1 struct {
+	gorm.Model
+	Level         PriorityLevel
+	CourseID      int
+	ParticipantID int
+	Course        courseV1
+	Participant   participantV1
+}
+
+func (priorityV1) TableName() string { return "priorities" }
+
+type historyEntryV3 struct {
+	ID        int
+	CreatedAt time.Time
+	Action    string
+	Summary   string
+	Changes   string
+	Undone    bool `gorm:"index"`
+}
+
+func (historyEntryV3) TableName() string { return "history_entries" }
+
+type snapshotV4 struct {
+	ID               int
+	CreatedAt        time.Time
+	Name             string
+	Automatic        bool
+	CourseCount      int
+	ParticipantCount int
+	Rows             string
+}
+
+func (snapshotV4) TableName() string { return "snapshots" }
+
+type sessionKeyV5 struct {
+	ID        int
+	CreatedAt time.Time
+	Wrapped   []byte
+}
+
+func (sessionKeyV5) TableName() string { return "session_keys" }
+
+type ciphertextBindingV6 struct {
+	ID      int
+	Pending bool
+}
+
+func (ciphertextBindingV6) TableName() string { return "ciphertext_bindings" }
@@ -24,24 +24,37 @@ type Participant struct {
 type ParticipantOption func(*Participant)
 
 // NewParticipant creates a new participant model it will encrypt the names passed as arguments, so that they
-// will be stored in encrypted form on the db. To set anything other than the names use the opts.
+// will be stored in encrypted form on the db. To set anything other than the names use the opts. Since the names are
+// bound to the id of the participant, WithParticipantId is required. Use SetNames for participants that get their id
+// from the db.
 func NewParticipant(plainPrename, plainSurname string, secret crypt.Secret, opts ...ParticipantOption) (Participant, error) {
-	encryptedPrename, err := crypt.Encrypt(plainPrename, secret)
-	if err != nil {
-		return Participant{}, err
+	result := &Participant{}
+
+	for _, opt := range opts {
+		opt(result)
 	}
-	encryptedSurname, err := crypt.Encrypt(plainSurname, secret)
-	if err != nil {
+
+	if err := result.SetNames(plainPrename, plainSurname, secret); err != nil {
 		return Participant{}, err
 	}
 
-	result := &Participant{EncryptedSurname: encryptedSurname, EncryptedPrename: encryptedPrename}
+	return *result, nil
+}
 
-	for _, opt := range opts {
-		opt(result)
+// SetNames encrypts the names and binds them to the id of the participant, so the id has to be set before.
+func (p *Participant) SetNames(plainPrename, plainSurname string, secret crypt.Secret) error {
+	encryptedPrename, err := participantPrename.Encrypt(p.ID, plainPrename, secret)
+	if err != nil {
+		return err
+	}
+	encryptedSurname, err := participantSurname.Encrypt(p.ID, plainSurname, secret)
+	if err != nil {
+		return err
 	}
 
-	return *result, nil
+	p.EncryptedPrename, p.EncryptedSurname = encryptedPrename, encryptedSurname
+
+	return nil
 }
 
 func WithParticipantId(id int) ParticipantOption {
@@ -78,11 +91,41 @@ func (p *Participant) Valid() map[string]string {
 }
 
 func (p *Participant) Prename(secret crypt.Secret) (string, error) {
-	return crypt.Decrypt(p.EncryptedPrename, secret)
+	return participantPrename.Decrypt(p.ID, p.EncryptedPrename, secret)
 }
 
 func (p *Participant) Surname(secret crypt.Secret) (string, error) {
-	return crypt.Decrypt(p.EncryptedSurname, secret)
+	return participantSurname.Decrypt(p.ID, p.EncryptedSurname, secret)
+}
+
+// Rekey re-encrypts the names, which are encrypted with oldSecret, with newSecret.
+func (p *Participant) Rekey(oldSecret, newSecret crypt.Secret) error {
+	prename, err := p.Prename(oldSecret)
+	if err != nil {
+		return err
+	}
+	surname, err := p.Surname(oldSecret)
+	if err != nil {
+		return err
+	}
+
+	return p.SetNames(prename, surname, newSecret)
+}
+
+// BindCiphertexts binds names that were encrypted before ciphertexts were bound to their row. It reports whether any
+// name changed.
+func (p *Participant) BindCiphertexts(secret crypt.Secret) (bool, error) {
+	var boundPrename, boundSurname bool
+	var err error
+
+	if p.EncryptedPrename, boundPrename, err = participantPrename.Bind(p.ID, p.EncryptedPrename, secret); err != nil {
+		return false, err
+	}
+	if p.EncryptedSurname, boundSurname, err = participantSurname.Bind(p.ID, p.EncryptedSurname, secret); err != nil {
+		return false, err
+	}
+
+	return boundPrename || boundSurname, nil
 }
 
 func (p *Participant) TrimFields() {
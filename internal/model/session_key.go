@@ -0,0 +1,11 @@
+package model
+
+import "time"
+
+// SessionKey holds the secret of a passphrase-protected session, wrapped with a key derived from the passphrase.
+// There is at most one per session db.
+type SessionKey struct {
+	ID        int
+	CreatedAt time.Time
+	Wrapped   []byte
+}
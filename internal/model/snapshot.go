@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// Snapshot keeps a named copy of all courses, participants and priorities of a session.
+type Snapshot struct {
+	ID        int
+	CreatedAt time.Time
+	Name      string
+	// Automatic snapshots are taken before the scenario is overwritten, e.g. by loading an Excel file.
+	Automatic        bool
+	CourseCount      int
+	ParticipantCount int
+	// Rows holds the copied rows as JSON. Names of participants stay encrypted.
+	Rows string
+}
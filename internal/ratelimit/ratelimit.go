@@ -0,0 +1,89 @@
+// Package ratelimit throttles clients with a token bucket per key, e.g. per session or per IP.
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+)
+
+// pruneInterval is how often buckets that are full again are dropped, so that idle clients do not use memory.
+const pruneInterval = time.Minute
+
+// Limiter allows each key up to perMinute requests per minute. A bucket holds up to perMinute tokens, so that short
+// bursts are fine as long as the average stays below the limit. A Limiter with a limit of zero allows everything.
+type Limiter struct {
+	clock     clockwork.Clock
+	perMinute int
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastPrune time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+func New(perMinute int, clock clockwork.Clock) *Limiter {
+	return &Limiter{
+		clock:     clock,
+		perMinute: perMinute,
+		buckets:   make(map[string]*bucket),
+		lastPrune: clock.Now(),
+	}
+}
+
+// Allow takes a token from the bucket of key. If the bucket is empty, it returns false and how long the client has
+// to wait for the next token.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	if l.perMinute <= 0 {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	l.prune(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.perMinute), updated: now}
+		l.buckets[key] = b
+	}
+	b.refill(now, l.perMinute)
+
+	if b.tokens < 1 {
+		missing := 1 - b.tokens
+		return false, time.Duration(math.Ceil(missing * float64(time.Minute) / float64(l.perMinute)))
+	}
+
+	b.tokens--
+
+	return true, 0
+}
+
+func (b *bucket) refill(now time.Time, perMinute int) {
+	elapsed := now.Sub(b.updated)
+	b.tokens = math.Min(float64(perMinute), b.tokens+elapsed.Minutes()*float64(perMinute))
+	b.updated = now
+}
+
+// prune drops the buckets that are full again. They behave exactly like buckets that were never used.
+func (l *Limiter) prune(now time.Time) {
+	if now.Sub(l.lastPrune) < pruneInterval {
+		return
+	}
+
+	for key, b := range l.buckets {
+		b.refill(now, l.perMinute)
+		if b.tokens >= float64(l.perMinute) {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastPrune = now
+}
@@ -0,0 +1,55 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/matryer/is"
+)
+
+func TestLimiterRefusesBeyondBurstAndRefills(t *testing.T) {
+	is := is.New(t)
+	clock := clockwork.NewFakeClock()
+	limiter := New(3, clock)
+
+	for range 3 {
+		ok, _ := limiter.Allow("client")
+		is.True(ok) // the bucket starts full
+	}
+
+	ok, retryAfter := limiter.Allow("client")
+	is.True(!ok)                         // the bucket is empty
+	is.Equal(retryAfter, 20*time.Second) // one token per 20 seconds
+
+	ok, _ = limiter.Allow("other client")
+	is.True(ok) // every key has its own bucket
+
+	clock.Advance(20 * time.Second)
+	ok, _ = limiter.Allow("client")
+	is.True(ok) // one token was refilled
+	ok, _ = limiter.Allow("client")
+	is.True(!ok)
+}
+
+func TestLimiterWithoutLimitAllowsEverything(t *testing.T) {
+	is := is.New(t)
+	limiter := New(0, clockwork.NewFakeClock())
+
+	for range 100 {
+		ok, _ := limiter.Allow("client")
+		is.True(ok)
+	}
+}
+
+func TestLimiterDropsIdleBuckets(t *testing.T) {
+	is := is.New(t)
+	clock := clockwork.NewFakeClock()
+	limiter := New(60, clock)
+
+	limiter.Allow("idle client")
+	clock.Advance(pruneInterval)
+	limiter.Allow("client")
+
+	is.Equal(len(limiter.buckets), 1) // the idle bucket was full again and could be dropped
+}
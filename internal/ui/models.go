@@ -15,3 +15,98 @@ type Participant struct {
 func (p Participant) Id() int {
 	return p.ID
 }
+
+type Diagnostic struct {
+	Check    string
+	Passed   bool
+	Blocking bool
+	Issues   []string
+}
+
+type CourseDemand struct {
+	Name           string
+	MinCapacity    int
+	MaxCapacity    int
+	CountsByLevel  []int
+	WeightedDemand string
+	Ratio          string
+	Outlook        string
+	// Highlighted is true if the course will certainly overflow or be cancelled.
+	Highlighted bool
+}
+
+type ExplainedCourse struct {
+	Name        string
+	Level       int
+	Allocation  int
+	MaxCapacity int
+	Full        bool
+	Occupants   []string
+}
+
+type Explanation struct {
+	ParticipantName  string
+	AssignedCourse   string
+	AssignedLevel    int
+	HigherPriorities []ExplainedCourse
+	Improvements     []string
+}
+
+type SessionExpiration struct {
+	Extendable bool
+}
+
+type RetentionChoice struct {
+	Seconds  int
+	Label    string
+	Selected bool
+}
+
+type HistoryEntry struct {
+	Label   string
+	Time    string
+	Summary string
+	Undone  bool
+}
+
+type History struct {
+	Entries []HistoryEntry
+	CanUndo bool
+	CanRedo bool
+}
+
+type Snapshot struct {
+	ID               int
+	Name             string
+	CreatedAt        string
+	Automatic        bool
+	CourseCount      int
+	ParticipantCount int
+}
+
+func (s Snapshot) Id() int {
+	return s.ID
+}
+
+// CourseComparison shows a course in two snapshots. Left and Right are empty if the course is missing in a snapshot.
+type CourseComparison struct {
+	Name    string
+	Left    string
+	Right   string
+	Changed bool
+}
+
+type ParticipantComparison struct {
+	Name  string
+	Left  string
+	Right string
+}
+
+type SnapshotComparison struct {
+	Left            Snapshot
+	Right           Snapshot
+	UnassignedLeft  int
+	UnassignedRight int
+	Courses         []CourseComparison
+	Participants    []ParticipantComparison
+}
@@ -63,3 +63,18 @@ func (c *Config) SetParamValue(k, v string) {
 func (c *Config) Z3Value() C.Z3_config {
 	return c.raw
 }
+
+// SetGlobalParam sets a global Z3 parameter such as "smt.random_seed".
+// Global parameters affect every context and solver created afterwards,
+// so callers have to make sure no other solving happens concurrently.
+//
+// Maps to: Z3_global_param_set
+func SetGlobalParam(k, v string) {
+	ck := C.CString(k)
+	cv := C.CString(v)
+
+	defer C.free(unsafe.Pointer(ck))
+	defer C.free(unsafe.Pointer(cv))
+
+	C.Z3_global_param_set(ck, cv)
+}
@@ -2,6 +2,7 @@ package apptest
 
 import (
 	"context"
+	"net/http"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -131,6 +132,40 @@ func TestDataIsPersistedBetweenDeployments(t *testing.T) {
 	is.Equal(courses[0].MaxCapacity, expectedCourse.MaxCapacity)
 }
 
+func TestSessionsSurviveSecretRotation(t *testing.T) {
+	is := is.New(t)
+
+	dbDir := MakeTestingDbDir(t)
+	envPairs := []string{"PRIOBAER_DB_ROOT_DIR", dbDir, "PRIOBAER_SESSION_MAX_AGE", strconv.Itoa(maxAgeDefault), "PRIOBAER_PORT", strconv.Itoa(port)}
+	deploy := func(pairs ...string) context.CancelFunc {
+		ctx, cancel := context.WithCancel(context.Background())
+		go server.Run(ctx, setupMockEnv(append(envPairs, pairs...)...), defaultFakeClock())
+		is.NoErr(defaultWaitForReady()) // Service was not ready
+		return cancel
+	}
+
+	cancel := deploy("PRIOBAER_SECRET", "old secret")
+	testClient := NewTestClient(t, localhost)
+	expectedCourse := ui.RandomCourse()
+	testClient.CoursesCreateAction(expectedCourse, nil)
+	waitForTerminationDefault(cancel)
+
+	cancel = deploy("PRIOBAER_SECRET", "new secret", "PRIOBAER_PREVIOUS_SECRETS", "old secret")
+	resp, err := testClient.client.Get(testClient.Endpoint("scenario"))
+	is.NoErr(err)
+	resp.Body.Close()
+	is.Equal(resp.StatusCode, http.StatusOK) // cookie signed with the previous secret should be accepted
+	testClient.storeCookies(resp)
+	waitForTerminationDefault(cancel)
+
+	cancel = deploy("PRIOBAER_SECRET", "new secret")
+	defer waitForTerminationDefault(cancel)
+
+	courses := testClient.CoursesIndexAction()
+	is.Equal(len(courses), 1) // cookie should have been signed with the new secret in the meantime
+	is.Equal(courses[0].Name, expectedCourse.Name)
+}
+
 func TestCreateAndReadCourse(t *testing.T) {
 	is := is.New(t)
 
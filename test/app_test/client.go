@@ -25,6 +25,15 @@ type TestClient struct {
 }
 
 func NewTestClient(t *testing.T, baseUrl string) *TestClient {
+	testClient := NewTestClientWithoutSession(t, baseUrl)
+	testClient.AcquireSessionCookie()
+
+	return testClient
+}
+
+// NewTestClientWithoutSession returns a client that visited the start page, so that it holds a CSRF token, but did
+// not start a session yet.
+func NewTestClientWithoutSession(t *testing.T, baseUrl string) *TestClient {
 	is := is.New(t)
 	jar, err := cookiejar.New(nil)
 	is.NoErr(err) // create cookie jar failed
@@ -33,14 +42,21 @@ func NewTestClient(t *testing.T, baseUrl string) *TestClient {
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
 			return http.ErrUseLastResponse
 		},
-		Jar: jar,
+		Jar:       jar,
+		Transport: &csrfTransport{},
 	}
 
 	baseUrlParsed, err := url.Parse(baseUrl)
 	is.NoErr(err) // could not parse baseUrl
 
 	testClient := TestClient{T: t, client: &client, baseUrl: baseUrlParsed}
-	testClient.AcquireSessionCookie()
+
+	resp, err := client.Get(testClient.Endpoint("sessions/new"))
+	is.NoErr(err) // get request failed
+	defer resp.Body.Close()
+	is.Equal(resp.StatusCode, 200)
+
+	testClient.storeCookies(resp)
 
 	return &testClient
 }
@@ -54,17 +70,110 @@ func (c *TestClient) AcquireSessionCookie() {
 
 	is.Equal(resp.StatusCode, 303)
 
-	cookies := resp.Cookies()
-	is.Equal(len(cookies), 1)
+	hasSessionCookie := slices.ContainsFunc(resp.Cookies(), func(cookie *http.Cookie) bool { return cookie.Name == "session" })
+	is.True(hasSessionCookie)
 
 	// Workaround to send cookies along although we are testing with a non-secure local http-server
+	c.storeCookies(resp)
+}
+
+// csrfTransport sends the CSRF token along with state-changing requests, like index.js does in the browser. It picks
+// up the token from the cookie the server mirrors it into. Requests that set the header themselves keep theirs.
+type csrfTransport struct {
+	mu    sync.Mutex
+	token string
+}
+
+func (t *csrfTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	token := t.token
+	t.mu.Unlock()
+
+	if req.Method != http.MethodGet && req.Method != http.MethodHead && req.Header.Get("X-CSRF-Token") == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("X-CSRF-Token", token)
+	}
+
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, cookie := range resp.Cookies() {
+		if cookie.Name == "csrf_token" {
+			t.mu.Lock()
+			t.token = cookie.Value
+			t.mu.Unlock()
+		}
+	}
+
+	return resp, nil
+}
+
+// RecoveryCodeAction returns a recovery code of the current session, wrapped with the passphrase.
+func (c *TestClient) RecoveryCodeAction(passphrase string) string {
+	is := is.New(c.T)
+
+	resp, err := c.client.Do(c.RequestWithFormBody("POST", c.Endpoint("sessions/recovery"), "passphrase", passphrase))
+	is.NoErr(err) // post request failed
+	defer resp.Body.Close()
+	is.Equal(resp.StatusCode, 200) // want the recovery code to be shown
+
+	code, err := unmarshalTextOf(resp.Body, "recovery-code")
+	is.NoErr(err)
+
+	return code
+}
+
+// SessionResumeAction resumes the session of the recovery code and returns the status code of the response.
+func (c *TestClient) SessionResumeAction(code, passphrase string) int {
+	return c.postFormStoringCookies("sessions/resume", "code", code, "passphrase", passphrase)
+}
+
+func (c *TestClient) SetPassphraseAction(passphrase, confirmation string) int {
+	return c.postFormStoringCookies("sessions/passphrase", "passphrase", passphrase, "confirmation", confirmation)
+}
+
+func (c *TestClient) LockAction() int {
+	return c.postFormStoringCookies("sessions/lock")
+}
+
+func (c *TestClient) UnlockAction(passphrase string) int {
+	return c.postFormStoringCookies("sessions/unlock", "passphrase", passphrase)
+}
+
+// postFormStoringCookies posts the form and keeps the cookies of the response, since the handlers behind it change
+// the secret or the session.
+func (c *TestClient) postFormStoringCookies(path string, args ...string) int {
+	is := is.New(c.T)
+
+	resp, err := c.client.Do(c.RequestWithFormBody("POST", c.Endpoint(path), args...))
+	is.NoErr(err) // post request failed
+	defer resp.Body.Close()
+
+	c.storeCookies(resp)
+
+	return resp.StatusCode
+}
+
+// storeCookies keeps the cookies of the response, although they are meant for https only.
+func (c *TestClient) storeCookies(resp *http.Response) {
+	cookies := resp.Cookies()
 	for _, cookie := range cookies {
 		cookie.Secure = false
 	}
-
 	c.client.Jar.SetCookies(c.baseUrl, cookies)
 }
 
+// dropCookie removes the cookie from the client, as browsers do with session cookies when they are closed.
+func (c *TestClient) dropCookie(name string) {
+	c.client.Jar.SetCookies(c.baseUrl, []*http.Cookie{{Name: name, Path: "/", MaxAge: -1}})
+}
+
+func (c *TestClient) RekeyAction(passphrase string) int {
+	return c.postFormStoringCookies("sessions/rekey", "passphrase", passphrase)
+}
+
 func (c *TestClient) ParticipantsCreateAction(participant ui.Participant, prioritizedCourseIDs []int, finish *sync.WaitGroup) ui.Participant {
 	// TODO: I want to get rid of paricipant.Priority member and replace it with a map[int]int, therefore I need to change this functions signature and its usages
 	if finish != nil {
@@ -220,8 +329,8 @@ func (c *TestClient) SolveAssignmentsAction() {
 	req, err := http.NewRequest("PUT", c.Endpoint("assignments"), nil)
 	is.NoErr(err) // want to create request successfully
 	resp, err := c.client.Do(req)
+	is.NoErr(err) // want request to be successful
 	defer resp.Body.Close()
-	is.NoErr(err)                  // want request to be successful
 	is.Equal(resp.StatusCode, 303) // want to be redirected with 303
 
 	loc := resp.Header.Get("Location")
@@ -331,6 +440,12 @@ func (c *TestClient) DataSaveAction() []byte {
 }
 
 func (c *TestClient) DataLoadAction(data []byte) {
+	is := is.New(c.T)
+	is.Equal(c.DataLoadStatusAction(data), 303)
+}
+
+// DataLoadStatusAction uploads the Excel file and returns the status code of the response.
+func (c *TestClient) DataLoadStatusAction(data []byte) int {
 	is := is.New(c.T)
 	body := &bytes.Buffer{}
 	writer := multipart.NewWriter(body)
@@ -346,7 +461,116 @@ func (c *TestClient) DataLoadAction(data []byte) {
 	resp, err := c.client.Do(req)
 	is.NoErr(err) // post request failed
 	defer resp.Body.Close()
-	is.Equal(resp.StatusCode, 303)
+
+	return resp.StatusCode
+}
+
+// ScenarioWarningsAction renders the scenario page and returns the verification warnings shown on it.
+func (c *TestClient) ScenarioWarningsAction() []string {
+	is := is.New(c.T)
+
+	resp, err := c.client.Get(c.Endpoint("scenario"))
+	is.NoErr(err)                  // get request failed
+	is.Equal(resp.StatusCode, 200) // get scenario did not return 200
+	defer resp.Body.Close()
+
+	warnings, err := unmarshalListItemsOf(resp.Body, "verification-warnings")
+	is.NoErr(err) // error while unmarshalling warnings
+
+	return warnings
+}
+
+// UndoAction undoes the latest change and returns the status code of the response.
+func (c *TestClient) UndoAction() int {
+	return c.postWithoutBody("history/undo")
+}
+
+// RedoAction redoes the latest undone change and returns the status code of the response.
+func (c *TestClient) RedoAction() int {
+	return c.postWithoutBody("history/redo")
+}
+
+// HistoryAction returns the entries of the history panel, newest first.
+func (c *TestClient) HistoryAction() []string {
+	is := is.New(c.T)
+
+	resp, err := c.client.Get(c.Endpoint("history"))
+	is.NoErr(err)                  // get request failed
+	is.Equal(resp.StatusCode, 200) // get history did not return 200
+	defer resp.Body.Close()
+
+	entries, err := unmarshalListItemsOf(resp.Body, "history-entries")
+	is.NoErr(err) // error while unmarshalling history entries
+
+	return entries
+}
+
+func (c *TestClient) SnapshotsCreateAction(name string) {
+	is := is.New(c.T)
+
+	resp, err := c.client.Do(c.RequestWithFormBody("POST", c.Endpoint("snapshots"), "name", name))
+	is.NoErr(err) // post request failed
+	defer resp.Body.Close()
+	is.Equal(resp.StatusCode, 303) // want to be redirected after saving a snapshot
+}
+
+func (c *TestClient) SnapshotsIndexAction() []ui.Snapshot {
+	is := is.New(c.T)
+
+	resp, err := c.client.Get(c.Endpoint("snapshots"))
+	is.NoErr(err)                  // get request failed
+	is.Equal(resp.StatusCode, 200) // get snapshots did not return 200
+	defer resp.Body.Close()
+
+	snapshots, err := unmarshalAll[ui.Snapshot](resp.Body, "snapshot-")
+	is.NoErr(err)
+
+	return snapshots
+}
+
+func (c *TestClient) SnapshotsRestoreAction(snapshotId int) {
+	is := is.New(c.T)
+
+	is.Equal(c.postWithoutBody(fmt.Sprintf("snapshots/%d/restore", snapshotId)), 303) // want to be redirected after restoring
+}
+
+// SnapshotsCompareAction returns the rows of the table of differently assigned participants.
+func (c *TestClient) SnapshotsCompareAction(leftId, rightId int) [][]string {
+	is := is.New(c.T)
+
+	resp, err := c.client.Get(c.Endpoint("snapshots/compare") + fmt.Sprintf("?left=%d&right=%d", leftId, rightId))
+	is.NoErr(err)                  // get request failed
+	is.Equal(resp.StatusCode, 200) // get comparison did not return 200
+	defer resp.Body.Close()
+
+	rows, err := unmarshalTableRowsOf(resp.Body, "compared-participants")
+	is.NoErr(err)
+
+	return rows
+}
+
+// SessionRemainingAction sends a request without body and returns the remaining seconds of the session it reports.
+func (c *TestClient) SessionRemainingAction(method, path string) string {
+	is := is.New(c.T)
+
+	req, err := http.NewRequest(method, c.Endpoint(path), nil)
+	is.NoErr(err) // could not build request
+	resp, err := c.client.Do(req)
+	is.NoErr(err) // request failed
+	defer resp.Body.Close()
+	is.Equal(resp.StatusCode, http.StatusOK)
+
+	return resp.Header.Get("X-Session-Remaining")
+}
+
+func (c *TestClient) postWithoutBody(path string) int {
+	is := is.New(c.T)
+
+	resp, err := c.client.Post(c.Endpoint(path), "", nil)
+	is.NoErr(err) // post request failed
+	defer resp.Body.Close()
+
+	return resp.StatusCode
 }
 
 func (c *TestClient) Endpoint(path string) string {
@@ -386,3 +610,37 @@ func BuildFormBody(args ...string) io.Reader {
 func SetHxRequest(req *http.Request) {
 	req.Header.Add("HX-Request", "true")
 }
+
+// SessionDeleteAction deletes all data of the session and returns the confirmation shown to the user.
+func (c *TestClient) SessionDeleteAction() string {
+	is := is.New(c.T)
+
+	req, err := http.NewRequest(http.MethodPost, c.Endpoint("sessions/delete"), nil)
+	is.NoErr(err)
+	SetHxRequest(req)
+
+	resp, err := c.client.Do(req)
+	is.NoErr(err)                  // post request failed
+	is.Equal(resp.StatusCode, 200) // delete session did not return 200
+	defer resp.Body.Close()
+
+	removedAt, err := unmarshalTextOf(resp.Body, "removed-at")
+	is.NoErr(err)            // error while unmarshalling confirmation
+	is.True(removedAt != "") // confirmation should tell when the data was removed
+
+	return removedAt
+}
+
+// csrfToken returns the CSRF token the client sends along with state-changing requests.
+func (c *TestClient) csrfToken() string {
+	transport := c.client.Transport.(*csrfTransport)
+	transport.mu.Lock()
+	defer transport.mu.Unlock()
+
+	return transport.token
+}
+
+// withoutCSRFToken returns a client that shares the cookies of c, but does not send the CSRF token on its own.
+func (c *TestClient) withoutCSRFToken() *http.Client {
+	return &http.Client{CheckRedirect: c.client.CheckRedirect, Jar: c.client.Jar}
+}
@@ -1,8 +1,10 @@
 package apptest
 
 import (
+	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/matryer/is"
@@ -22,8 +24,60 @@ func TestThatParticipantsNamesAreStoredEncrypted(t *testing.T) {
 	createdParticipant := ui.RandomParticipant()
 	client.ParticipantsCreateAction(createdParticipant, make([]int, 0), nil)
 
+	db := openSessionDb(t, sut.dbDir)
+
+	var participants []model.Participant
+	is.NoErr(db.Find(&participants).Error)                                  // Want querying against db to be successful
+	is.Equal(len(participants), 1)                                          // Want exactly one participant
+	is.True(participants[0].EncryptedPrename != createdParticipant.Prename) // Want that Name stored in DB is not what we created via client (i.e. value in DB is encrypted)
+	is.True(participants[0].EncryptedSurname != createdParticipant.Surname)
+}
+
+func TestThatCourseNamesAreStoredEncrypted(t *testing.T) {
+	is := is.New(t)
+	sut := StartupSystemUnderTest(t, nil)
+	defer waitForTerminationDefault(sut.cancel)
+
+	client := NewTestClient(t, localhost)
+
+	createdCourse := ui.RandomCourse()
+	client.CoursesCreateAction(createdCourse, nil)
+
+	db := openSessionDb(t, sut.dbDir)
+
+	var courses []model.Course
+	is.NoErr(db.Find(&courses).Error)
+	is.Equal(len(courses), 1)
+	is.True(!strings.Contains(courses[0].EncryptedName, createdCourse.Name)) // name must not be stored in plain text
+	is.True(!strings.Contains(courses[0].NameIndex, createdCourse.Name))
+	is.Equal(client.CoursesIndexAction()[0].Name, createdCourse.Name) // name should still be readable via the app
+}
+
+func TestCreatingCourseWithTakenNameFails(t *testing.T) {
+	is := is.New(t)
+	sut := StartupSystemUnderTest(t, nil)
+	defer waitForTerminationDefault(sut.cancel)
+
+	client := NewTestClient(t, localhost)
+
+	course := ui.RandomCourse()
+	client.CoursesCreateAction(course, nil)
+
+	req := client.RequestWithFormBody("POST", client.Endpoint("courses"), "name", " "+course.Name, "max-capacity", "10", "min-capacity", "1")
+	resp, err := client.client.Do(req)
+	is.NoErr(err)
+	defer resp.Body.Close()
+
+	is.Equal(resp.StatusCode, http.StatusUnprocessableEntity) // names must stay unique, even though they are encrypted
+	is.Equal(len(client.CoursesIndexAction()), 1)
+}
+
+// openSessionDb opens the db of the only session in dbDir directly.
+func openSessionDb(t *testing.T, dbDir string) *gorm.DB {
+	is := is.New(t)
+
 	var sqlFiles []string
-	err := filepath.Walk(sut.dbDir, func(path string, info os.FileInfo, err error) error {
+	err := filepath.Walk(dbDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
@@ -40,9 +94,5 @@ func TestThatParticipantsNamesAreStoredEncrypted(t *testing.T) {
 	db, err := gorm.Open(sqlite.Open(sqlFiles[0]), &gorm.Config{})
 	is.NoErr(err) // Want to be able to open sql file without problems
 
-	var participants []model.Participant
-	is.NoErr(db.Find(&participants).Error)                                  // Want querying against db to be successful
-	is.Equal(len(participants), 1)                                          // Want exactly one participant
-	is.True(participants[0].EncryptedPrename != createdParticipant.Prename) // Want that Name stored in DB is not what we created via client (i.e. value in DB is encrypted)
-	is.True(participants[0].EncryptedSurname != createdParticipant.Surname)
+	return db
 }
@@ -0,0 +1,124 @@
+package apptest
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+	"softbaer.dev/ass/internal/ui"
+)
+
+func TestUndoAndRedoAssignment(t *testing.T) {
+	is := is.New(t)
+
+	sut := StartupSystemUnderTest(t, nil)
+	defer waitForTerminationDefault(sut.cancel)
+
+	testClient := NewTestClient(t, localhost)
+
+	participant := testClient.ParticipantsCreateAction(ui.RandomParticipant(), nil, nil)
+	course := testClient.CoursesCreateAction(ui.RandomCourse(), nil)
+
+	testClient.InitialAssignAction(participant.ID, course.ID)
+
+	is.Equal(testClient.UndoAction(), http.StatusSeeOther)
+	_, unassigned := testClient.AssignmentsIndexAction()
+	is.Equal(len(unassigned), 1) // participant should be unassigned after undoing the assignment
+
+	is.Equal(testClient.RedoAction(), http.StatusSeeOther)
+	_, unassigned = testClient.AssignmentsIndexAction()
+	is.Equal(len(unassigned), 0) // participant should be assigned again after redoing the assignment
+}
+
+func TestUndoCourseDeleteRestoresAssignmentsAndPriorities(t *testing.T) {
+	is := is.New(t)
+
+	sut := StartupSystemUnderTest(t, nil)
+	defer waitForTerminationDefault(sut.cancel)
+
+	testClient := NewTestClient(t, localhost)
+
+	course := testClient.CoursesCreateAction(ui.RandomCourse(), nil)
+	participant := testClient.ParticipantsCreateAction(ui.RandomParticipant(), []int{course.ID}, nil)
+	testClient.InitialAssignAction(participant.ID, course.ID)
+
+	testClient.CoursesDeleteAction(course.ID)
+	is.Equal(len(testClient.CoursesIndexAction()), 0) // course should be gone after deleting it
+
+	is.Equal(testClient.UndoAction(), http.StatusSeeOther)
+
+	courses := testClient.CoursesIndexAction()
+	is.Equal(len(courses), 1)              // course should be back after undoing the delete
+	is.Equal(courses[0].ID, course.ID)     // course should keep its id
+	is.Equal(courses[0].Name, course.Name) // course should keep its name
+
+	_, participants := testClient.AssignmentsIndexAction("selected-course", strconv.Itoa(course.ID))
+	is.Equal(len(participants), 1)                                  // participant should be assigned to the restored course again
+	is.Equal(len(participants[0].Priorities), 1)                    // priority should be restored along with the course
+	is.Equal(participants[0].Priorities[0].CourseName, course.Name) // priority should point to the restored course
+}
+
+func TestUndoLoadRestoresPreviousScenario(t *testing.T) {
+	is := is.New(t)
+
+	sut := StartupSystemUnderTest(t, nil)
+	defer waitForTerminationDefault(sut.cancel)
+
+	testClient := NewTestClient(t, localhost)
+
+	testClient.CreateCoursesWithAllocationsAction([]int{1, 2})
+	saved := testClient.DataSaveAction()
+
+	for _, course := range testClient.CoursesIndexAction() {
+		testClient.CoursesDeleteAction(course.ID)
+	}
+	for _, participant := range testClient.ParticipantsIndexAction() {
+		testClient.ParticipantsDeleteAction(participant.ID)
+	}
+	testClient.CoursesCreateAction(ui.RandomCourse(), nil)
+
+	testClient.DataLoadAction(saved)
+	is.Equal(len(testClient.CoursesIndexAction()), 2) // loaded scenario should replace the single course
+
+	is.Equal(testClient.UndoAction(), http.StatusSeeOther)
+	is.Equal(len(testClient.CoursesIndexAction()), 1)      // scenario before loading should be back
+	is.Equal(len(testClient.ParticipantsIndexAction()), 0) // loaded participants should be gone
+}
+
+func TestRecordingDiscardsUndoneChanges(t *testing.T) {
+	is := is.New(t)
+
+	sut := StartupSystemUnderTest(t, nil)
+	defer waitForTerminationDefault(sut.cancel)
+
+	testClient := NewTestClient(t, localhost)
+
+	is.Equal(testClient.UndoAction(), http.StatusNoContent) // nothing to undo in a new session
+
+	testClient.CoursesCreateAction(ui.RandomCourse(), nil)
+	is.Equal(testClient.UndoAction(), http.StatusSeeOther)
+
+	testClient.CoursesCreateAction(ui.RandomCourse(), nil)
+	is.Equal(testClient.RedoAction(), http.StatusNoContent) // new change should discard the undone one
+	is.Equal(len(testClient.CoursesIndexAction()), 1)
+}
+
+func TestHistoryListsChangesNewestFirst(t *testing.T) {
+	is := is.New(t)
+
+	sut := StartupSystemUnderTest(t, nil)
+	defer waitForTerminationDefault(sut.cancel)
+
+	testClient := NewTestClient(t, localhost)
+
+	course := testClient.CoursesCreateAction(ui.RandomCourse(), nil)
+	testClient.CoursesDeleteAction(course.ID)
+	is.Equal(testClient.UndoAction(), http.StatusSeeOther)
+
+	entries := testClient.HistoryAction()
+	is.Equal(len(entries), 2)
+	is.True(strings.Contains(entries[0], "Kurs gelöscht")) // latest change should come first
+	is.True(strings.Contains(entries[1], "Kurs erstellt"))
+}
@@ -0,0 +1,135 @@
+package apptest
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+	"softbaer.dev/ass/internal/ui"
+)
+
+func TestUploadBeyondSizeLimitIsRefused(t *testing.T) {
+	is := is.New(t)
+	sut := StartupSystemUnderTestWithEnv(t, "PRIOBAER_MAX_UPLOAD_BYTES", "1000")
+	defer waitForTerminationDefault(sut.cancel)
+
+	client := NewTestClient(t, localhost)
+	client.CoursesCreateAction(ui.RandomCourse(), nil)
+	data := client.DataSaveAction()
+	is.True(len(data) > 1000) // the Excel file must exceed the limit for this test
+
+	is.Equal(client.DataLoadStatusAction(data), http.StatusRequestEntityTooLarge)
+	is.Equal(len(client.CoursesIndexAction()), 1) // the scenario must stay as it was
+}
+
+func TestUploadBeyondSheetLimitsIsRefused(t *testing.T) {
+	is := is.New(t)
+	sut := StartupSystemUnderTestWithEnv(t, "PRIOBAER_MAX_SHEET_ROWS", "2")
+	defer waitForTerminationDefault(sut.cancel)
+
+	client := NewTestClient(t, localhost)
+	for range 2 {
+		client.CoursesCreateAction(ui.RandomCourse(), nil)
+	}
+	data := client.DataSaveAction()
+
+	is.Equal(client.DataLoadStatusAction(data), http.StatusRequestEntityTooLarge) // header and two courses exceed two rows
+	is.Equal(len(client.CoursesIndexAction()), 2)
+}
+
+func TestRequestsBeyondSessionRateLimitAreRefused(t *testing.T) {
+	is := is.New(t)
+	sut := StartupSystemUnderTestWithEnv(t, "PRIOBAER_SESSION_RATE_LIMIT", "3")
+	defer waitForTerminationDefault(sut.cancel)
+
+	client := NewTestClient(t, localhost)
+	for range 3 {
+		client.CoursesCreateAction(ui.RandomCourse(), nil)
+	}
+
+	course := ui.RandomCourse()
+	req := client.RequestWithFormBody("POST", client.Endpoint("courses"), "name", course.Name, "max-capacity", "10", "min-capacity", "1")
+	SetHxRequest(req)
+	resp, err := client.client.Do(req)
+	is.NoErr(err)
+	defer resp.Body.Close()
+
+	is.Equal(resp.StatusCode, http.StatusTooManyRequests)
+	is.True(resp.Header.Get("Retry-After") != "") // clients should know when to try again
+	body, err := unmarshalTextOf(resp.Body, "limit-message")
+	is.NoErr(err)
+	is.True(strings.Contains(body, "Zu viele Anfragen")) // a dialog should explain the refusal
+
+	is.Equal(len(client.CoursesIndexAction()), 3) // reading is not limited
+
+	other := NewTestClient(t, localhost)
+	other.CoursesCreateAction(ui.RandomCourse(), nil) // other sessions have their own limit
+}
+
+func TestRequestsBeyondIPRateLimitAreRefused(t *testing.T) {
+	is := is.New(t)
+	sut := StartupSystemUnderTestWithEnv(t, "PRIOBAER_IP_RATE_LIMIT", "2")
+	defer waitForTerminationDefault(sut.cancel)
+
+	client := NewTestClient(t, localhost) // starting the session is the first request
+	client.CoursesCreateAction(ui.RandomCourse(), nil)
+
+	course := ui.RandomCourse()
+	req := client.RequestWithFormBody("POST", client.Endpoint("courses"), "name", course.Name, "max-capacity", "10", "min-capacity", "1")
+	resp, err := client.client.Do(req)
+	is.NoErr(err)
+	resp.Body.Close()
+
+	is.Equal(resp.StatusCode, http.StatusTooManyRequests)
+}
+
+func TestPassphraseAttemptsBeyondRateLimitAreRefused(t *testing.T) {
+	is := is.New(t)
+	sut := StartupSystemUnderTestWithEnv(t, "PRIOBAER_PASSPHRASE_RATE_LIMIT", "2")
+	defer waitForTerminationDefault(sut.cancel)
+
+	client := NewTestClient(t, localhost)
+	code := client.RecoveryCodeAction("ein langes Passwort") // deriving the key of the code is the first attempt
+
+	otherBrowser := NewTestClient(t, localhost)
+	is.Equal(otherBrowser.SessionResumeAction(code, "ein falsches Passwort"), http.StatusUnprocessableEntity)
+	is.Equal(otherBrowser.SessionResumeAction(code, "ein langes Passwort"), http.StatusTooManyRequests)
+
+	client.CoursesCreateAction(ui.RandomCourse(), nil) // other requests are not limited
+}
+
+func TestIPRateLimitUsesClientAddressForwardedByTrustedProxy(t *testing.T) {
+	testcases := []struct {
+		name           string
+		trustedProxies string
+		wantStatus     int
+	}{
+		{"Trusted proxy", "127.0.0.1", http.StatusSeeOther},
+		{"Untrusted proxy", "", http.StatusTooManyRequests},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			is := is.New(t)
+			sut := StartupSystemUnderTestWithEnv(t, "PRIOBAER_IP_RATE_LIMIT", "1", "PRIOBAER_TRUSTED_PROXIES", tc.trustedProxies)
+			defer waitForTerminationDefault(sut.cancel)
+
+			var statusCodes []int
+			for _, clientAddress := range []string{"203.0.113.1", "203.0.113.2"} {
+				client := NewTestClientWithoutSession(t, localhost)
+				req, err := http.NewRequest(http.MethodPost, client.Endpoint("sessions"), nil)
+				is.NoErr(err)
+				req.Header.Set("X-Forwarded-For", clientAddress)
+
+				resp, err := client.client.Do(req)
+				is.NoErr(err)
+				resp.Body.Close()
+				statusCodes = append(statusCodes, resp.StatusCode)
+			}
+
+			is.Equal(statusCodes[0], http.StatusSeeOther)
+			is.Equal(statusCodes[1], tc.wantStatus) // clients behind a trusted proxy have their own limit
+		})
+	}
+}
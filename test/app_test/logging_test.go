@@ -0,0 +1,75 @@
+package apptest
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestResponsesCarryRequestId(t *testing.T) {
+	is := is.New(t)
+	sut := StartupSystemUnderTest(t, nil)
+	defer waitForTerminationDefault(sut.cancel)
+
+	client := NewTestClient(t, localhost)
+	first := client.requestId("")
+	second := client.requestId("")
+
+	is.True(first != "")
+	is.True(first != second) // every request gets its own id
+}
+
+func TestRequestIdsAreOnlyTakenOverFromTrustedProxies(t *testing.T) {
+	testcases := []struct {
+		name           string
+		trustedProxies string
+		sentId         string
+		wantSentId     bool
+	}{
+		{"Trusted proxy", "127.0.0.1", "proxy-request-1", true},
+		{"Untrusted proxy", "", "proxy-request-1", false},
+		{"Invalid id of trusted proxy", "127.0.0.1", "proxy request\t1", false},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			is := is.New(t)
+			sut := StartupSystemUnderTestWithEnv(t, "PRIOBAER_TRUSTED_PROXIES", tc.trustedProxies)
+			defer waitForTerminationDefault(sut.cancel)
+
+			client := NewTestClient(t, localhost)
+			requestId := client.requestId(tc.sentId)
+
+			is.True(requestId != "")
+			is.Equal(requestId == tc.sentId, tc.wantSentId)
+		})
+	}
+}
+
+func TestLogFormatIsConfigurable(t *testing.T) {
+	is := is.New(t)
+	sut := StartupSystemUnderTestWithEnv(t, "PRIOBAER_LOG_FORMAT", "json")
+	defer waitForTerminationDefault(sut.cancel)
+
+	client := NewTestClient(t, localhost)
+	is.True(client.requestId("") != "")
+}
+
+// requestId sends a request with the given X-Request-Id, if any, and returns the id of the response.
+func (c *TestClient) requestId(sentId string) string {
+	is := is.New(c.T)
+
+	req, err := http.NewRequest(http.MethodGet, c.Endpoint("scenario"), nil)
+	is.NoErr(err)
+	if sentId != "" {
+		req.Header.Set("X-Request-Id", sentId)
+	}
+
+	resp, err := c.client.Do(req)
+	is.NoErr(err)
+	defer resp.Body.Close()
+	is.Equal(resp.StatusCode, http.StatusOK)
+
+	return resp.Header.Get("X-Request-Id")
+}
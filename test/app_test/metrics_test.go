@@ -0,0 +1,98 @@
+package apptest
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+	"softbaer.dev/ass/internal/ui"
+)
+
+const metricsToken = "metrics-token"
+
+func TestMetricsAreNotServedUnlessConfigured(t *testing.T) {
+	is := is.New(t)
+	sut := StartupSystemUnderTest(t, nil)
+	defer waitForTerminationDefault(sut.cancel)
+
+	client := NewTestClient(t, localhost)
+	status, _ := client.metrics(metricsToken)
+	is.True(status != http.StatusOK)
+}
+
+func TestMetricsRequireToken(t *testing.T) {
+	is := is.New(t)
+	sut := StartupSystemUnderTestWithEnv(t, "PRIOBAER_METRICS_TOKEN", metricsToken)
+	defer waitForTerminationDefault(sut.cancel)
+
+	client := NewTestClient(t, localhost)
+	status, _ := client.metrics("")
+	is.Equal(status, http.StatusForbidden)
+	status, _ = client.metrics("wrong-token")
+	is.Equal(status, http.StatusForbidden)
+	status, _ = client.metrics(metricsToken)
+	is.Equal(status, http.StatusOK)
+}
+
+func TestMetricsAreRestrictedToConfiguredNetworks(t *testing.T) {
+	is := is.New(t)
+	sut := StartupSystemUnderTestWithEnv(t, "PRIOBAER_METRICS_NETWORKS", "10.0.0.0/8")
+	defer waitForTerminationDefault(sut.cancel)
+
+	client := NewTestClient(t, localhost)
+	status, _ := client.metrics("")
+	is.Equal(status, http.StatusForbidden) // the tests connect from localhost
+}
+
+func TestMetricsReportUsageWithoutPersonalData(t *testing.T) {
+	is := is.New(t)
+	sut := StartupSystemUnderTestWithEnv(t, "PRIOBAER_METRICS_TOKEN", metricsToken)
+	defer waitForTerminationDefault(sut.cancel)
+
+	client := NewTestClient(t, localhost)
+	course := client.CoursesCreateAction(ui.RandomCourse(ui.WithCapacity(0, 5)), nil)
+	participant := client.ParticipantsCreateAction(ui.RandomParticipant(), []int{course.ID}, nil)
+	client.SolveAssignmentsAction()
+
+	status, body := client.metrics(metricsToken)
+	is.Equal(status, http.StatusOK)
+
+	for _, line := range []string{
+		"priobaer_sessions 1",
+		"priobaer_open_dbs 1",
+		`priobaer_http_requests_total{method="POST",route="/courses",status="200"} 1`,
+		`priobaer_http_requests_total{method="PUT",route="/assignments",status="303"} 1`,
+		`priobaer_solves_total{outcome="ok"}`,
+		`priobaer_solve_duration_seconds_count{outcome="ok"}`,
+		"priobaer_solve_wait_seconds_count",
+		"priobaer_solve_participants_count",
+		"priobaer_db_dir_bytes",
+	} {
+		is.True(strings.Contains(body, line)) // want the metric to be reported
+	}
+
+	is.True(!strings.Contains(body, course.Name))         // course names must not be reported
+	is.True(!strings.Contains(body, participant.Prename)) // participant names must not be reported
+	is.True(!strings.Contains(body, participant.Surname))
+}
+
+func (c *TestClient) metrics(token string) (int, string) {
+	is := is.New(c.T)
+
+	req, err := http.NewRequest("GET", c.Endpoint("metrics"), nil)
+	is.NoErr(err)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.client.Do(req)
+	is.NoErr(err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	is.NoErr(err)
+
+	return resp.StatusCode, string(body)
+}
@@ -0,0 +1,79 @@
+package apptest
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+	"softbaer.dev/ass/internal/ui"
+)
+
+func TestCreatingCourseBeyondQuotaShowsDialog(t *testing.T) {
+	is := is.New(t)
+	sut := StartupSystemUnderTestWithEnv(t, "PRIOBAER_MAX_COURSES", "1")
+	defer waitForTerminationDefault(sut.cancel)
+
+	client := NewTestClient(t, localhost)
+	client.CoursesCreateAction(ui.RandomCourse(), nil)
+
+	course := ui.RandomCourse()
+	req := client.RequestWithFormBody(
+		http.MethodPost, client.Endpoint("courses"),
+		"name", course.Name,
+		"max-capacity", strconv.Itoa(course.MaxCapacity),
+		"min-capacity", strconv.Itoa(course.MinCapacity),
+	)
+	SetHxRequest(req)
+
+	resp, err := client.client.Do(req)
+	is.NoErr(err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	is.NoErr(err)
+
+	is.Equal(resp.StatusCode, http.StatusUnprocessableEntity)
+	is.True(strings.Contains(string(body), "höchstens 1 Kurse")) // dialog should name the exceeded quota
+	is.Equal(len(client.CoursesIndexAction()), 1)                // course beyond the quota should not be saved
+}
+
+func TestCreatingSessionBeyondMaximumIsRefused(t *testing.T) {
+	is := is.New(t)
+	sut := StartupSystemUnderTestWithEnv(t, "PRIOBAER_MAX_SESSIONS", "1")
+	defer waitForTerminationDefault(sut.cancel)
+
+	NewTestClient(t, localhost)
+
+	refused := NewTestClientWithoutSession(t, localhost)
+	resp, err := refused.client.Post(refused.Endpoint("sessions"), "application/x-www-form-urlencoded", nil)
+	is.NoErr(err)
+	defer resp.Body.Close()
+
+	is.Equal(resp.StatusCode, http.StatusServiceUnavailable)
+	is.Equal(len(resp.Cookies()), 0) // refused users should not get a session
+
+	dbFilesCount, err := countSQLiteFiles(sut.dbDir)
+	is.NoErr(err)
+	is.Equal(dbFilesCount, 1)
+}
+
+func TestCreatingSessionBeyondMaximumEvictsOldest(t *testing.T) {
+	is := is.New(t)
+	sut := StartupSystemUnderTestWithEnv(t, "PRIOBAER_MAX_SESSIONS", "1", "PRIOBAER_EVICT_OLDEST_SESSION", "true")
+	defer waitForTerminationDefault(sut.cancel)
+
+	oldest := NewTestClient(t, localhost)
+	oldest.CoursesCreateAction(ui.RandomCourse(), nil)
+
+	newest := NewTestClient(t, localhost)
+	newest.CoursesCreateAction(ui.RandomCourse(), nil)
+
+	dbFilesCount, err := countSQLiteFiles(sut.dbDir)
+	is.NoErr(err)
+	is.Equal(dbFilesCount, 1) // oldest session should have made room for the newest one
+
+	is.Equal(len(newest.CoursesIndexAction()), 1)
+}
@@ -0,0 +1,199 @@
+package apptest
+
+import (
+	"encoding/base64"
+	"html"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+	"softbaer.dev/ass/internal/ui"
+)
+
+func TestStateChangingRequestsWithoutCSRFTokenAreRefused(t *testing.T) {
+	is := is.New(t)
+	sut := StartupSystemUnderTest(t, nil)
+	defer waitForTerminationDefault(sut.cancel)
+
+	client := NewTestClient(t, localhost)
+	course := ui.RandomCourse()
+
+	req := client.RequestWithFormBody("POST", client.Endpoint("courses"), "name", course.Name, "max-capacity", "10", "min-capacity", "1")
+	resp, err := client.withoutCSRFToken().Do(req)
+	is.NoErr(err)
+	resp.Body.Close()
+	is.Equal(resp.StatusCode, http.StatusForbidden) // cookies alone must not be enough to change data
+
+	req = client.RequestWithFormBody("POST", client.Endpoint("courses"), "name", course.Name, "max-capacity", "10", "min-capacity", "1")
+	req.Header.Set("X-CSRF-Token", "forged")
+	resp, err = client.client.Do(req)
+	is.NoErr(err)
+	resp.Body.Close()
+	is.Equal(resp.StatusCode, http.StatusForbidden) // a token that is not the session's must be refused
+
+	is.Equal(len(client.CoursesIndexAction()), 0)
+}
+
+func TestHtmxRequestWithoutCSRFTokenGetsSwappableDialog(t *testing.T) {
+	is := is.New(t)
+	sut := StartupSystemUnderTest(t, nil)
+	defer waitForTerminationDefault(sut.cancel)
+
+	client := NewTestClient(t, localhost)
+	course := ui.RandomCourse()
+
+	req := client.RequestWithFormBody("POST", client.Endpoint("courses"), "name", course.Name, "max-capacity", "10", "min-capacity", "1")
+	req.Header.Set("HX-Request", "true")
+	resp, err := client.withoutCSRFToken().Do(req)
+	is.NoErr(err)
+	defer resp.Body.Close()
+
+	is.Equal(resp.StatusCode, http.StatusForbidden)
+	is.Equal(resp.Header.Get("HX-Retarget"), "body") // the dialog should not replace the target of the request
+	body, err := io.ReadAll(resp.Body)
+	is.NoErr(err)
+	is.True(strings.Contains(string(body), "<dialog"))
+
+	page, err := client.client.Get(client.Endpoint("scenario"))
+	is.NoErr(err)
+	defer page.Body.Close()
+	pageBody, err := io.ReadAll(page.Body)
+	is.NoErr(err)
+	is.True(strings.Contains(html.UnescapeString(string(pageBody)), `{"code":"403", "swap": true}`)) // htmx should swap 403 responses
+}
+
+func TestCSRFTokenIsAcceptedAsFormField(t *testing.T) {
+	is := is.New(t)
+	sut := StartupSystemUnderTest(t, nil)
+	defer waitForTerminationDefault(sut.cancel)
+
+	client := NewTestClient(t, localhost)
+	course := ui.RandomCourse()
+
+	req := client.RequestWithFormBody("POST", client.Endpoint("courses"), "name", course.Name, "max-capacity", "10", "min-capacity", "1", "csrf_token", client.csrfToken())
+	resp, err := client.withoutCSRFToken().Do(req)
+	is.NoErr(err)
+	resp.Body.Close()
+	is.Equal(resp.StatusCode, http.StatusSeeOther) // plain forms send the token as form field
+
+	is.Equal(len(client.CoursesIndexAction()), 1)
+}
+
+func TestCreatingSessionRequiresCSRFToken(t *testing.T) {
+	is := is.New(t)
+	sut := StartupSystemUnderTest(t, nil)
+	defer waitForTerminationDefault(sut.cancel)
+
+	client := NewTestClientWithoutSession(t, localhost)
+
+	resp, err := client.withoutCSRFToken().Post(client.Endpoint("sessions"), "application/x-www-form-urlencoded", nil)
+	is.NoErr(err)
+	resp.Body.Close()
+
+	is.Equal(resp.StatusCode, http.StatusForbidden) // other sites must not replace the session of a user
+	is.Equal(len(resp.Cookies()), 0)
+}
+
+func TestResponsesCarrySecurityHeaders(t *testing.T) {
+	is := is.New(t)
+	sut := StartupSystemUnderTest(t, nil)
+	defer waitForTerminationDefault(sut.cancel)
+
+	client := NewTestClient(t, localhost)
+
+	for _, path := range []string{"sessions/new", "scenario", "index.js"} {
+		resp, err := client.client.Get(client.Endpoint(path))
+		is.NoErr(err)
+		resp.Body.Close()
+
+		csp := resp.Header.Get("Content-Security-Policy")
+		is.True(strings.Contains(csp, "frame-ancestors 'none'")) // pages must not be embedded by other sites
+		is.True(strings.Contains(csp, "script-src 'self'"))
+		is.True(!strings.Contains(csp, "unsafe-inline"))
+		is.Equal(resp.Header.Get("X-Frame-Options"), "DENY")
+		is.Equal(resp.Header.Get("X-Content-Type-Options"), "nosniff")
+		is.Equal(resp.Header.Get("Referrer-Policy"), "same-origin")
+	}
+}
+
+func TestCookieSecurityModes(t *testing.T) {
+	testcases := []struct {
+		name           string
+		mode           string
+		forwardedProto string
+		wantSecure     bool
+	}{
+		{"Always", "always", "", true},
+		{"Never", "never", "", false},
+		{"Auto via http", "auto", "", false},
+		{"Auto via https at trusted proxy", "auto", "https", true},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			is := is.New(t)
+			sut := StartupSystemUnderTestWithEnv(t, "PRIOBAER_COOKIE_SECURE", tc.mode, "PRIOBAER_TRUSTED_PROXIES", "127.0.0.1")
+			defer waitForTerminationDefault(sut.cancel)
+
+			req, err := http.NewRequest(http.MethodGet, localhost+"/sessions/new", nil)
+			is.NoErr(err)
+			if tc.forwardedProto != "" {
+				req.Header.Set("X-Forwarded-Proto", tc.forwardedProto)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			is.NoErr(err)
+			resp.Body.Close()
+
+			cookies := resp.Cookies()
+			is.Equal(len(cookies), 2) // session and CSRF token
+			for _, cookie := range cookies {
+				is.Equal(cookie.Secure, tc.wantSecure)
+			}
+		})
+	}
+}
+
+func TestForwardedProtoOfUntrustedClientsIsIgnored(t *testing.T) {
+	is := is.New(t)
+	sut := StartupSystemUnderTestWithEnv(t, "PRIOBAER_COOKIE_SECURE", "auto")
+	defer waitForTerminationDefault(sut.cancel)
+
+	req, err := http.NewRequest(http.MethodGet, localhost+"/sessions/new", nil)
+	is.NoErr(err)
+	req.Header.Set("X-Forwarded-Proto", "https")
+
+	resp, err := http.DefaultClient.Do(req)
+	is.NoErr(err)
+	resp.Body.Close()
+
+	for _, cookie := range resp.Cookies() {
+		is.True(!cookie.Secure) // only trusted proxies can tell that the request was made via https
+	}
+}
+
+func TestSessionCookieIsEncrypted(t *testing.T) {
+	is := is.New(t)
+	sut := StartupSystemUnderTest(t, nil)
+	defer waitForTerminationDefault(sut.cancel)
+
+	client := NewTestClientWithoutSession(t, localhost)
+	resp, err := client.client.Post(client.Endpoint("sessions"), "application/x-www-form-urlencoded", nil)
+	is.NoErr(err)
+	defer resp.Body.Close()
+
+	index := slices.IndexFunc(resp.Cookies(), func(cookie *http.Cookie) bool { return cookie.Name == "session" })
+	is.True(index >= 0)
+
+	// Cookies are made of the date, the value in base64 and its signature, which may contain the separator as well.
+	cookie, err := base64.URLEncoding.DecodeString(resp.Cookies()[index].Value)
+	is.NoErr(err)
+	parts := strings.SplitN(string(cookie), "|", 3)
+	is.Equal(len(parts), 3)
+	value, err := base64.URLEncoding.DecodeString(parts[1])
+	is.NoErr(err)
+	is.True(!strings.Contains(string(value), "secret")) // the keys of the session should not be readable
+}
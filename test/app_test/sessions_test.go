@@ -0,0 +1,224 @@
+package apptest
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"softbaer.dev/ass/internal/ui"
+)
+
+func TestDeletingSessionRemovesDbImmediately(t *testing.T) {
+	is := is.New(t)
+	sut := StartupSystemUnderTest(t, nil)
+	defer waitForTerminationDefault(sut.cancel)
+
+	client := NewTestClient(t, localhost)
+	client.CoursesCreateAction(ui.RandomCourse(), nil)
+
+	dbFilesCount, err := countSQLiteFiles(sut.dbDir)
+	is.NoErr(err)
+	is.Equal(dbFilesCount, 1) // there should be exactly one db-file before deletion
+
+	confirmation := client.SessionDeleteAction()
+	is.True(strings.Contains(confirmation, "01.01.2001 um 12:05:00 Uhr")) // confirmation should contain the time of removal
+
+	dbFilesCount, err = countSQLiteFiles(sut.dbDir)
+	is.NoErr(err)
+	is.Equal(dbFilesCount, 0) // db-file should be gone right after deletion
+
+	resp, err := client.client.Get(client.Endpoint("scenario"))
+	is.NoErr(err)
+	defer resp.Body.Close()
+	is.Equal(resp.StatusCode, http.StatusSeeOther) // cleared cookie should lead to a new session
+	is.Equal(resp.Header.Get("Location"), "/sessions/new")
+}
+
+func TestCreatingSessionWithRetentionAboveMaximumFails(t *testing.T) {
+	is := is.New(t)
+	sut := StartupSystemUnderTest(t, nil)
+	defer waitForTerminationDefault(sut.cancel)
+
+	client := NewTestClient(t, localhost)
+
+	req := client.RequestWithFormBody(http.MethodPost, client.Endpoint("sessions"), "retention", "999999999")
+	resp, err := client.client.Do(req)
+	is.NoErr(err)
+	defer resp.Body.Close()
+
+	is.Equal(resp.StatusCode, http.StatusBadRequest)
+}
+
+func TestResumeSessionWithRecoveryCode(t *testing.T) {
+	is := is.New(t)
+	sut := StartupSystemUnderTest(t, nil)
+	defer waitForTerminationDefault(sut.cancel)
+
+	client := NewTestClient(t, localhost)
+	participant := client.ParticipantsCreateAction(ui.RandomParticipant(), nil, nil)
+	code := client.RecoveryCodeAction("ein langes Passwort")
+
+	otherBrowser := NewTestClient(t, localhost)
+	is.Equal(len(otherBrowser.ParticipantsIndexAction()), 0) // other browser should start with its own session
+
+	is.Equal(otherBrowser.SessionResumeAction(code, "ein falsches Passwort"), http.StatusUnprocessableEntity)
+	is.Equal(otherBrowser.SessionResumeAction(code, "ein langes Passwort"), http.StatusSeeOther)
+
+	participants := otherBrowser.ParticipantsIndexAction()
+	is.Equal(len(participants), 1)                         // resumed session should contain the data of the original one
+	is.Equal(participants[0].Prename, participant.Prename) // names should be decryptable with the recovered secret
+}
+
+func TestResumingDeletedSessionFails(t *testing.T) {
+	is := is.New(t)
+	sut := StartupSystemUnderTest(t, nil)
+	defer waitForTerminationDefault(sut.cancel)
+
+	client := NewTestClient(t, localhost)
+	code := client.RecoveryCodeAction("ein langes Passwort")
+	client.SessionDeleteAction()
+
+	otherBrowser := NewTestClient(t, localhost)
+	is.Equal(otherBrowser.SessionResumeAction(code, "ein langes Passwort"), http.StatusUnprocessableEntity) // data of deleted session is gone
+}
+
+func TestLockedSessionRequiresPassphrase(t *testing.T) {
+	is := is.New(t)
+	sut := StartupSystemUnderTest(t, nil)
+	defer waitForTerminationDefault(sut.cancel)
+
+	client := NewTestClient(t, localhost)
+	participant := client.ParticipantsCreateAction(ui.RandomParticipant(), nil, nil)
+
+	is.Equal(client.SetPassphraseAction("ein langes Passwort", "ein anderes Passwort"), http.StatusUnprocessableEntity) // confirmation must match
+	is.Equal(client.SetPassphraseAction("kurz", "kurz"), http.StatusUnprocessableEntity)                                // passphrase must be long enough
+	is.Equal(client.SetPassphraseAction("ein langes Passwort", "ein langes Passwort"), http.StatusSeeOther)
+	is.Equal(client.LockAction(), http.StatusSeeOther)
+
+	resp, err := client.client.Get(client.Endpoint("scenario"))
+	is.NoErr(err)
+	defer resp.Body.Close()
+	is.Equal(resp.StatusCode, http.StatusSeeOther) // locked session should not show any data
+	is.Equal(resp.Header.Get("Location"), "/sessions/unlock")
+
+	is.Equal(client.UnlockAction("ein falsches Passwort"), http.StatusUnprocessableEntity)
+	is.Equal(client.UnlockAction("ein langes Passwort"), http.StatusSeeOther)
+
+	participants := client.ParticipantsIndexAction()
+	is.Equal(len(participants), 1)
+	is.Equal(participants[0].Prename, participant.Prename) // names should be decryptable with the unlocked secret
+}
+
+func TestProtectedSessionIsLockedWhenBrowserIsClosed(t *testing.T) {
+	is := is.New(t)
+	sut := StartupSystemUnderTest(t, nil)
+	defer waitForTerminationDefault(sut.cancel)
+
+	client := NewTestClient(t, localhost)
+	client.ParticipantsCreateAction(ui.RandomParticipant(), nil, nil)
+	is.Equal(client.SetPassphraseAction("ein langes Passwort", "ein langes Passwort"), http.StatusSeeOther)
+	is.Equal(len(client.ParticipantsIndexAction()), 1) // setting a passphrase keeps the session unlocked
+
+	req := client.RequestWithFormBody("POST", client.Endpoint("sessions/unlock"), "passphrase", "ein langes Passwort")
+	resp, err := client.client.Do(req)
+	is.NoErr(err)
+	resp.Body.Close()
+	unlocked := resp.Cookies()[slices.IndexFunc(resp.Cookies(), func(cookie *http.Cookie) bool { return cookie.Name == "unlocked" })]
+	is.Equal(unlocked.MaxAge, 0)       // the secret must not outlive the browser session
+	is.True(unlocked.Expires.IsZero()) // the secret must not outlive the browser session
+
+	client.dropCookie("unlocked") // closing the browser drops cookies without expiration
+
+	resp, err = client.client.Get(client.Endpoint("scenario"))
+	is.NoErr(err)
+	defer resp.Body.Close()
+	is.Equal(resp.StatusCode, http.StatusSeeOther) // the session cookie alone must not hold the secret
+	is.Equal(resp.Header.Get("Location"), "/sessions/unlock")
+
+	is.Equal(client.UnlockAction("ein langes Passwort"), http.StatusSeeOther)
+	is.Equal(len(client.ParticipantsIndexAction()), 1)
+}
+
+func TestLockingSessionWithoutPassphraseFails(t *testing.T) {
+	is := is.New(t)
+	sut := StartupSystemUnderTest(t, nil)
+	defer waitForTerminationDefault(sut.cancel)
+
+	client := NewTestClient(t, localhost)
+	client.ParticipantsCreateAction(ui.RandomParticipant(), nil, nil)
+
+	is.Equal(client.LockAction(), http.StatusConflict) // locking would make the data unreadable for good
+	is.Equal(len(client.ParticipantsIndexAction()), 1)
+}
+
+func TestRekeyReencryptsSessionData(t *testing.T) {
+	is := is.New(t)
+	sut := StartupSystemUnderTest(t, nil)
+	defer waitForTerminationDefault(sut.cancel)
+
+	client := NewTestClient(t, localhost)
+	course := client.CoursesCreateAction(ui.RandomCourse(), nil)
+	participant := client.ParticipantsCreateAction(ui.RandomParticipant(), []int{course.ID}, nil)
+	client.SnapshotsCreateAction("vorher")
+	is.Equal(client.SetPassphraseAction("ein langes Passwort", "ein langes Passwort"), http.StatusSeeOther)
+	outdatedCode := client.RecoveryCodeAction("ein langes Passwort")
+
+	is.Equal(client.RekeyAction("ein falsches Passwort"), http.StatusUnprocessableEntity) // protected sessions need their passphrase
+	is.Equal(client.RekeyAction("ein langes Passwort"), http.StatusSeeOther)
+
+	participants := client.ParticipantsIndexAction()
+	is.Equal(len(participants), 1)
+	is.Equal(participants[0].Prename, participant.Prename) // names should be readable with the new secret
+	is.Equal(client.CoursesIndexAction()[0].Name, course.Name)
+	client.InitialAssignAction(participant.ID, course.ID)
+	client.SnapshotsCreateAction("nachher")
+	snapshots := client.SnapshotsIndexAction()
+	rows := client.SnapshotsCompareAction(snapshots[1].ID, snapshots[0].ID)
+	is.Equal(rows[0], []string{participant.Surname + ", " + participant.Prename, "Nicht zugeteilt", course.Name}) // snapshots should be readable as well
+
+	is.Equal(client.LockAction(), http.StatusSeeOther)
+	is.Equal(client.UnlockAction("ein langes Passwort"), http.StatusSeeOther) // passphrase should unlock the new secret
+	is.Equal(client.CoursesIndexAction()[0].Name, course.Name)
+
+	otherBrowser := NewTestClient(t, localhost)
+	is.Equal(otherBrowser.SessionResumeAction(outdatedCode, "ein langes Passwort"), http.StatusUnprocessableEntity) // old secret is useless now
+}
+
+func TestRecoveryIgnoresPassphraseInQuery(t *testing.T) {
+	is := is.New(t)
+	sut := StartupSystemUnderTest(t, nil)
+	defer waitForTerminationDefault(sut.cancel)
+
+	client := NewTestClient(t, localhost)
+	client.CoursesCreateAction(ui.RandomCourse(), nil)
+
+	resp, err := client.client.Get(client.Endpoint("sessions/recovery") + "?passphrase=ein+langes+Passwort")
+	is.NoErr(err)
+	defer resp.Body.Close()
+	is.Equal(resp.StatusCode, http.StatusOK)
+
+	passphrase, err := unmarshalTextOf(resp.Body, "recovery-passphrase")
+	is.NoErr(err)
+	is.True(passphrase != "")                    // a passphrase should be generated
+	is.True(passphrase != "ein langes Passwort") // the passphrase in the url should not be used
+}
+
+func TestLoadingExpirationBannerDoesNotExtendSession(t *testing.T) {
+	is := is.New(t)
+	fakeClock := defaultFakeClock()
+	sut := StartupSystemUnderTestWithFakeClock(t, nil, fakeClock)
+	defer waitForTerminationDefault(sut.cancel)
+	client := NewTestClient(t, localhost)
+
+	fakeClock.Advance(maxAgeDefault / 2 * time.Second)
+
+	remaining := client.SessionRemainingAction(http.MethodGet, "sessions/expiration")
+	is.Equal(remaining, strconv.Itoa(maxAgeDefault/2)) // loading the banner must not slide the expiration
+
+	remaining = client.SessionRemainingAction(http.MethodPost, "sessions/extend")
+	is.Equal(remaining, strconv.Itoa(maxAgeDefault)) // extending explicitly slides the expiration
+}
@@ -35,7 +35,7 @@ func StartupSystemUnderTestWithFakeClock(t *testing.T, env func(string) string,
 
 	go server.Run(ctx, env, fakeClock)
 
-	err := waitForReady(time.Millisecond*200, 16, localhost+"/health")
+	err := defaultWaitForReady()
 
 	if err != nil {
 		t.Fatalf("Application did not boot in specified time span")
@@ -45,6 +45,22 @@ func StartupSystemUnderTestWithFakeClock(t *testing.T, env func(string) string,
 
 }
 
+// StartupSystemUnderTestWithEnv starts the system with the default env extended by the given key-value pairs.
+func StartupSystemUnderTestWithEnv(t *testing.T, pairs ...string) SystemUnderTest {
+	dbDir := MakeTestingDbDir(t)
+	defaultPairs := []string{"PRIOBAER_DB_ROOT_DIR", dbDir, "PRIOBAER_SESSION_MAX_AGE", strconv.Itoa(maxAgeDefault), "PRIOBAER_PORT", strconv.Itoa(port), "PRIOBAER_SECRET", "secret"}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	go server.Run(ctx, setupMockEnv(append(defaultPairs, pairs...)...), defaultFakeClock())
+
+	if err := defaultWaitForReady(); err != nil {
+		t.Fatalf("Application did not boot in specified time span")
+	}
+
+	return SystemUnderTest{dbDir: dbDir, cancel: cancel}
+}
+
 func MakeTestingDbDir(t *testing.T) string {
 	tempDir := t.TempDir()
 	dbDir := path.Join(tempDir, "db")
@@ -57,7 +73,7 @@ func MakeTestingDbDir(t *testing.T) string {
 }
 
 func defaultWaitForReady() error {
-	return waitForReady(time.Millisecond*200, 100, localhost+"/health")
+	return waitForReady(time.Millisecond*10, 2000, localhost+"/health")
 }
 
 func waitForReady(
@@ -79,6 +95,8 @@ func waitForReady(
 
 		resp, err := client.Do(req)
 		if err != nil {
+			// The server may not listen yet.
+			<-timer.C
 			continue
 		}
 		if resp.StatusCode == http.StatusOK {
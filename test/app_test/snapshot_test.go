@@ -0,0 +1,87 @@
+package apptest
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/matryer/is"
+	"softbaer.dev/ass/internal/ui"
+)
+
+func TestRestoreSnapshot(t *testing.T) {
+	is := is.New(t)
+
+	sut := StartupSystemUnderTest(t, nil)
+	defer waitForTerminationDefault(sut.cancel)
+
+	testClient := NewTestClient(t, localhost)
+
+	course := testClient.CoursesCreateAction(ui.RandomCourse(), nil)
+	participant := testClient.ParticipantsCreateAction(ui.RandomParticipant(), []int{course.ID}, nil)
+	testClient.SnapshotsCreateAction("Vor dem Löschen")
+
+	testClient.CoursesDeleteAction(course.ID)
+	testClient.ParticipantsDeleteAction(participant.ID)
+	testClient.CoursesCreateAction(ui.RandomCourse(), nil)
+
+	snapshots := testClient.SnapshotsIndexAction()
+	is.Equal(len(snapshots), 1)
+	is.Equal(snapshots[0].Name, "Vor dem Löschen")
+
+	testClient.SnapshotsRestoreAction(snapshots[0].ID)
+
+	courses := testClient.CoursesIndexAction()
+	is.Equal(len(courses), 1)              // only the course of the snapshot should exist
+	is.Equal(courses[0].Name, course.Name) // course of the snapshot should be back
+
+	participants := testClient.ParticipantsIndexAction()
+	is.Equal(len(participants), 1)               // participant of the snapshot should be back
+	is.Equal(len(participants[0].Priorities), 1) // priorities of the snapshot should be back
+
+	is.Equal(testClient.UndoAction(), http.StatusSeeOther)
+	is.Equal(testClient.CoursesIndexAction()[0].Name == course.Name, false) // restoring should be undoable
+}
+
+func TestLoadTakesAutomaticSnapshot(t *testing.T) {
+	is := is.New(t)
+
+	sut := StartupSystemUnderTest(t, nil)
+	defer waitForTerminationDefault(sut.cancel)
+
+	testClient := NewTestClient(t, localhost)
+
+	testClient.CreateCoursesWithAllocationsAction([]int{1, 2})
+	saved := testClient.DataSaveAction()
+
+	testClient.DataLoadAction(saved)
+
+	snapshots := testClient.SnapshotsIndexAction()
+	is.Equal(len(snapshots), 1) // loading should take a snapshot of the previous state
+	is.Equal(snapshots[0].Name, "Vor dem Laden einer Excel-Datei")
+	is.Equal(snapshots[0].CourseCount, 2)      // snapshot should contain the previous courses
+	is.Equal(snapshots[0].ParticipantCount, 3) // snapshot should contain the previous participants
+}
+
+func TestCompareSnapshots(t *testing.T) {
+	is := is.New(t)
+
+	sut := StartupSystemUnderTest(t, nil)
+	defer waitForTerminationDefault(sut.cancel)
+
+	testClient := NewTestClient(t, localhost)
+
+	course := testClient.CoursesCreateAction(ui.RandomCourse(), nil)
+	participant := testClient.ParticipantsCreateAction(ui.RandomParticipant(), nil, nil)
+	testClient.ParticipantsCreateAction(ui.RandomParticipant(), nil, nil)
+	testClient.SnapshotsCreateAction("Ohne Zuteilung")
+
+	testClient.InitialAssignAction(participant.ID, course.ID)
+	testClient.SnapshotsCreateAction("Mit Zuteilung")
+
+	snapshots := testClient.SnapshotsIndexAction()
+	is.Equal(len(snapshots), 2)
+
+	rows := testClient.SnapshotsCompareAction(snapshots[1].ID, snapshots[0].ID)
+	is.Equal(len(rows), 1) // only the assigned participant should differ
+	is.Equal(rows[0], []string{participant.Surname + ", " + participant.Prename, "Nicht zugeteilt", course.Name})
+}
@@ -249,3 +249,108 @@ func findEntityDivs(current *html.Node, idContains string) []*html.Node {
 
 	return alreadyFound
 }
+
+// unmarshalListItemsOf returns the inner text of all list items inside the element with the given id.
+func unmarshalListItemsOf(body io.Reader, elementId string) ([]string, error) {
+	rootNode, err := html.Parse(body)
+	if err != nil {
+		return nil, err
+	}
+
+	element := findElementById(rootNode, elementId)
+	if element == nil {
+		return nil, nil
+	}
+
+	var items []string
+	var collect func(node *html.Node)
+	collect = func(node *html.Node) {
+		if node.Type == html.ElementNode && node.Data == "li" && node.FirstChild != nil {
+			items = append(items, getInnerTextData(node))
+			return
+		}
+		for c := node.FirstChild; c != nil; c = c.NextSibling {
+			collect(c)
+		}
+	}
+	collect(element)
+
+	return items, nil
+}
+
+// unmarshalTextOf returns the inner text of the element with the given id or an empty string if there is none.
+func unmarshalTextOf(body io.Reader, elementId string) (string, error) {
+	rootNode, err := html.Parse(body)
+	if err != nil {
+		return "", err
+	}
+
+	element := findElementById(rootNode, elementId)
+	if element == nil || element.FirstChild == nil {
+		return "", nil
+	}
+
+	return getInnerTextData(element), nil
+}
+
+// unmarshalTableRowsOf returns the text of the cells of all table rows inside the element with the given id.
+func unmarshalTableRowsOf(body io.Reader, elementId string) ([][]string, error) {
+	rootNode, err := html.Parse(body)
+	if err != nil {
+		return nil, err
+	}
+
+	element := findElementById(rootNode, elementId)
+	if element == nil {
+		return nil, nil
+	}
+
+	var rows [][]string
+	for row := element.FirstChild; row != nil; row = row.NextSibling {
+		if row.Type != html.ElementNode || row.Data != "tr" {
+			continue
+		}
+
+		var cells []string
+		for cell := row.FirstChild; cell != nil; cell = cell.NextSibling {
+			if cell.Type == html.ElementNode && cell.Data == "td" {
+				cells = append(cells, strings.TrimSpace(getTextContent(cell)))
+			}
+		}
+		rows = append(rows, cells)
+	}
+
+	return rows, nil
+}
+
+// getTextContent returns the text of the node and all its descendants.
+func getTextContent(node *html.Node) string {
+	if node.Type == html.TextNode {
+		return node.Data
+	}
+
+	var text strings.Builder
+	for c := node.FirstChild; c != nil; c = c.NextSibling {
+		text.WriteString(getTextContent(c))
+	}
+
+	return text.String()
+}
+
+func findElementById(current *html.Node, id string) *html.Node {
+	if current.Type == html.ElementNode {
+		for _, attr := range current.Attr {
+			if attr.Key == "id" && attr.Val == id {
+				return current
+			}
+		}
+	}
+
+	for c := current.FirstChild; c != nil; c = c.NextSibling {
+		if found := findElementById(c, id); found != nil {
+			return found
+		}
+	}
+
+	return nil
+}
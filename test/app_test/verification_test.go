@@ -0,0 +1,78 @@
+package apptest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+	"softbaer.dev/ass/internal/domain"
+	"softbaer.dev/ass/internal/model/loadsave"
+	"softbaer.dev/ass/internal/ui"
+)
+
+func TestLoadingOverbookedScenarioShowsWarningOnce(t *testing.T) {
+	is := is.New(t)
+	sut := StartupSystemUnderTest(t, nil)
+	defer waitForTerminationDefault(sut.cancel)
+
+	scenario := domain.EmptyScenario()
+	scenario.AddCourse(domain.CourseData{ID: 1, Name: "overbooked", MinCapacity: 0, MaxCapacity: 1})
+	for pid := range 2 {
+		participant := ui.RandomParticipant()
+		scenario.AddParticipant(domain.ParticipantData{
+			ID:              domain.ParticipantID(pid + 1),
+			ParticipantName: domain.ParticipantName{Prename: participant.Prename, Surname: participant.Surname},
+		})
+		is.NoErr(scenario.Assign(domain.ParticipantID(pid+1), 1))
+	}
+	excelBytes, err := loadsave.SaveScenarioToExcelFile(scenario)
+	is.NoErr(err) // want to export scenario
+
+	client := NewTestClient(t, localhost)
+	client.DataLoadAction(excelBytes)
+
+	warnings := client.ScenarioWarningsAction()
+	is.Equal(len(warnings), 1)                           // want exactly one warning for the overbooked course
+	is.True(strings.Contains(warnings[0], "overbooked")) // want warning to name the course
+
+	is.Equal(len(client.ScenarioWarningsAction()), 0) // want warnings to be shown only once
+}
+
+func TestSolvingProducesNoWarnings(t *testing.T) {
+	is := is.New(t)
+	sut := StartupSystemUnderTest(t, nil)
+	defer waitForTerminationDefault(sut.cancel)
+
+	client := NewTestClient(t, localhost)
+	var courseIds []int
+	for range 2 {
+		courseIds = append(courseIds, client.CoursesCreateAction(ui.RandomCourse(ui.WithCapacity(1, 2)), nil).ID)
+	}
+	for range 3 {
+		client.ParticipantsCreateAction(ui.RandomParticipant(), courseIds, nil)
+	}
+
+	client.SolveAssignmentsAction()
+
+	is.Equal(len(client.ScenarioWarningsAction()), 0) // want a valid solution without any warnings
+}
+
+func TestSolvingAfterDeletingPrioritizedCourseProducesNoWarnings(t *testing.T) {
+	is := is.New(t)
+	sut := StartupSystemUnderTest(t, nil)
+	defer waitForTerminationDefault(sut.cancel)
+
+	client := NewTestClient(t, localhost)
+	var courseIds []int
+	for range 3 {
+		courseIds = append(courseIds, client.CoursesCreateAction(ui.RandomCourse(ui.WithCapacity(0, 1)), nil).ID)
+	}
+	for range 2 {
+		client.ParticipantsCreateAction(ui.RandomParticipant(), courseIds, nil)
+	}
+	client.CoursesDeleteAction(courseIds[1]) // leaves a gap between the first and third priority
+
+	client.SolveAssignmentsAction()
+
+	is.Equal(len(client.ScenarioWarningsAction()), 0) // want the verifier to accept the levels used by the solver
+}
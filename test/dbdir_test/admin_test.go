@@ -0,0 +1,111 @@
+package dbdirtest
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/matryer/is"
+	"softbaer.dev/ass/internal/dbdir"
+)
+
+func TestListSessions_ReportsExpirationAndRowCounts(t *testing.T) {
+	is := is.New(t)
+
+	c := newConfig(t)
+	sut := c.createSut()
+	defer sut.Close()
+
+	conn, err := sut.Open(c.DbId.String())
+	is.NoErr(err)
+	is.NoErr(conn.Create(&testData{Number: 1}).Error)
+	is.NoErr(conn.Create(&testData{Number: 2}).Error)
+
+	writeCorruptDb(c, uuid.NewString(), is)
+
+	infos, err := dbdir.ListSessions(c.TmpDir)
+	is.NoErr(err)
+	is.Equal(len(infos), 2)
+
+	for _, info := range infos {
+		if info.DbId != c.DbId.String() {
+			is.True(info.Err != nil) // corrupt db should be listed as unreadable
+			continue
+		}
+
+		is.NoErr(info.Err)
+		is.True(info.ExpiresAt.Equal(c.FakeClock.Now().Add(c.Expiration)))
+		is.Equal(info.RowCounts["test_data"], int64(2))
+		is.True(info.Size > 0)
+		is.True(!info.Expired(c.FakeClock.Now()))
+	}
+}
+
+func TestPurgeSession_RemovesDb_WhileDirectoryKeepsWorking(t *testing.T) {
+	is := is.New(t)
+
+	c := newConfig(t).withMaxOpen(1)
+	sut := c.createSut()
+	defer sut.Close()
+
+	conn, err := sut.Open(c.DbId.String())
+	is.NoErr(err)
+	is.NoErr(conn.Create(&testData{Number: 1}).Error)
+
+	// Close the connection, so that the directory has to reopen the db.
+	_, err = sut.Open(uuid.NewString())
+	is.NoErr(err)
+
+	is.NoErr(dbdir.PurgeSession(c.TmpDir, c.DbId.String()))
+
+	_, err = os.Stat(fmt.Sprintf("%s/%s.sqlite", c.TmpDir, c.DbId))
+	is.True(os.IsNotExist(err)) // db file should be gone
+
+	reopened, err := sut.Open(c.DbId.String())
+	is.NoErr(err)
+	connHasNoRows(reopened, is)
+}
+
+func TestPurgeSession_ClosesOpenConnectionOfDirectory(t *testing.T) {
+	is := is.New(t)
+
+	c := newConfig(t)
+	sut := c.createSut()
+	defer sut.Close()
+
+	conn, err := sut.Open(c.DbId.String())
+	is.NoErr(err)
+	is.NoErr(conn.Create(&testData{Number: 1}).Error)
+
+	is.NoErr(dbdir.PurgeSession(c.TmpDir, c.DbId.String()))
+
+	reopened, err := sut.Open(c.DbId.String())
+	is.NoErr(err)
+	is.True(reopened != conn) // the connection to the removed files must not be reused
+	connHasNoRows(reopened, is)
+
+	is.True(conn.Create(&testData{Number: 2}).Error != nil) // the old connection should be closed
+}
+
+func TestVacuumSession_KeepsData(t *testing.T) {
+	is := is.New(t)
+
+	c := newConfig(t)
+	sut := c.createSut()
+	defer sut.Close()
+
+	conn, err := sut.Open(c.DbId.String())
+	is.NoErr(err)
+	is.NoErr(conn.Create(&testData{Number: 7}).Error)
+
+	is.NoErr(dbdir.VacuumSession(c.TmpDir, c.DbId.String()))
+
+	var actualData testData
+	is.NoErr(conn.First(&actualData).Error)
+	is.Equal(actualData.Number, 7)
+
+	usage, err := dbdir.DiskUsage(c.TmpDir)
+	is.NoErr(err)
+	is.True(usage > 0)
+}
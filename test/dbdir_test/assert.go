@@ -1,6 +1,8 @@
 package dbdirtest
 
 import (
+	"time"
+
 	"github.com/matryer/is"
 	"gorm.io/gorm"
 )
@@ -12,3 +14,17 @@ func connHasNoRows(conn *gorm.DB, is *is.I) {
 
 	is.Equal(len(datas), 0)
 }
+
+// eventually polls cond until it holds, but gives up after a second. Removals run on timers in the background, so
+// they can take longer than timeWaitingForRemoval when several dbs expire at once.
+func eventually(cond func() bool) bool {
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return true
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	return cond()
+}
@@ -1,9 +1,12 @@
 package dbdirtest
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"log/slog"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -12,6 +15,7 @@ import (
 	"github.com/matryer/is"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+	"softbaer.dev/ass/internal/crypt"
 	"softbaer.dev/ass/internal/dbdir"
 )
 
@@ -144,7 +148,11 @@ func TestNewDbDirectory_RestoresDataAndExpirationFromExistingDbFiles(t *testing.
 	is.Equal(actualData.Number, expectedNumber) // did not got the same number set earlier
 
 	c.FakeClock.Advance(expiration)
-	time.Sleep(timeWaitingForRemoval)
+	removed := eventually(func() bool {
+		_, err := os.Stat(fmt.Sprintf("%s/%s.sqlite", c.TmpDir, c.DbId))
+		return errors.Is(err, os.ErrNotExist)
+	})
+	is.True(removed) // restored db should have been removed at its expiration
 
 	conn3, err := sutNew.Open(c.DbId.String())
 	is.NoErr(err)
@@ -226,3 +234,296 @@ func TestNewDbDirectory_RemovesExpiredDbs(t *testing.T) {
 		t.Fatalf("could not open file for other reason then 'ErrNotExist': %v", err)
 	}
 }
+
+func TestExtend_PostponesRemoval(t *testing.T) {
+	is := is.New(t)
+
+	expiration := 60 * time.Second
+	c := newConfig(t).withExpiration(expiration).withMaxLifetime(10 * expiration)
+
+	sut := c.createSut()
+	defer sut.Close()
+
+	conn1, err := sut.Open(c.DbId.String())
+	is.NoErr(err)
+
+	c.FakeClock.Advance(expiration / 2)
+	extended, err := sut.Extend(c.DbId.String())
+	is.NoErr(err)
+	is.Equal(extended.Remaining, expiration) // extending should slide the expiration to maxAge from now
+	is.True(extended.Extendable())
+
+	c.FakeClock.Advance(expiration / 2)
+	time.Sleep(timeWaitingForRemoval)
+
+	conn2, err := sut.Open(c.DbId.String())
+	is.NoErr(err)
+	is.Equal(conn1, conn2) // db should not be removed at its original expiration
+
+	c.FakeClock.Advance(expiration / 2)
+	time.Sleep(timeWaitingForRemoval)
+
+	conn3, err := sut.Open(c.DbId.String())
+	is.NoErr(err)
+	is.True(conn2 != conn3) // db should be removed at its extended expiration
+}
+
+func TestExtend_NeverExceedsMaxLifetime(t *testing.T) {
+	is := is.New(t)
+
+	expiration := 60 * time.Second
+	maxLifetime := 90 * time.Second
+	c := newConfig(t).withExpiration(expiration).withMaxLifetime(maxLifetime)
+
+	sut := c.createSut()
+	defer sut.Close()
+
+	_, err := sut.Open(c.DbId.String())
+	is.NoErr(err)
+
+	c.FakeClock.Advance(expiration / 2)
+	_, err = sut.Extend(c.DbId.String())
+	is.NoErr(err)
+
+	c.FakeClock.Advance(expiration / 2)
+	extended, err := sut.Extend(c.DbId.String())
+	is.NoErr(err)
+
+	is.Equal(extended.Remaining, maxLifetime-expiration) // expiration should be capped at maxLifetime after creation
+	is.True(!extended.Extendable())
+}
+
+func TestExtend_DoesNothingWithoutMaxLifetime(t *testing.T) {
+	is := is.New(t)
+
+	expiration := 60 * time.Second
+	c := newConfig(t).withExpiration(expiration)
+
+	sut := c.createSut()
+	defer sut.Close()
+
+	_, err := sut.Open(c.DbId.String())
+	is.NoErr(err)
+
+	c.FakeClock.Advance(expiration / 2)
+	extended, err := sut.Extend(c.DbId.String())
+	is.NoErr(err)
+
+	is.Equal(extended.Remaining, expiration/2) // without a max lifetime, dbs expire maxAge after creation
+}
+
+func TestCreate_RejectsRetentionAboveMaxLifetime(t *testing.T) {
+	is := is.New(t)
+
+	c := newConfig(t).withMaxLifetime(time.Hour)
+	sut := c.createSut()
+	defer sut.Close()
+
+	_, err := sut.Create(c.DbId.String(), 2*time.Hour)
+
+	is.True(errors.Is(err, dbdir.ErrInvalidRetention))
+}
+
+func TestRemove_DeletesDbFilesImmediately(t *testing.T) {
+	is := is.New(t)
+
+	c := newConfig(t).withMaxLifetime(time.Hour)
+	sut := c.createSut()
+	defer sut.Close()
+
+	conn1, err := sut.Create(c.DbId.String(), time.Hour)
+	is.NoErr(err)
+	is.NoErr(conn1.Create(&testData{Number: 7}).Error)
+
+	_, err = sut.Remove(c.DbId.String())
+	is.NoErr(err)
+
+	files, err := os.ReadDir(c.TmpDir)
+	is.NoErr(err)
+	is.Equal(len(files), 0) // db file and its side files should be gone
+
+	conn2, err := sut.Open(c.DbId.String())
+	is.NoErr(err)
+	connHasNoRows(conn2, is)
+}
+
+func TestOpen_ClosesLeastRecentlyUsedConnection_BeyondMaxOpen(t *testing.T) {
+	is := is.New(t)
+
+	c := newConfig(t).withMaxOpen(1)
+	sut := c.createSut()
+	defer sut.Close()
+
+	connA, err := sut.Open(c.DbId.String())
+	is.NoErr(err)
+	is.NoErr(connA.Create(&testData{Number: 42}).Error)
+
+	_, err = sut.Open(uuid.NewString())
+	is.NoErr(err)
+
+	sqlDb, err := connA.DB()
+	is.NoErr(err)
+	is.True(sqlDb.Ping() != nil) // connection of A should have been closed
+
+	reopenedA, err := sut.Open(c.DbId.String())
+	is.NoErr(err)
+
+	var actualData testData
+	is.NoErr(reopenedA.First(&actualData).Error)
+	is.Equal(actualData.Number, 42) // data should survive closing the connection
+}
+
+func TestStats_CountsSessionsAndOpenConnections(t *testing.T) {
+	is := is.New(t)
+
+	c := newConfig(t).withMaxOpen(1)
+	sut := c.createSut()
+	defer sut.Close()
+
+	is.Equal(sut.Stats(), dbdir.Stats{})
+
+	_, err := sut.Open(c.DbId.String())
+	is.NoErr(err)
+	_, err = sut.Open(uuid.NewString())
+	is.NoErr(err)
+
+	is.Equal(sut.Stats(), dbdir.Stats{Sessions: 2, OpenConnections: 1}) // the first connection was closed beyond max open
+}
+
+func TestAcquire_KeepsConnectionOpen_UntilReleased(t *testing.T) {
+	is := is.New(t)
+
+	c := newConfig(t).withMaxOpen(1)
+	sut := c.createSut()
+	defer sut.Close()
+
+	connA, release, err := sut.Acquire(c.DbId.String())
+	is.NoErr(err)
+
+	_, err = sut.Open(uuid.NewString())
+	is.NoErr(err)
+
+	sqlDb, err := connA.DB()
+	is.NoErr(err)
+	is.NoErr(sqlDb.Ping()) // acquired connection must not be closed
+
+	release()
+	_, err = sut.Open(uuid.NewString())
+	is.NoErr(err)
+
+	is.True(sqlDb.Ping() != nil) // released connection should be closed
+}
+
+func TestOpen_ClosedDbExpiresOnSchedule(t *testing.T) {
+	is := is.New(t)
+
+	c := newConfig(t).withMaxOpen(1)
+	sut := c.createSut()
+	defer sut.Close()
+
+	connA, err := sut.Open(c.DbId.String())
+	is.NoErr(err)
+	is.NoErr(connA.Create(&testData{Number: 42}).Error)
+
+	_, err = sut.Open(uuid.NewString())
+	is.NoErr(err)
+
+	c.FakeClock.Advance(c.Expiration)
+
+	removed := eventually(func() bool {
+		_, err := os.Stat(fmt.Sprintf("%s/%s.sqlite", c.TmpDir, c.DbId))
+		return errors.Is(err, os.ErrNotExist)
+	})
+	is.True(removed) // closed db should have been removed at its expiration
+}
+
+func TestNewDbDirectory_QuarantinesCorruptDbs(t *testing.T) {
+	is := is.New(t)
+
+	c := newConfig(t)
+
+	sutOld := c.createSut()
+	conn1, err := sutOld.Open(c.DbId.String())
+	is.NoErr(err)
+	is.NoErr(conn1.Create(&testData{Number: 282}).Error)
+	errs := sutOld.Close()
+	is.Equal(len(errs), 0) // closing sut yielded some errors
+
+	corruptId := uuid.NewString()
+	writeCorruptDb(c, corruptId, is)
+
+	sutNew := c.createSut()
+	defer sutNew.Close()
+
+	conn2, err := sutNew.Open(c.DbId.String())
+	is.NoErr(err)
+
+	var actualData testData
+	is.NoErr(conn2.First(&actualData).Error)
+	is.Equal(actualData.Number, 282) // intact db should be restored next to the corrupt one
+
+	_, err = os.Stat(fmt.Sprintf("%s/%s.sqlite", c.TmpDir, corruptId))
+	is.True(errors.Is(err, os.ErrNotExist)) // corrupt db should have been moved out of the root dir
+
+	quarantined, err := os.ReadDir(fmt.Sprintf("%s/quarantine", c.TmpDir))
+	is.NoErr(err)
+	is.Equal(len(quarantined), 1)
+}
+
+func TestNewDbDirectory_LogsPseudonymOfCorruptDb(t *testing.T) {
+	is := is.New(t)
+
+	var logs bytes.Buffer
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+	defer slog.SetDefault(defaultLogger)
+
+	secret := crypt.GenerateSecret()
+	c := newConfig(t).withPseudonymSecret(secret)
+	corruptId := uuid.NewString()
+	writeCorruptDb(c, corruptId, is)
+
+	sut := c.createSut()
+	defer sut.Close()
+
+	is.True(strings.Contains(logs.String(), "Moved broken db to quarantine"))
+	is.True(strings.Contains(logs.String(), crypt.Pseudonym(corruptId, secret))) // the db should be named by its pseudonym
+	is.True(!strings.Contains(logs.String(), corruptId))                         // the id gives access to the session
+}
+
+func TestNewDbDirectory_RemovesQuarantinedDbs_AtExpiration(t *testing.T) {
+	is := is.New(t)
+
+	c := newConfig(t)
+	writeCorruptDb(c, uuid.NewString(), is)
+
+	sut := c.createSut()
+	defer sut.Close()
+
+	c.FakeClock.Advance(c.Expiration)
+
+	removed := eventually(func() bool {
+		quarantined, err := os.ReadDir(fmt.Sprintf("%s/quarantine", c.TmpDir))
+		return err == nil && len(quarantined) == 0
+	})
+	is.True(removed) // quarantined db should be removed at its expiration
+}
+
+func TestNewDbDirectory_RemovesQuarantinedDbs_ExpiredWhileDown(t *testing.T) {
+	is := is.New(t)
+
+	c := newConfig(t)
+	writeCorruptDb(c, uuid.NewString(), is)
+
+	sutOld := c.createSut()
+	sutOld.Close()
+
+	c.FakeClock.Advance(c.Expiration)
+
+	sutNew := c.createSut()
+	defer sutNew.Close()
+
+	quarantined, err := os.ReadDir(fmt.Sprintf("%s/quarantine", c.TmpDir))
+	is.NoErr(err)
+	is.Equal(len(quarantined), 0) // quarantined db should be removed on startup, because it expired
+}
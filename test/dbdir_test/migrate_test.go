@@ -0,0 +1,95 @@
+package dbdirtest
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/matryer/is"
+	"gorm.io/gorm"
+	"softbaer.dev/ass/internal/dbdir"
+	"softbaer.dev/ass/internal/model"
+)
+
+// legacyDbPath points to a db created before versioned migrations and before names of participants were encrypted.
+const legacyDbPath = "../db_test/db.sqlite"
+
+func TestOpen_MigratesDbOfOlderVersion(t *testing.T) {
+	is := is.New(t)
+
+	c := newConfig(t).withMigrations(model.Migrations)
+	c.Models = nil
+	copyLegacyDb(c, is)
+
+	sut := c.createSut()
+	defer sut.Close()
+
+	conn, err := sut.Open(c.DbId.String())
+	is.NoErr(err)
+
+	var version int
+	is.NoErr(conn.Raw("SELECT MAX(version) FROM schema_versions").Scan(&version).Error)
+	is.Equal(version, len(model.Migrations)) // all migrations should be applied
+
+	is.True(!conn.Migrator().HasColumn("participants", "prename"))
+	is.True(conn.Migrator().HasColumn("participants", "plain_prename")) // plaintext names should be kept until they are encrypted
+	is.True(conn.Migrator().HasColumn("participants", "encrypted_prename"))
+
+	var plainNames int64
+	is.NoErr(conn.Table("participants").Where("plain_prename IS NOT NULL").Count(&plainNames).Error)
+	is.Equal(plainNames, int64(1000)) // no name should be lost
+
+	var courseCount, participantCount, priorityCount int64
+	is.NoErr(conn.Model(&model.Course{}).Count(&courseCount).Error)
+	is.NoErr(conn.Model(model.EmptyParticipantPointer()).Count(&participantCount).Error)
+	is.NoErr(conn.Model(&model.Priority{}).Count(&priorityCount).Error)
+	is.Equal(courseCount, int64(50)) // courses should survive the migration
+	is.Equal(participantCount, int64(1000))
+	is.True(priorityCount > 0)
+}
+
+func TestOpen_AppliesMigrationsOnlyOnce(t *testing.T) {
+	is := is.New(t)
+
+	runs := 0
+	migrations := []dbdir.Migration{{Version: 1, Description: "count runs", Up: func(tx *gorm.DB) error {
+		runs++
+		return tx.AutoMigrate(&testData{})
+	}}}
+
+	c := newConfig(t).withMigrations(migrations)
+	c.Models = nil
+
+	sutOld := c.createSut()
+	_, err := sutOld.Open(c.DbId.String())
+	is.NoErr(err)
+	sutOld.Close()
+
+	sutNew := c.createSut()
+	defer sutNew.Close()
+	_, err = sutNew.Open(c.DbId.String())
+	is.NoErr(err)
+
+	is.Equal(runs, 1) // migration should not run again for a migrated db
+}
+
+func TestMigrate_RejectsDbOfNewerVersion(t *testing.T) {
+	is := is.New(t)
+
+	db, err := dbdir.NewDb(fmt.Sprintf("%s/newer.sqlite", t.TempDir()), nil)
+	is.NoErr(err)
+
+	noop := func(tx *gorm.DB) error { return nil }
+	is.NoErr(dbdir.Migrate(db, []dbdir.Migration{{Version: 1, Up: noop}, {Version: 2, Up: noop}}))
+
+	err = dbdir.Migrate(db, []dbdir.Migration{{Version: 1, Up: noop}})
+	is.True(errors.Is(err, dbdir.ErrUnknownSchemaVersion))
+}
+
+func copyLegacyDb(c *config, is *is.I) {
+	data, err := os.ReadFile(legacyDbPath)
+	is.NoErr(err)
+
+	is.NoErr(os.WriteFile(fmt.Sprintf("%s/%s.sqlite", c.TmpDir, c.DbId), data, 0o600))
+}
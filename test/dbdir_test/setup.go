@@ -1,12 +1,16 @@
 package dbdirtest
 
 import (
+	"fmt"
+	"os"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jonboulle/clockwork"
+	"github.com/matryer/is"
 	"gorm.io/gorm"
+	"softbaer.dev/ass/internal/crypt"
 	"softbaer.dev/ass/internal/dbdir"
 )
 
@@ -15,8 +19,18 @@ type config struct {
 	FakeClock  clockwork.FakeClock
 	DbId       uuid.UUID
 	Expiration time.Duration
-	Models     []any
-	T          *testing.T
+	// MaxLifetime is passed to the sut via dbdir.WithMaxLifetime if it is set.
+	MaxLifetime time.Duration
+	// MaxOpen is passed to the sut via dbdir.WithMaxOpenConnections if it is set.
+	MaxOpen int
+	// Storage is passed to the sut via dbdir.WithStorage if it is set.
+	Storage dbdir.Storage
+	// Migrations are passed to the sut via dbdir.WithMigrations if they are set.
+	Migrations []dbdir.Migration
+	// PseudonymSecret is passed to the sut via dbdir.WithPseudonymSecret if it is set.
+	PseudonymSecret crypt.Secret
+	Models          []any
+	T               *testing.T
 }
 
 type testData struct {
@@ -41,8 +55,55 @@ func (c *config) withExpiration(e time.Duration) *config {
 	return c
 }
 
+func (c *config) withMaxLifetime(l time.Duration) *config {
+	c.MaxLifetime = l
+
+	return c
+}
+
+func (c *config) withMaxOpen(n int) *config {
+	c.MaxOpen = n
+
+	return c
+}
+
+func (c *config) withMigrations(migrations []dbdir.Migration) *config {
+	c.Migrations = migrations
+
+	return c
+}
+
+func (c *config) withPseudonymSecret(secret crypt.Secret) *config {
+	c.PseudonymSecret = secret
+
+	return c
+}
+
+func (c *config) withStorage(storage dbdir.Storage) *config {
+	c.Storage = storage
+
+	return c
+}
+
 func (c *config) createSut() *dbdir.DbDirectory {
-	sut, err := dbdir.New(c.TmpDir, c.Expiration, c.FakeClock, c.Models)
+	var opts []dbdir.Option
+	if c.MaxLifetime != 0 {
+		opts = append(opts, dbdir.WithMaxLifetime(c.MaxLifetime))
+	}
+	if c.MaxOpen != 0 {
+		opts = append(opts, dbdir.WithMaxOpenConnections(c.MaxOpen))
+	}
+	if c.Storage != nil {
+		opts = append(opts, dbdir.WithStorage(c.Storage))
+	}
+	if c.Migrations != nil {
+		opts = append(opts, dbdir.WithMigrations(c.Migrations))
+	}
+	if c.PseudonymSecret != nil {
+		opts = append(opts, dbdir.WithPseudonymSecret(c.PseudonymSecret))
+	}
+
+	sut, err := dbdir.New(c.TmpDir, c.Expiration, c.FakeClock, c.Models, opts...)
 
 	if err != nil {
 		c.T.Fatalf("Could not create sut, err: %v", err)
@@ -50,3 +111,8 @@ func (c *config) createSut() *dbdir.DbDirectory {
 
 	return sut
 }
+
+func writeCorruptDb(c *config, dbId string, is *is.I) {
+	err := os.WriteFile(fmt.Sprintf("%s/%s.sqlite", c.TmpDir, dbId), []byte("definitely not a sqlite file"), 0o600)
+	is.NoErr(err)
+}
@@ -0,0 +1,116 @@
+package dbdirtest
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/matryer/is"
+	"softbaer.dev/ass/internal/dbdir"
+)
+
+// storages returns a fresh instance of every storage, so that the tests below can check they all behave the same.
+func storages(t *testing.T) map[string]dbdir.Storage {
+	return map[string]dbdir.Storage{
+		"file":   dbdir.NewFileStorage(t.TempDir()),
+		"memory": dbdir.NewMemoryStorage(),
+	}
+}
+
+func TestStorage_KeepsDataWhenConnectionIsClosed(t *testing.T) {
+	for name, storage := range storages(t) {
+		t.Run(name, func(t *testing.T) {
+			is := is.New(t)
+
+			c := newConfig(t).withStorage(storage).withMaxOpen(1)
+			sut := c.createSut()
+			defer sut.Close()
+
+			conn, err := sut.Open(c.DbId.String())
+			is.NoErr(err)
+			is.NoErr(conn.Create(&testData{Number: 42}).Error)
+
+			_, err = sut.Open(uuid.NewString())
+			is.NoErr(err)
+
+			reopened, err := sut.Open(c.DbId.String())
+			is.NoErr(err)
+
+			var actualData testData
+			is.NoErr(reopened.First(&actualData).Error)
+			is.Equal(actualData.Number, 42) // data should survive closing the connection
+		})
+	}
+}
+
+func TestStorage_RemovesDbAtExpiration(t *testing.T) {
+	for name, storage := range storages(t) {
+		t.Run(name, func(t *testing.T) {
+			is := is.New(t)
+
+			c := newConfig(t).withStorage(storage)
+			sut := c.createSut()
+			defer sut.Close()
+
+			conn, err := sut.Open(c.DbId.String())
+			is.NoErr(err)
+			is.NoErr(conn.Create(&testData{Number: 42}).Error)
+
+			c.FakeClock.Advance(c.Expiration)
+			is.True(eventually(func() bool { return !storage.Exists(c.DbId.String()) })) // db should be removed at its expiration
+
+			reopened, err := sut.Open(c.DbId.String())
+			is.NoErr(err)
+			connHasNoRows(reopened, is)
+		})
+	}
+}
+
+func TestStorage_RestoresDbsAndExpiration(t *testing.T) {
+	for name, storage := range storages(t) {
+		t.Run(name, func(t *testing.T) {
+			is := is.New(t)
+
+			c := newConfig(t).withStorage(storage)
+			sutOld := c.createSut()
+
+			conn, err := sutOld.Open(c.DbId.String())
+			is.NoErr(err)
+			is.NoErr(conn.Create(&testData{Number: 42}).Error)
+			sutOld.Close()
+
+			c.FakeClock.Advance(c.Expiration / 2)
+
+			sutNew := c.createSut()
+			defer sutNew.Close()
+
+			expiration, err := sutNew.Expiration(c.DbId.String())
+			is.NoErr(err)
+			is.Equal(expiration.Remaining, c.Expiration/2) // expiration should be restored from the db
+
+			c.FakeClock.Advance(c.Expiration / 2)
+			is.True(eventually(func() bool { return !storage.Exists(c.DbId.String()) })) // restored db should expire as before
+		})
+	}
+}
+
+func TestMemoryStorage_SeparatesInstances(t *testing.T) {
+	is := is.New(t)
+
+	dbId := uuid.NewString()
+	first := newConfig(t).withStorage(dbdir.NewMemoryStorage())
+	second := newConfig(t).withStorage(dbdir.NewMemoryStorage())
+
+	sutFirst := first.createSut()
+	defer sutFirst.Close()
+	sutSecond := second.createSut()
+	defer sutSecond.Close()
+
+	conn, err := sutFirst.Open(dbId)
+	is.NoErr(err)
+	is.NoErr(conn.Create(&testData{Number: 42}).Error)
+
+	other, err := sutSecond.Open(dbId)
+	is.NoErr(err)
+	connHasNoRows(other, is) // dbs of different storages should not be shared
+
+}